@@ -2,16 +2,25 @@ package main
 
 import (
 	"SocialMedia/internal/auth"
+	"SocialMedia/internal/blob"
+	"SocialMedia/internal/contentfilter"
+	"SocialMedia/internal/health"
 	"SocialMedia/internal/mailer"
+	"SocialMedia/internal/metrics"
+	"SocialMedia/internal/pubsub"
 	"SocialMedia/internal/ratelimiter"
 	"SocialMedia/internal/store"
 	"SocialMedia/internal/store/cache"
+	"SocialMedia/internal/tracing"
+	"SocialMedia/internal/unfurl"
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -33,18 +42,63 @@ type application struct {
 	mailer        mailer.Client
 	authenticator auth.Authenticator
 	rateLimiter   ratelimiter.Limiter
+	contentFilter *contentfilter.Pipeline
+	signer        *auth.Signer
+	pubsub        pubsub.PubSub
+	blobs         blob.Store
+	unfurler      *unfurl.Unfurler
+	linkQueue     chan []string
+	health        *health.Checker
+	metrics       *metrics.Metrics
+	// Event streams open on this instance, by user
+	eventStreams streamCounter
+	// Set once the server starts shutting down, readiness fails from then on
+	shuttingDown atomic.Bool
 }
 
 type config struct {
-	addr        string
-	db          dbConfig
-	env         string
-	apiURL      string
-	mail        mailConfig
-	frontendURL string
-	auth        authConfig
-	redisCfg    redisConfig
-	rateLimiter ratelimiter.Config
+	addr              string
+	db                dbConfig
+	env               string
+	apiURL            string
+	mail              mailConfig
+	frontendURL       string
+	auth              authConfig
+	redisCfg          redisConfig
+	rateLimiter       ratelimiter.Config
+	rateLimitPolicies rateLimitConfig
+	filter            filterConfig
+	notifications     notificationConfig
+	media             mediaConfig
+	previews          previewConfig
+	tracing           tracing.Config
+	// Proxies whose forwarded client address is trusted, the address of the
+	// peer is used for everyone else
+	trustedProxies []*net.IPNet
+	// Time readiness fails before the server stops taking requests, so the
+	// load balancer stops sending them first
+	shutdownDelay time.Duration
+}
+
+type mediaConfig struct {
+	backend   string // local or s3
+	localDir  string
+	s3        blob.S3Config
+	publicURL string // base URL the stored images are served from
+	// Largest image accepted, the 1MB limit of JSON bodies doesn't apply
+	maxUploadSize int64
+}
+
+type notificationConfig struct {
+	// Secret signing the unsubscribe links
+	unsubscribeSecret string
+	unsubscribeURL    string
+	digest            digestConfig
+}
+
+type filterConfig struct {
+	// Path to the JSON content policy file, empty disables filtering
+	path string
 }
 
 type redisConfig struct {
@@ -52,6 +106,19 @@ type redisConfig struct {
 	pw      string
 	db      int
 	enabled bool
+	local   localCacheConfig
+	breaker breakerConfig
+}
+
+type breakerConfig struct {
+	threshold     int           // consecutive errors before Redis is skipped
+	probeInterval time.Duration // how often Redis is pinged once skipped
+}
+
+// localCacheConfig is the in-memory tier of the users in front of Redis.
+type localCacheConfig struct {
+	size int // users kept per instance, the tier is disabled when 0
+	ttl  time.Duration
 }
 
 type authConfig struct {
@@ -73,13 +140,25 @@ type basicConfig struct {
 type mailConfig struct {
 	exp       time.Duration
 	fromEmail string
+	backend   string // sendgrid, smtp, file or memory
 	sendGrid  sendGridConfig
+	smtp      smtpConfig
+	fileDir   string
+	outbox    outboxConfig
 }
 
 type sendGridConfig struct {
 	apiKey string
 }
 
+type smtpConfig struct {
+	host     string
+	port     int
+	username string
+	password string
+	startTLS bool
+}
+
 type dbConfig struct {
 	addr         string
 	maxOpenConns int
@@ -95,73 +174,171 @@ func (app *application) mount() http.Handler {
 	r.Use(cors.Handler(cors.Options{
 		// AllowedOrigins: []string{"https://foo.com"}, // Use this to allow specific origin hosts
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
 	// A good base middleware stack
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(app.tracingMiddleware)
+	r.Use(app.realIPMiddleware)
+	r.Use(app.metricsMiddleware)
+	r.Use(app.accessLogMiddleware)
 	r.Use(middleware.Recoverer) // Recover from a panics
-	r.Use(app.RateLimiterMiddleware)
-
-	// Set a timeout value on the request context (ctx), that will signal
-	// through ctx.Done() that the request has timed out and further
-	// processing should be stopped.
-	r.Use(middleware.Timeout(60 * time.Second))
 
 	r.Route("/v1", func(r chi.Router) {
-		// Pass the middleware for a particular route.
-		r.With(
-		// app.BasicAuthMiddleware(),
-		).Get("/health", app.healthCheckHandler)
+		// Streaming, the connection must outlive the request timeout
+		r.With(app.streamAuthMiddleware(), app.rateLimit(policyDefault)).Get("/events", app.eventsHandler)
 
-		docsURL := fmt.Sprintf("%s/swagger/doc.json", app.config.addr)
-		r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(docsURL)))
+		r.Group(func(r chi.Router) {
+			// Set a timeout value on the request context (ctx), that will signal
+			// through ctx.Done() that the request has timed out and further
+			// processing should be stopped.
+			r.Use(middleware.Timeout(60 * time.Second))
 
-		r.Route("/posts", func(r chi.Router) {
-			r.Use(app.AuthTokenMiddleware())
+			// Pass the middleware for a particular route.
+			r.With(
+				// app.BasicAuthMiddleware(),
+				app.rateLimit(policyDefault),
+			).Get("/health", app.healthCheckHandler)
 
-			r.Post("/", app.createPostHandler)
+			//? Probed by the orchestrator, not rate limited
+			r.Get("/health/live", app.livenessHandler)
+			r.Get("/health/ready", app.readinessHandler)
 
-			r.Route("/{postID}", func(r chi.Router) {
-				r.Use(app.postsContextMiddleware) // Injecting a middleware here to make fetching for the post easier.
+			r.With(app.BasicAuthMiddleware()).Get("/metrics", app.metrics.Handler().ServeHTTP)
 
-				r.Get("/", app.getPostHandler)
-				r.Delete("/", app.checkPostOwnership("moderator", app.deletePostHandler))
-				r.Patch("/", app.checkPostOwnership("admin", app.patchPostHandler))
+			docsURL := fmt.Sprintf("%s/swagger/doc.json", app.config.addr)
+			r.With(app.rateLimit(policyDefault)).Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(docsURL)))
+
+			r.Route("/posts", func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware())
+				r.Use(app.rateLimit(policyDefault))
+
+				r.With(app.rateLimit(policyWrite)).Post("/", app.createPostHandler)
+
+				r.Route("/{postID}", func(r chi.Router) {
+					r.Use(app.postsContextMiddleware) // Injecting a middleware here to make fetching for the post easier.
+
+					r.Get("/", app.getPostHandler)
+					r.Delete("/", app.checkPostOwnership("moderator", app.deletePostHandler))
+					r.Patch("/", app.checkPostOwnership("admin", app.patchPostHandler))
+
+					r.With(app.rateLimit(policyWrite)).Post("/comments", app.createCommentHandler)
+
+					r.With(app.rateLimit(policyWrite)).Post("/attachments", app.checkPostOwnership("admin", app.uploadAttachmentHandler))
+					r.Delete("/attachments/{attachmentID}", app.checkPostOwnership("moderator", app.deleteAttachmentHandler))
+				})
 			})
-		})
 
-		r.Route("/user", func(r chi.Router) {
-			r.Put("/activate/{token}", app.activateUserHandler)
+			// Public so the images can be embedded, the handler authenticates those of non-public posts
+			r.With(app.rateLimit(policyDefault)).Get("/media/*", app.getMediaHandler)
+
+			r.Route("/user", func(r chi.Router) {
+				r.With(app.rateLimit(policyAuth)).Put("/activate/{token}", app.activateUserHandler)
+
+				r.Route("/{userID}", func(r chi.Router) {
+					r.Use(app.AuthTokenMiddleware())
+					r.Use(app.rateLimit(policyDefault))
+
+					r.Get("/", app.getUserHandler)
+					r.Get("/posts", app.getUserPostsHandler)
+					r.Put("/follow", app.followUserHandler)
+					r.Put("/unfollow", app.unfollowUserHandler)
+					r.Put("/block", app.blockUserHandler)
+					r.Delete("/block", app.unblockUserHandler)
+					r.Put("/mute", app.muteUserHandler)
+					r.Delete("/mute", app.unmuteUserHandler)
+					r.Put("/suspension", app.requireRole("moderator", app.suspendUserHandler))
+					r.Delete("/suspension", app.requireRole("moderator", app.liftSuspensionHandler))
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(app.AuthTokenMiddleware())
+					r.Use(app.rateLimit(policyDefault))
+
+					r.Get("/", app.getUserProfile)
+					r.Patch("/", app.updateUserHandler)
+					r.Get("/feed", app.getUserFeedHandler)
+
+					r.Get("/notification-preferences", app.getNotificationPreferencesHandler)
+					r.Patch("/notification-preferences", app.updateNotificationPreferencesHandler)
+
+					r.Get("/follow-requests", app.getFollowRequestsHandler)
+					r.Put("/follow-requests/{userID}", app.approveFollowRequestHandler)
+					r.Delete("/follow-requests/{userID}", app.rejectFollowRequestHandler)
+				})
+			})
 
-			r.Route("/{userID}", func(r chi.Router) {
+			r.Route("/conversations", func(r chi.Router) {
 				r.Use(app.AuthTokenMiddleware())
+				r.Use(app.rateLimit(policyDefault))
 
-				r.Get("/", app.getUserHandler)
-				r.Put("/follow", app.followUserHandler)
-				r.Put("/unfollow", app.unfollowUserHandler)
+				r.Get("/", app.getConversationsHandler)
+				r.With(app.rateLimit(policyWrite)).Post("/", app.createConversationHandler)
+
+				r.Route("/{conversationID}", func(r chi.Router) {
+					r.Use(app.conversationContextMiddleware)
+
+					r.Get("/", app.getConversationHandler)
+					r.Get("/messages", app.getMessagesHandler)
+					r.With(app.rateLimit(policyWrite)).Post("/messages", app.sendMessageHandler)
+					r.Put("/read", app.markConversationReadHandler)
+				})
 			})
 
-			r.Group(func(r chi.Router) {
+			r.Route("/moderation", func(r chi.Router) {
 				r.Use(app.AuthTokenMiddleware())
+				r.Use(app.rateLimit(policyDefault))
+
+				r.Get("/posts", app.requireRole("moderator", app.getPendingPostsHandler))
+				r.Put("/posts/{postID}", app.requireRole("moderator", app.approvePostHandler))
+				r.Delete("/posts/{postID}", app.requireRole("moderator", app.rejectPostHandler))
 
-				r.Get("/", app.getUserProfile)
-				r.Get("/feed", app.getUserFeedHandler)
+				r.Get("/comments", app.requireRole("moderator", app.getPendingCommentsHandler))
+				r.Put("/comments/{commentID}", app.requireRole("moderator", app.approveCommentHandler))
+				r.Delete("/comments/{commentID}", app.requireRole("moderator", app.rejectCommentHandler))
+
+				r.Get("/messages", app.requireRole("moderator", app.getPendingMessagesHandler))
+				r.Put("/messages/{messageID}", app.requireRole("moderator", app.approveMessageHandler))
+				r.Delete("/messages/{messageID}", app.requireRole("moderator", app.rejectMessageHandler))
 			})
-		})
 
-		// Public routes
-		r.Route("/authentication", func(r chi.Router) {
-			r.Post("/user", app.registerUserHandler)
-			r.Post("/token", app.createTokenHandler)
-		})
+			r.Route("/tags", func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware())
+				r.Use(app.rateLimit(policyDefault))
+
+				r.Get("/trending", app.getTrendingTagsHandler)
+				r.Get("/{tag}/posts", app.getTagPostsHandler)
+			})
 
+			r.Route("/notifications", func(r chi.Router) {
+				// Public so the link in the emails works without logging in
+				r.With(app.rateLimit(policyDefault)).Get("/unsubscribe", app.confirmUnsubscribeHandler)
+				r.With(app.rateLimit(policyDefault)).Post("/unsubscribe", app.unsubscribeHandler)
+
+				r.Group(func(r chi.Router) {
+					r.Use(app.AuthTokenMiddleware())
+					r.Use(app.rateLimit(policyDefault))
+
+					r.Get("/", app.getNotificationsHandler)
+					r.Get("/unread-count", app.getUnreadNotificationsCountHandler)
+					r.Put("/read", app.markAllNotificationsReadHandler)
+					r.Put("/{notificationID}/read", app.markNotificationReadHandler)
+				})
+			})
+
+			// Public routes
+			r.Route("/authentication", func(r chi.Router) {
+				r.Use(app.rateLimit(policyAuth))
+
+				r.Post("/user", app.registerUserHandler)
+				r.Post("/token", app.createTokenHandler)
+			})
+		})
 	})
 
 	return r
@@ -181,6 +358,20 @@ func (app *application) run(mux http.Handler) error {
 		IdleTimeout:  time.Minute,
 	}
 
+	//? Event streams never go idle on their own, end them so the shutdown doesn't wait on them
+	srv.RegisterOnShutdown(func() {
+		app.pubsub.Close()
+	})
+
+	// Background workers, stopped once the server shuts down
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	mailWorkers := app.startMailWorkers(workersCtx)
+	digestJob := app.startDigestJob(workersCtx)
+	unfurlWorkers := app.startUnfurlWorkers(workersCtx)
+	cacheMonitor := app.startCacheMonitor(workersCtx)
+
 	// ? Graceful shutdown implementation
 	shutdown := make(chan error)
 
@@ -191,12 +382,35 @@ func (app *application) run(mux http.Handler) error {
 
 		s := <-quit
 
+		app.logger.Infow("signal caught", "signal", s.String())
+
+		//* Fail readiness while still serving, until the load balancer notices
+		app.shuttingDown.Store(true)
+		time.Sleep(app.config.shutdownDelay)
+
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		app.logger.Infow("signal caught", "signal", s.String())
+		err := srv.Shutdown(ctx)
+
+		stopWorkers()
+
+		done := make(chan struct{})
+		go func() {
+			mailWorkers.Wait()
+			digestJob.Wait()
+			unfurlWorkers.Wait()
+			cacheMonitor.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			app.logger.Warnw("background workers did not stop in time")
+		}
 
-		shutdown <- srv.Shutdown(ctx)
+		shutdown <- err
 	}()
 
 	app.logger.Infow("server has started", "addr", app.config.addr, "env", app.config.env)
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"SocialMedia/internal/blob"
+	"SocialMedia/internal/media"
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	maxAttachmentsPerPost = 4
+	// Room for the form boundaries and the other fields on top of the image
+	multipartOverhead = 64 << 10
+)
+
+var (
+	errTooManyAttachments = fmt.Errorf("a post can't have more than %d attachments", maxAttachmentsPerPost)
+	errImageTooLarge      = errors.New("image is too large")
+)
+
+type AttachmentPayload struct {
+	AltText string `validate:"max=1000"`
+}
+
+// UploadAttachment godoc
+//
+//	@Summary		Attach an image to a post
+//	@Description	Upload a JPEG, PNG or GIF image as multipart form data. The type is detected from the content, the image is re-encoded without its metadata (EXIF location included) and a thumbnail is generated.
+//	@Tags			posts
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			id			path		int					true	"Post ID"
+//	@Param			file		formData	file				true	"Image"
+//	@Param			alt_text	formData	string				false	"Description of the image"
+//	@Success		201			{object}	models.Attachment	"Attached image"
+//	@Failure		400			{object}	error				"Invalid request"
+//	@Failure		404			{object}	error				"Post not found"
+//	@Failure		409			{object}	error				"Too many attachments"
+//	@Failure		413			{object}	error				"Image too large"
+//	@Failure		415			{object}	error				"Unsupported image type"
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/attachments [post]
+func (app *application) uploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	post := getPostFromCtx(r)
+
+	//? Spares processing the image of a post that is already full, the store
+	//? enforces the limit against the concurrent uploads
+	if len(post.Attachments) >= maxAttachmentsPerPost {
+		app.conflictResponse(w, r, errTooManyAttachments)
+		return
+	}
+
+	maxSize := app.config.media.maxUploadSize
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+
+	if err := r.ParseMultipartForm(maxSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			app.payloadTooLargeResponse(w, r, errImageTooLarge)
+		default:
+			app.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxSize {
+		app.payloadTooLargeResponse(w, r, errImageTooLarge)
+		return
+	}
+
+	payload := AttachmentPayload{AltText: r.FormValue("alt_text")}
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	//? Whatever the client claimed, the type is sniffed from the content
+	processed, err := media.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrUnsupportedType):
+			app.unsupportedMediaTypeResponse(w, r, err)
+		case errors.Is(err, media.ErrTooLarge), errors.Is(err, media.ErrTooManyFrames):
+			app.payloadTooLargeResponse(w, r, err)
+		default:
+			app.badRequestResponse(w, r, fmt.Errorf("invalid image: %w", err))
+		}
+		return
+	}
+
+	ctx := r.Context()
+
+	name := fmt.Sprintf("posts/%d/%s", post.ID, uuid.New().String())
+	attachment := &models.Attachment{
+		PostID:       post.ID,
+		Key:          name + processed.Original.Ext,
+		ThumbnailKey: name + "_thumb" + processed.Thumbnail.Ext,
+		ContentType:  processed.Original.ContentType,
+		Size:         int64(len(processed.Original.Data)),
+		Width:        processed.Original.Width,
+		Height:       processed.Original.Height,
+		AltText:      payload.AltText,
+	}
+	attachment.URL = app.mediaURL(attachment.Key)
+	attachment.ThumbnailURL = app.mediaURL(attachment.ThumbnailKey)
+
+	if err := app.putImage(ctx, attachment.Key, processed.Original); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.putImage(ctx, attachment.ThumbnailKey, processed.Thumbnail); err != nil {
+		app.deleteBlobs(ctx, attachment.Key)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.Attachments.Create(ctx, attachment, maxAttachmentsPerPost); err != nil {
+		app.deleteBlobs(ctx, attachment.Key, attachment.ThumbnailKey)
+
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errTooManyAttachments)
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidatePost(ctx, post)
+
+	if err := app.jsonResponse(w, http.StatusCreated, attachment); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// DeleteAttachment godoc
+//
+//	@Summary		Delete an attachment
+//	@Description	Remove an image from a post along with its stored files.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"Post ID"
+//	@Param			attachmentID	path		int		true	"Attachment ID"
+//	@Success		204				{string}	string	"Attachment deleted"
+//	@Failure		400				{object}	error	"Invalid request"
+//	@Failure		404				{object}	error	"Attachment not found"
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/attachments/{attachmentID} [delete]
+func (app *application) deleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	post := getPostFromCtx(r)
+
+	attachmentID, err := strconv.ParseInt(chi.URLParam(r, "attachmentID"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	attachment, err := app.store.Attachments.Delete(ctx, post.ID, attachmentID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidatePost(ctx, post)
+	app.deleteBlobs(ctx, attachment.Key, attachment.ThumbnailKey)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetMedia godoc
+//
+//	@Summary		Get a stored image
+//	@Description	Serve an attached image or its thumbnail. The URLs are returned with the attachments of the posts. The images of posts that aren't public need the token of a user who can view the post, in the Authorization header or the access_token query parameter.
+//	@Tags			media
+//	@Produce		image/jpeg,image/png,image/gif
+//	@Param			key				path		string	true	"Key of the image"
+//	@Param			access_token	query		string	false	"JWT of the viewer, for the images of posts that aren't public"
+//	@Success		200				{file}		file	"Image"
+//	@Failure		401				{object}	error	"Unauthorized"
+//	@Failure		404				{object}	error	"Image not found"
+//	@Router			/media/{key} [get]
+func (app *application) getMediaHandler(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	ctx := r.Context()
+
+	postID, err := app.store.Attachments.GetPostIDByKey(ctx, key)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	post, err := app.getPost(ctx, postID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	public, err := app.isPublicPost(ctx, post)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// Keys are random and never reused so the images of public posts can be cached for good
+	if public {
+		app.serveMedia(w, r, key, "public, max-age=31536000, immutable")
+		return
+	}
+
+	//? Images are embedded in tags that can't set headers, the token can come in the query
+	app.streamAuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, err := app.canViewPost(r.Context(), getUserFromCtx(r), post)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if !allowed {
+			app.notFoundResponse(w, r, store.ErrNotFound)
+			return
+		}
+
+		app.serveMedia(w, r, key, "private, no-cache")
+	})).ServeHTTP(w, r)
+}
+
+// isPublicPost reports whether anyone, signed in or not, can view the post.
+func (app *application) isPublicPost(ctx context.Context, post *models.Post) (bool, error) {
+	if post.Status != models.StatusPublished || post.Visibility == models.VisibilityFollowers {
+		return false, nil
+	}
+
+	author, err := app.getUser(ctx, post.UserID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return !author.IsPrivate, nil
+}
+
+func (app *application) serveMedia(w http.ResponseWriter, r *http.Request, key string, cacheControl string) {
+	obj, err := app.blobs.Get(r.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, blob.ErrNotFound), errors.Is(err, blob.ErrInvalidKey):
+			app.notFoundResponse(w, r, store.ErrNotFound)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	defer obj.Body.Close()
+
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if obj.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, obj.Body); err != nil {
+		app.requestLogger(r.Context()).Warnw("error streaming media", "key", key, "error", err.Error())
+	}
+}
+
+func (app *application) putImage(ctx context.Context, key string, img media.Image) error {
+	return app.blobs.Put(ctx, key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType)
+}
+
+// deleteBlobs is best effort, a leftover file is only wasted space.
+func (app *application) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := app.blobs.Delete(ctx, key); err != nil && !errors.Is(err, blob.ErrNotFound) {
+			app.requestLogger(ctx).Errorw("error deleting media", "key", key, "error", err.Error())
+		}
+	}
+}
+
+func (app *application) mediaURL(key string) string {
+	return strings.TrimSuffix(app.config.media.publicURL, "/") + "/" + key
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAttachments(t *testing.T) {
+	app := newTestApplication(t, config{
+		media: mediaConfig{
+			publicURL:     "http://localhost:8080/v1/media",
+			maxUploadSize: 256 << 10,
+		},
+	})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 640, 480))); err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(t *testing.T, postID string, data []byte) (*http.Response, *models.Attachment) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+
+		// The declared type is ignored, the content is sniffed
+		part, err := form.CreateFormFile("file", "photo.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+		form.WriteField("alt_text", "a grey square")
+		form.Close()
+
+		req, err := http.NewRequest(http.MethodPost, "/v1/posts/"+postID+"/attachments", &body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+
+		rr := executeRequest(req, mux)
+
+		var envelope struct {
+			Data models.Attachment `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&envelope)
+
+		return rr.Result(), &envelope.Data
+	}
+
+	getAs := func(t *testing.T, url string, token string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, strings.TrimPrefix(url, "http://localhost:8080"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		return executeRequest(req, mux).Result()
+	}
+
+	get := func(t *testing.T, url string) *http.Response {
+		return getAs(t, url, "")
+	}
+
+	t.Run("should store the image and its thumbnail", func(t *testing.T) {
+		res, attachment := upload(t, "1", img.Bytes())
+		checkResponseCode(t, http.StatusCreated, res.StatusCode)
+
+		if attachment.ContentType != "image/png" || attachment.Width != 640 || attachment.Height != 480 || attachment.AltText != "a grey square" {
+			t.Fatalf("unexpected attachment %+v", attachment)
+		}
+
+		res = get(t, attachment.URL)
+		checkResponseCode(t, http.StatusOK, res.StatusCode)
+		if ct := res.Header.Get("Content-Type"); ct != "image/png" {
+			t.Errorf("expected the image to be served as a png, got %s", ct)
+		}
+		if cc := res.Header.Get("Cache-Control"); !strings.HasPrefix(cc, "public") {
+			t.Errorf("expected the image of a public post to be cached publicly, got %s", cc)
+		}
+
+		res = get(t, attachment.ThumbnailURL)
+		checkResponseCode(t, http.StatusOK, res.StatusCode)
+
+		thumbnail, err := png.DecodeConfig(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if thumbnail.Width != 320 || thumbnail.Height != 240 {
+			t.Errorf("expected a 320x240 thumbnail, got %dx%d", thumbnail.Width, thumbnail.Height)
+		}
+	})
+
+	t.Run("should reject what isn't an image", func(t *testing.T) {
+		res, _ := upload(t, "2", []byte("#!/bin/sh\necho definitely a photo"))
+		checkResponseCode(t, http.StatusUnsupportedMediaType, res.StatusCode)
+	})
+
+	t.Run("should reject images over the size limit", func(t *testing.T) {
+		res, _ := upload(t, "2", bytes.Repeat(img.Bytes(), 500))
+		checkResponseCode(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	t.Run("should limit the attachments of a post", func(t *testing.T) {
+		for range maxAttachmentsPerPost {
+			res, _ := upload(t, "3", img.Bytes())
+			checkResponseCode(t, http.StatusCreated, res.StatusCode)
+		}
+
+		res, _ := upload(t, "3", img.Bytes())
+		checkResponseCode(t, http.StatusConflict, res.StatusCode)
+	})
+
+	t.Run("should limit the concurrent uploads", func(t *testing.T) {
+		var wg sync.WaitGroup
+		codes := make(chan int, 2*maxAttachmentsPerPost)
+
+		for range 2 * maxAttachmentsPerPost {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				res, _ := upload(t, "7", img.Bytes())
+				codes <- res.StatusCode
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		created := 0
+		for code := range codes {
+			switch code {
+			case http.StatusCreated:
+				created++
+			case http.StatusConflict:
+			default:
+				t.Errorf("unexpected status code %d", code)
+			}
+		}
+
+		if created != maxAttachmentsPerPost {
+			t.Errorf("expected %d attachments, got %d", maxAttachmentsPerPost, created)
+		}
+	})
+
+	t.Run("should delete the stored files", func(t *testing.T) {
+		_, attachment := upload(t, "4", img.Bytes())
+
+		req, err := http.NewRequest(http.MethodDelete, "/v1/posts/4/attachments/"+strconv.FormatInt(attachment.ID, 10), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		checkResponseCode(t, http.StatusNoContent, executeRequest(req, mux).Code)
+		checkResponseCode(t, http.StatusNotFound, get(t, attachment.URL).StatusCode)
+		checkResponseCode(t, http.StatusNotFound, get(t, attachment.ThumbnailURL).StatusCode)
+
+		// Already gone
+		checkResponseCode(t, http.StatusNotFound, executeRequest(req, mux).Code)
+	})
+
+	t.Run("should only serve the images of a post to its viewers", func(t *testing.T) {
+		posts := app.store.Posts.(*store.MockPostStore)
+		posts.Posts = map[int64]models.Post{
+			5: {ID: 5, UserID: 1, Status: models.StatusPublished, Visibility: models.VisibilityFollowers},
+			6: {ID: 6, UserID: 2, Status: models.StatusPublished, Visibility: models.VisibilityFollowers},
+		}
+		defer func() { posts.Posts = nil }()
+
+		_, attachment := upload(t, "5", img.Bytes())
+
+		checkResponseCode(t, http.StatusUnauthorized, get(t, attachment.URL).StatusCode)
+
+		res := getAs(t, attachment.URL, testToken)
+		checkResponseCode(t, http.StatusOK, res.StatusCode)
+		if cc := res.Header.Get("Cache-Control"); !strings.HasPrefix(cc, "private") {
+			t.Errorf("expected the image of a followers only post to be cached privately, got %s", cc)
+		}
+
+		// Not a follower of the author
+		other := &models.Attachment{PostID: 6, Key: "other.png", ThumbnailKey: "other_thumb.png"}
+		if err := app.store.Attachments.Create(context.Background(), other, maxAttachmentsPerPost); err != nil {
+			t.Fatal(err)
+		}
+		checkResponseCode(t, http.StatusNotFound, getAs(t, "/v1/media/other.png", testToken).StatusCode)
+	})
+
+	t.Run("should not serve files outside of the store", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNotFound, get(t, "/v1/media/../../go.mod").StatusCode)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"SocialMedia/internal/store"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -18,6 +19,7 @@ type RegisterUserPayload struct {
 	Username string `json:"username" validate:"required,max=100"`
 	Email    string `json:"email" validate:"required,email,max=255"`
 	Password string `json:"password" validate:"required,min=3,max=72"`
+	Language string `json:"language" validate:"omitempty,bcp47_language_tag"`
 }
 
 type CreateUserTokenPayload struct {
@@ -54,6 +56,7 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	user := &models.User{
 		Username: payload.Username,
 		Email:    payload.Email,
+		Language: payload.Language,
 		Role: models.Role{
 			Name: "user",
 		},
@@ -72,21 +75,8 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	hash := sha256.Sum256([]byte(plainToken))
 	hashToken := hex.EncodeToString(hash[:])
 
-	if err := app.store.Users.CreateAndInvite(ctx, user, hashToken, app.config.mail.exp); err != nil {
-		switch err {
-		case store.ErrDuplicateUsername:
-			app.conflictResponse(w, r, err)
-		case store.ErrDuplicateEmail:
-			app.conflictResponse(w, r, err)
-		default:
-			app.internalServerError(w, r, err)
-		}
-		return
-	}
-
 	activationURL := fmt.Sprintf("%s/confirm/%s", app.config.frontendURL, plainToken)
 
-	isProdEnv := app.config.env == "production"
 	vars := struct {
 		Username      string
 		ActivationURL string
@@ -95,21 +85,35 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		ActivationURL: activationURL,
 	}
 
-	// Mail Integration
-	status, err := app.mailer.Send(mailer.UserWelcomeTemplate, user.Username, user.Email, vars, !isProdEnv)
+	data, err := json.Marshal(vars)
 	if err != nil {
-		app.logger.Errorw("error sending welcome email", "error", err.Error())
+		app.internalServerError(w, r, err)
+		return
+	}
 
-		// rollback user creation if email fails (SAGA pattern)
-		if err := app.store.Users.Delete(ctx, user.ID); err != nil {
-			app.logger.Errorw("error deleting user", "error", err)
-		}
+	//? The invitation is written to the outbox in the same transaction as the user, the mail workers deliver it
+	invitation := &models.OutboxMail{
+		Template: mailer.UserWelcomeTemplate,
+		Username: user.Username,
+		Email:    user.Email,
+		Locale:   user.Language,
+		Data:     data,
+	}
 
-		app.internalServerError(w, r, err)
+	if err := app.store.Users.CreateAndInvite(ctx, user, hashToken, app.config.mail.exp, invitation); err != nil {
+		switch err {
+		case store.ErrDuplicateUsername:
+			app.conflictResponse(w, r, err)
+		case store.ErrDuplicateEmail:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
 		return
 	}
 
-	app.logger.Infow("user creation info", "email status code", status, "token", plainToken)
+	//* The token is only sent by mail, use the file mail backend to read it in development
+	app.requestLogger(ctx).Infow("user created", "user", user.ID, "invitation mail id", invitation.ID)
 
 	if err := app.jsonResponse(w, http.StatusCreated, user); err != nil {
 		app.internalServerError(w, r, err)
@@ -128,6 +132,7 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 //	@Success		200		{string}	string					"Token"
 //	@Failure		400		{object}	error					"Invalid payload"
 //	@Failure		401		{object}	error					"User not found"
+//	@Failure		403		{object}	error					"Account suspended"
 //	@Failure		500		{object}	error
 //	@Router			/authentication/token [post]
 func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Request) {
@@ -162,6 +167,11 @@ func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if user.Suspension.IsActive() {
+		app.accountSuspendedResponse(w, r, user.Suspension)
+		return
+	}
+
 	// generate the token -> add claims
 	claims := jwt.MapClaims{
 		"sub": user.ID,
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"SocialMedia/internal/mailer"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegisterUser(t *testing.T) {
+	cfg := config{
+		frontendURL: "http://localhost:3000",
+	}
+
+	app := newTestApplication(t, cfg)
+	mux := app.mount()
+
+	t.Run("should queue the invitation email instead of sending it", func(t *testing.T) {
+		inbox := app.mailer.(*mailer.InMemoryMailer)
+		inbox.Reset()
+
+		body := strings.NewReader(`{"username":"gopher","email":"gopher@example.com","password":"secret"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/authentication/user", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := executeRequest(req, mux)
+
+		checkResponseCode(t, http.StatusCreated, rr.Code)
+
+		if n := len(inbox.Messages()); n != 0 {
+			t.Errorf("expected the email to be left to the outbox workers, got %d sent", n)
+		}
+	})
+
+	t.Run("should not send an email for an invalid payload", func(t *testing.T) {
+		inbox := app.mailer.(*mailer.InMemoryMailer)
+		inbox.Reset()
+
+		body := strings.NewReader(`{"username":"gopher"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/authentication/user", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := executeRequest(req, mux)
+
+		checkResponseCode(t, http.StatusBadRequest, rr.Code)
+
+		if n := len(inbox.Messages()); n != 0 {
+			t.Errorf("expected no email to be sent, got %d", n)
+		}
+	})
+}
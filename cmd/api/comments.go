@@ -0,0 +1,97 @@
+package main
+
+import (
+	"SocialMedia/internal/contentfilter"
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"SocialMedia/internal/textparse"
+	"net/http"
+)
+
+type CreateCommentPayload struct {
+	Content string `json:"content" validate:"required,max=1000"`
+}
+
+// CreateComment godoc
+//
+//	@Summary		Comment on a post
+//	@Description	Create a comment on a post by id.
+//	@Tags			posts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Post ID"
+//	@Param			body	body		CreateCommentPayload	true	"Request body with comment details"
+//	@Success		201		{object}	models.Comment			"Created comment information"
+//	@Success		202		{object}	models.Comment			"Comment held for review"
+//	@Failure		400		{object}	error					"Invalid request"
+//	@Failure		404		{object}	error					"Post not found"
+//	@Failure		422		{object}	error					"Rejected by the content filter"
+//	@Security		ApiKeyAuth
+//	@Router			/posts/{id}/comments [post]
+func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	post := getPostFromCtx(r)
+
+	var payload CreateCommentPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	visible, err := app.canViewPost(ctx, user, post)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if !visible {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	status, err := app.filterContent(ctx, contentfilter.Content{
+		Kind:   contentfilter.KindComment,
+		UserID: user.ID,
+		Body:   payload.Content,
+	})
+	if err != nil {
+		app.contentRejectedResponse(w, r, err)
+		return
+	}
+
+	comment := &models.Comment{
+		PostID:  post.ID,
+		UserID:  user.ID,
+		Content: payload.Content,
+		Status:  status,
+		User:    *user,
+	}
+
+	if err := app.store.Comments.Create(ctx, comment); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	mentioned := app.indexComment(ctx, comment, textparse.Parse(comment.Content))
+
+	code := http.StatusCreated
+	if comment.Status == models.StatusPendingReview {
+		code = http.StatusAccepted
+	} else {
+		app.notifyComment(ctx, user, post, comment)
+		app.notifyMentions(ctx, user, post, comment.Content, mentioned)
+		app.publishComment(ctx, comment)
+	}
+
+	if err := app.jsonResponse(w, code, comment); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"SocialMedia/internal/contentfilter"
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type conversationKey string
+
+const conversationCtx conversationKey = "conversation"
+
+// eventMessageCreated is streamed to the participants of the conversation
+const eventMessageCreated = "message.created"
+
+type CreateConversationPayload struct {
+	// Users to talk to, a group is created for more than one
+	ParticipantIDs []int64 `json:"participant_ids" validate:"required,min=1,max=9,unique,dive,gt=0"`
+	// Only groups have a title
+	Title string `json:"title" validate:"max=100"`
+}
+
+type SendMessagePayload struct {
+	Content string `json:"content" validate:"required,max=1000"`
+}
+
+type ConversationsPage struct {
+	Conversations []models.Conversation `json:"conversations"`
+	// Empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type MessagesPage struct {
+	Messages []models.Message `json:"messages"`
+	// Empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetConversations godoc
+//
+//	@Summary		List conversations
+//	@Description	List the conversations of the current user with their last message and unread count, the most recently active first. Pass next_cursor as cursor to get the next page. A conversation that gets a message while paging moves to the top, past the cursor, so the next pages skip it: reload the first page to catch up.
+//	@Tags			conversations
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Conversations per page, up to 50"
+//	@Param			cursor	query		string	false	"Cursor of the page"
+//	@Success		200		{object}	ConversationsPage
+//	@Failure		400		{object}	error	"Invalid cursor"
+//	@Security		ApiKeyAuth
+//	@Router			/conversations [get]
+func (app *application) getConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	cq := store.CursorQuery{
+		Limit: 20,
+	}
+
+	cq, err := cq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(cq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	conversations, err := app.store.Conversations.GetByUserID(r.Context(), user.ID, cq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	page := ConversationsPage{
+		Conversations: conversations,
+	}
+
+	//? A full page means there may be more. The cursor is on the time of the last message so
+	//? the conversations that get a new one move up, the pages after the cursor don't see them again
+	if len(conversations) == cq.Limit {
+		last := conversations[len(conversations)-1]
+		page.NextCursor = store.EncodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// CreateConversation godoc
+//
+//	@Summary		Start a conversation
+//	@Description	Start a conversation with one user, or a group with up to 9 others. Two users only have one conversation, it is returned when it already exists.
+//	@Tags			conversations
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		CreateConversationPayload	true	"Participants of the conversation"
+//	@Success		200		{object}	models.Conversation			"Existing conversation"
+//	@Success		201		{object}	models.Conversation			"Created conversation"
+//	@Failure		400		{object}	error						"Invalid request"
+//	@Failure		403		{object}	error						"A participant is blocked"
+//	@Failure		404		{object}	error						"Participant not found"
+//	@Security		ApiKeyAuth
+//	@Router			/conversations [post]
+func (app *application) createConversationHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	var payload CreateConversationPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if slices.Contains(payload.ParticipantIDs, user.ID) {
+		app.badRequestResponse(w, r, errors.New("cannot start a conversation with yourself"))
+		return
+	}
+
+	ctx := r.Context()
+
+	conv := &models.Conversation{
+		CreatorID: user.ID,
+		Title:     payload.Title,
+		IsGroup:   len(payload.ParticipantIDs) > 1 || payload.Title != "",
+	}
+
+	if !conv.IsGroup {
+		existing, err := app.store.Conversations.GetDirect(ctx, user.ID, payload.ParticipantIDs[0])
+		switch err {
+		case nil:
+			if err := app.jsonResponse(w, http.StatusOK, existing); err != nil {
+				app.internalServerError(w, r, err)
+			}
+			return
+		case store.ErrNotFound:
+		default:
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	if err := app.store.Conversations.Create(ctx, conv, payload.ParticipantIDs); err != nil {
+		switch err {
+		case store.ErrBlocked:
+			app.forbiddenResponse(w, r)
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrConflict:
+			//? Created concurrently by the other user
+			existing, err := app.store.Conversations.GetDirect(ctx, user.ID, payload.ParticipantIDs[0])
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+
+			if err := app.jsonResponse(w, http.StatusOK, existing); err != nil {
+				app.internalServerError(w, r, err)
+			}
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	created, err := app.store.Conversations.GetByID(ctx, conv.ID, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, created); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetConversation godoc
+//
+//	@Summary		Get a conversation
+//	@Description	Get a conversation of the current user, the participants carry the read receipts
+//	@Tags			conversations
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversationID	path		int	true	"Conversation ID"
+//	@Success		200				{object}	models.Conversation
+//	@Failure		404				{object}	error	"Conversation not found"
+//	@Security		ApiKeyAuth
+//	@Router			/conversations/{conversationID} [get]
+func (app *application) getConversationHandler(w http.ResponseWriter, r *http.Request) {
+	conv := getConversationFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, conv); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetMessages godoc
+//
+//	@Summary		List messages
+//	@Description	List the messages of a conversation, the most recent first. Pass next_cursor as cursor to get older messages.
+//	@Tags			conversations
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversationID	path		int		true	"Conversation ID"
+//	@Param			limit			query		int		false	"Messages per page, up to 50"
+//	@Param			cursor			query		string	false	"Cursor of the page"
+//	@Success		200				{object}	MessagesPage
+//	@Failure		400				{object}	error	"Invalid cursor"
+//	@Failure		404				{object}	error	"Conversation not found"
+//	@Security		ApiKeyAuth
+//	@Router			/conversations/{conversationID}/messages [get]
+func (app *application) getMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	conv := getConversationFromCtx(r)
+
+	cq := store.CursorQuery{
+		Limit: 20,
+	}
+
+	cq, err := cq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(cq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	messages, err := app.store.Conversations.GetMessages(r.Context(), conv.ID, user.ID, cq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	page := MessagesPage{
+		Messages: messages,
+	}
+
+	if len(messages) == cq.Limit {
+		last := messages[len(messages)-1]
+		page.NextCursor = store.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// SendMessage godoc
+//
+//	@Summary		Send a message
+//	@Description	Send a message to a conversation. Messages go through the same content filter as posts.
+//	@Tags			conversations
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversationID	path		int					true	"Conversation ID"
+//	@Param			body			body		SendMessagePayload	true	"Message"
+//	@Success		201				{object}	models.Message		"Sent message"
+//	@Success		202				{object}	models.Message		"Message held for review"
+//	@Failure		400				{object}	error				"Invalid request"
+//	@Failure		403				{object}	error				"The other user is blocked"
+//	@Failure		404				{object}	error				"Conversation not found"
+//	@Failure		422				{object}	error				"Rejected by the content filter"
+//	@Security		ApiKeyAuth
+//	@Router			/conversations/{conversationID}/messages [post]
+func (app *application) sendMessageHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	conv := getConversationFromCtx(r)
+
+	var payload SendMessagePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	status, err := app.filterContent(ctx, contentfilter.Content{
+		Kind:   contentfilter.KindMessage,
+		UserID: user.ID,
+		Body:   payload.Content,
+	})
+	if err != nil {
+		app.contentRejectedResponse(w, r, err)
+		return
+	}
+
+	msg := &models.Message{
+		ConversationID: conv.ID,
+		UserID:         user.ID,
+		Content:        payload.Content,
+		Status:         status,
+		User:           models.User{ID: user.ID, Username: user.Username},
+	}
+
+	if err := app.store.Conversations.CreateMessage(ctx, msg); err != nil {
+		switch err {
+		case store.ErrBlocked:
+			app.forbiddenResponse(w, r)
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	code := http.StatusCreated
+	if msg.Status == models.StatusPendingReview {
+		code = http.StatusAccepted
+	} else {
+		app.publishMessage(ctx, conv, msg)
+	}
+
+	if err := app.jsonResponse(w, code, msg); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// MarkConversationRead godoc
+//
+//	@Summary		Mark a conversation as read
+//	@Description	Move the read receipt of the current user to the last message of the conversation
+//	@Tags			conversations
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversationID	path		int		true	"Conversation ID"
+//	@Success		204				{string}	string	"Conversation marked as read"
+//	@Failure		404				{object}	error	"Conversation not found"
+//	@Security		ApiKeyAuth
+//	@Router			/conversations/{conversationID}/read [put]
+func (app *application) markConversationReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	conv := getConversationFromCtx(r)
+
+	if err := app.store.Conversations.MarkRead(r.Context(), conv.ID, user.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// conversationContextMiddleware loads the conversation, the ones the user
+// doesn't take part in are not found.
+func (app *application) conversationContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromCtx(r)
+		conversationID, err := strconv.ParseInt(chi.URLParam(r, "conversationID"), 10, 64)
+
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		conv, err := app.store.Conversations.GetByID(ctx, conversationID, user.ID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, conversationCtx, conv)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getConversationFromCtx(r *http.Request) *models.Conversation {
+	conv, _ := r.Context().Value(conversationCtx).(*models.Conversation)
+
+	return conv
+}
+
+// publishMessage streams the message to the other participants, the ones in a
+// block relationship with the sender don't receive it.
+func (app *application) publishMessage(ctx context.Context, conv *models.Conversation, msg *models.Message) {
+	for _, p := range conv.Participants {
+		if p.User.ID == msg.UserID {
+			continue
+		}
+
+		app.publish(ctx, userTopic(p.User.ID), eventMessageCreated, msg.UserID, msg)
+	}
+}
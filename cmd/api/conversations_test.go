@@ -0,0 +1,130 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConversations(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conversationStore := app.store.Conversations.(*store.MockConversationStore)
+	conversationStore.Blocked = []int64{3}
+
+	send := func(t *testing.T, method string, url string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		req, err := http.NewRequest(method, url, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		return executeRequest(req, mux)
+	}
+
+	createConversation := func(t *testing.T, participantIDs ...int64) (int, models.Conversation) {
+		rr := send(t, http.MethodPost, "/v1/conversations", CreateConversationPayload{ParticipantIDs: participantIDs})
+
+		var body struct {
+			Data models.Conversation `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+
+		return rr.Code, body.Data
+	}
+
+	t.Run("should reuse the conversation of two users", func(t *testing.T) {
+		code, first := createConversation(t, 2)
+		checkResponseCode(t, http.StatusCreated, code)
+
+		code, second := createConversation(t, 2)
+		checkResponseCode(t, http.StatusOK, code)
+
+		if first.ID != second.ID {
+			t.Errorf("expected conversation %d, got %d", first.ID, second.ID)
+		}
+	})
+
+	t.Run("should create a group for several users", func(t *testing.T) {
+		code, group := createConversation(t, 2, 4)
+		checkResponseCode(t, http.StatusCreated, code)
+
+		if !group.IsGroup || len(group.Participants) != 3 {
+			t.Errorf("expected a group of 3, got %+v", group)
+		}
+	})
+
+	t.Run("should not allow invalid participants", func(t *testing.T) {
+		code, _ := createConversation(t, 1)
+		checkResponseCode(t, http.StatusBadRequest, code)
+
+		code, _ = createConversation(t, 2, 2)
+		checkResponseCode(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("should not allow blocked users", func(t *testing.T) {
+		code, _ := createConversation(t, 3)
+		checkResponseCode(t, http.StatusForbidden, code)
+	})
+
+	t.Run("should send and list messages", func(t *testing.T) {
+		_, conv := createConversation(t, 5)
+
+		rr := send(t, http.MethodPost, fmt.Sprintf("/v1/conversations/%d/messages", conv.ID), SendMessagePayload{Content: "hello"})
+		checkResponseCode(t, http.StatusCreated, rr.Code)
+
+		rr = send(t, http.MethodGet, fmt.Sprintf("/v1/conversations/%d/messages", conv.ID), nil)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var body struct {
+			Data MessagesPage `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(body.Data.Messages) != 1 || body.Data.Messages[0].Content != "hello" {
+			t.Errorf("expected the sent message, got %+v", body.Data.Messages)
+		}
+
+		rr = send(t, http.MethodPut, fmt.Sprintf("/v1/conversations/%d/read", conv.ID), nil)
+		checkResponseCode(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("should validate messages like posts", func(t *testing.T) {
+		_, conv := createConversation(t, 6)
+
+		rr := send(t, http.MethodPost, fmt.Sprintf("/v1/conversations/%d/messages", conv.ID), SendMessagePayload{Content: strings.Repeat("a", 1001)})
+		checkResponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("should hide the conversations of others", func(t *testing.T) {
+		other := &models.Conversation{CreatorID: 7}
+		if err := conversationStore.Create(context.Background(), other, []int64{8}); err != nil {
+			t.Fatal(err)
+		}
+
+		rr := send(t, http.MethodGet, fmt.Sprintf("/v1/conversations/%d/messages", other.ID), nil)
+		checkResponseCode(t, http.StatusNotFound, rr.Code)
+	})
+}
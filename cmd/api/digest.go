@@ -0,0 +1,172 @@
+package main
+
+import (
+	"SocialMedia/internal/mailer"
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+type digestConfig struct {
+	interval  time.Duration // how often due digests are looked for
+	period    time.Duration // time covered by a digest
+	batchSize int
+}
+
+// digestMail is the data of the digest emails, a section is empty when the
+// kind of activity isn't delivered daily.
+type digestMail struct {
+	Username       string
+	Followers      []digestItem
+	Comments       []digestItem
+	Mentions       []digestItem
+	UnsubscribeURL string
+}
+
+type digestItem struct {
+	ActorName string
+	PostTitle string
+	Excerpt   string
+	URL       string
+}
+
+// startDigestJob periodically queues the digest emails of the users who chose
+// a daily delivery. The job stops once ctx is cancelled.
+func (app *application) startDigestJob(ctx context.Context) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(app.config.notifications.digest.interval)
+		defer ticker.Stop()
+
+		for {
+			// Keep going while there are digests due, otherwise wait for the next tick
+			if n := app.sendDigests(ctx); n > 0 {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				app.logger.Infow("digest job stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return wg
+}
+
+// sendDigests queues the emails of a batch of users due for a digest, it
+// returns the number of digests completed. Users whose digest failed stay due
+// and are retried on the next tick.
+func (app *application) sendDigests(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	cfg := app.config.notifications.digest
+
+	recipients, err := app.store.NotificationPreferences.GetDueDigests(ctx, cfg.period, cfg.batchSize)
+	if err != nil {
+		app.logger.Errorw("error reading due digests", "error", err.Error())
+		return 0
+	}
+
+	completed := 0
+
+	for _, recipient := range recipients {
+		err := app.sendDigest(ctx, recipient)
+		switch {
+		case err == nil:
+			completed++
+		case errors.Is(err, store.ErrConflict):
+			//? Another instance sent this one
+		default:
+			app.logger.Errorw("error sending digest", "user", recipient.User.ID, "error", err.Error())
+		}
+	}
+
+	return completed
+}
+
+func (app *application) sendDigest(ctx context.Context, recipient models.DigestRecipient) error {
+	user := &recipient.User
+	prefs := &recipient.Preferences
+
+	digest := digestMail{
+		Username:       user.Username,
+		UnsubscribeURL: app.unsubscribeURL(user.ID, unsubscribeAll),
+	}
+
+	if prefs.NewFollower == models.DeliveryDaily {
+		activities, err := app.store.Activity.GetNewFollowers(ctx, user.ID, recipient.Since)
+		if err != nil {
+			return err
+		}
+		digest.Followers = app.digestItems(activities)
+	}
+
+	if prefs.Comment == models.DeliveryDaily {
+		activities, err := app.store.Activity.GetComments(ctx, user.ID, recipient.Since)
+		if err != nil {
+			return err
+		}
+		digest.Comments = app.digestItems(activities)
+	}
+
+	if prefs.Mention == models.DeliveryDaily {
+		activities, err := app.store.Activity.GetMentions(ctx, user.ID, recipient.Since)
+		if err != nil {
+			return err
+		}
+		digest.Mentions = app.digestItems(activities)
+	}
+
+	//? Nothing happened, the digest is completed without an email
+	if len(digest.Followers)+len(digest.Comments)+len(digest.Mentions) == 0 {
+		return app.store.NotificationPreferences.CompleteDigest(ctx, &recipient, nil)
+	}
+
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return err
+	}
+
+	//* The email is queued along with the new last digest, a failure leaves the user due
+	return app.store.NotificationPreferences.CompleteDigest(ctx, &recipient, &models.OutboxMail{
+		Template: mailer.DigestTemplate,
+		Username: user.Username,
+		Email:    user.Email,
+		Locale:   user.Language,
+		Data:     data,
+	})
+}
+
+func (app *application) digestItems(activities []models.Activity) []digestItem {
+	items := make([]digestItem, 0, len(activities))
+
+	for _, a := range activities {
+		item := digestItem{
+			ActorName: a.Actor.Username,
+			PostTitle: a.PostTitle,
+			Excerpt:   excerpt(a.Content),
+			URL:       app.userURL(a.Actor.ID),
+		}
+
+		if a.PostID != 0 {
+			item.URL = app.postURL(a.PostID)
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
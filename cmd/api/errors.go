@@ -1,41 +1,44 @@
 package main
 
 import (
+	"SocialMedia/internal/models"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (app *application) internalServerError(w http.ResponseWriter, r *http.Request, err error) {
-	app.logger.Errorw("internal error", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	app.requestLogger(r.Context()).Errorw("internal error", "method", r.Method, "route", routePattern(r), "error", err.Error())
 
 	writeJSONError(w, http.StatusInternalServerError, "server encountered a problem")
 }
 
 func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	app.logger.Warnf("bad request error", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	app.requestLogger(r.Context()).Warnw("bad request error", "method", r.Method, "route", routePattern(r), "error", err.Error())
 
 	writeJSONError(w, http.StatusBadRequest, err.Error())
 }
 
 func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request, err error) {
-	app.logger.Warnf("not found error", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	app.requestLogger(r.Context()).Warnw("not found error", "method", r.Method, "route", routePattern(r), "error", err.Error())
 
 	writeJSONError(w, http.StatusNotFound, err.Error())
 }
 
 func (app *application) conflictResponse(w http.ResponseWriter, r *http.Request, err error) {
-	app.logger.Errorw("conflict error", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	app.requestLogger(r.Context()).Errorw("conflict error", "method", r.Method, "route", routePattern(r), "error", err.Error())
 
 	writeJSONError(w, http.StatusConflict, err.Error())
 }
 
 func (app *application) unauthorizedErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	app.logger.Warnf("unauthorized error", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	app.requestLogger(r.Context()).Warnw("unauthorized error", "method", r.Method, "route", routePattern(r), "error", err.Error())
 
 	writeJSONError(w, http.StatusUnauthorized, "unauthorized")
 }
 
 func (app *application) unauthorizedBasicErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	app.logger.Warnf("unauthorized basic error", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	app.requestLogger(r.Context()).Warnw("unauthorized basic error", "method", r.Method, "route", routePattern(r), "error", err.Error())
 
 	w.Header().Set("WWW-Authenticate", `Basic real="restricted", charset="UTF-8"`)
 
@@ -43,15 +46,58 @@ func (app *application) unauthorizedBasicErrorResponse(w http.ResponseWriter, r
 }
 
 func (app *application) forbiddenResponse(w http.ResponseWriter, r *http.Request) {
-	app.logger.Warnw("unauthorized basic error", "method", r.Method, "path", r.URL.Path, "forbidden attempt")
+	app.requestLogger(r.Context()).Warnw("forbidden error", "method", r.Method, "route", routePattern(r))
 
 	writeJSONError(w, http.StatusForbidden, "invalid access")
 }
 
-func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter string) {
-	app.logger.Warnw("rate limit exceeded", "method", r.Method, "path", r.URL.Path)
+func (app *application) tooManyStreamsResponse(w http.ResponseWriter, r *http.Request) {
+	app.requestLogger(r.Context()).Warnw("too many event streams", "method", r.Method, "route", routePattern(r))
 
-	w.Header().Set("Retry-After", retryAfter)
+	writeJSONError(w, http.StatusTooManyRequests, "too many open event streams")
+}
+
+func (app *application) accountSuspendedResponse(w http.ResponseWriter, r *http.Request, suspension *models.Suspension) {
+	app.requestLogger(r.Context()).Warnw("suspended account", "method", r.Method, "route", routePattern(r), "user", suspension.UserID)
+
+	type envelope struct {
+		Error     string     `json:"error"`
+		Code      string     `json:"code"`
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	writeJSON(w, http.StatusForbidden, &envelope{
+		Error:     "account suspended",
+		Code:      "account_suspended",
+		Reason:    suspension.Reason,
+		ExpiresAt: suspension.ExpiresAt,
+	})
+}
+
+func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	app.requestLogger(r.Context()).Warnw("rate limit exceeded", "method", r.Method, "route", routePattern(r))
+
+	retryAfterSeconds := strconv.Itoa(seconds(retryAfter))
+	w.Header().Set("Retry-After", retryAfterSeconds)
+
+	writeJSONError(w, http.StatusTooManyRequests, "rate limit exceeded, retry after: "+retryAfterSeconds+"s")
+}
+
+func (app *application) contentRejectedResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.requestLogger(r.Context()).Warnw("content rejected", "method", r.Method, "route", routePattern(r), "error", err.Error())
+
+	writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+}
+
+func (app *application) payloadTooLargeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.requestLogger(r.Context()).Warnw("payload too large", "method", r.Method, "route", routePattern(r), "error", err.Error())
+
+	writeJSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+}
+
+func (app *application) unsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.requestLogger(r.Context()).Warnw("unsupported media type", "method", r.Method, "route", routePattern(r), "error", err.Error())
 
-	writeJSONError(w, http.StatusTooManyRequests, "rate limit exceeded, retry after: "+retryAfter)
+	writeJSONError(w, http.StatusUnsupportedMediaType, err.Error())
 }
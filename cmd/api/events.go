@@ -0,0 +1,437 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/pubsub"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// eventsHeartbeat keeps idle connections open through proxies
+	eventsHeartbeat = 25 * time.Second
+	// maxPostSubscriptions caps the posts a client follows the comments of
+	maxPostSubscriptions = 20
+	// maxStreamsPerUser caps the event streams a user keeps open on an instance
+	maxStreamsPerUser = 5
+)
+
+// Types of the events streamed to the clients
+const (
+	eventPostCreated    = "post.created"
+	eventCommentCreated = "comment.created"
+	eventNotification   = "notification"
+
+	// Sent to the follower when a follow starts or ends, the streams update
+	// their topics and don't forward it
+	eventFollowChanged = "follow.changed"
+	// Sent to both users when a block between them starts or ends and to the
+	// user when a mute does, the streams update the hidden users and don't
+	// forward them
+	eventBlockChanged = "block.changed"
+	eventMuteChanged  = "mute.changed"
+)
+
+// followChange is the data of the follow.changed events.
+type followChange struct {
+	FollowedID int64 `json:"followed_id"`
+	Following  bool  `json:"following"`
+}
+
+// hideChange is the data of the block.changed and mute.changed events.
+type hideChange struct {
+	UserID int64 `json:"user_id"`
+	Hidden bool  `json:"hidden"`
+}
+
+// event is the envelope published on the pub/sub topics.
+type event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+	// Author of the content, used to hide events from blocked users
+	ActorID int64 `json:"actor_id,omitempty"`
+}
+
+func userTopic(userID int64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func authorTopic(userID int64) string {
+	return fmt.Sprintf("posts:user:%d", userID)
+}
+
+func postTopic(postID int64) string {
+	return fmt.Sprintf("comments:post:%d", postID)
+}
+
+// Events godoc
+//
+//	@Summary		Stream real-time events
+//	@Description	Server-Sent Events stream of the new posts of followed users, the notifications of the current user and the comments of the given posts. Browsers can't set headers on an EventSource, so the token may be passed as access_token instead.
+//	@Tags			events
+//	@Produce		text/event-stream
+//	@Param			posts			query		string	false	"Comma separated IDs of the posts to receive the comments of"
+//	@Param			access_token	query		string	false	"Token, when the Authorization header can't be set"
+//	@Success		200				{string}	string	"Event stream"
+//	@Failure		400				{object}	error	"Invalid request"
+//	@Failure		401				{object}	error	"Unauthorized"
+//	@Failure		429				{object}	error	"Too many open event streams"
+//	@Security		ApiKeyAuth
+//	@Router			/events [get]
+func (app *application) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	ctx := r.Context()
+
+	//* Each stream holds a connection and its topics, a user can't keep opening them
+	if !app.eventStreams.acquire(user.ID, maxStreamsPerUser) {
+		app.tooManyStreamsResponse(w, r)
+		return
+	}
+	defer app.eventStreams.release(user.ID)
+
+	postIDs, err := parsePostIDs(r.URL.Query().Get("posts"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	topics, err := app.eventTopics(ctx, user, postIDs)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	sub, err := app.pubsub.Subscribe(ctx, topics...)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	defer sub.Close()
+
+	//? Loaded once subscribed so no block or mute made in between is missed
+	hidden, err := app.hiddenUsers(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	//? The server write timeout would cut the stream, not every writer supports lifting it though
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, ": connected\n\n")
+	if err := rc.Flush(); err != nil {
+		app.requestLogger(r.Context()).Errorw("event stream not supported", "error", err.Error())
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			//? Suspensions only end the stream once the user is read again
+			if app.isSuspended(ctx, user.ID) {
+				return
+			}
+
+			fmt.Fprint(w, ": ping\n\n")
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return
+			}
+
+			var e event
+			if err := json.Unmarshal(msg.Payload, &e); err != nil {
+				app.requestLogger(r.Context()).Errorw("invalid event", "topic", msg.Topic, "error", err.Error())
+				continue
+			}
+
+			switch e.Type {
+			case eventFollowChanged:
+				app.followTopics(ctx, sub, e)
+				continue
+			case eventBlockChanged, eventMuteChanged:
+				app.updateHiddenUsers(ctx, hidden, e)
+				continue
+			}
+
+			if !canReceiveEvent(user, hidden, e) {
+				continue
+			}
+
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, e.Data)
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// eventTopics returns the topics streamed to the user: their notifications, the
+// posts of the users they follow and the comments of the given posts they are
+// allowed to see.
+func (app *application) eventTopics(ctx context.Context, user *models.User, postIDs []int64) ([]string, error) {
+	topics := []string{userTopic(user.ID)}
+
+	following, err := app.store.Followers.GetFollowingIDs(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range following {
+		topics = append(topics, authorTopic(id))
+	}
+
+	for _, id := range postIDs {
+		post, err := app.getPost(ctx, id)
+		if err != nil {
+			continue
+		}
+
+		visible, err := app.canViewPost(ctx, user, post)
+		if err != nil {
+			return nil, err
+		}
+
+		if visible {
+			topics = append(topics, postTopic(id))
+		}
+	}
+
+	return topics, nil
+}
+
+// hiddenSet holds the users whose events a stream doesn't forward. Blocks and
+// mutes are kept apart, unmuting a blocked user must still hide them.
+type hiddenSet struct {
+	blocked map[int64]bool
+	muted   map[int64]bool
+}
+
+func (h *hiddenSet) hides(userID int64) bool {
+	return h.blocked[userID] || h.muted[userID]
+}
+
+// hiddenUsers loads the users the user blocked or muted, or who blocked the
+// user.
+func (app *application) hiddenUsers(ctx context.Context, userID int64) (*hiddenSet, error) {
+	hidden := &hiddenSet{blocked: map[int64]bool{}, muted: map[int64]bool{}}
+
+	blocked, err := app.store.Blocks.GetBlockedIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range blocked {
+		hidden.blocked[id] = true
+	}
+
+	muted, err := app.store.Mutes.GetMutedIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range muted {
+		hidden.muted[id] = true
+	}
+
+	return hidden, nil
+}
+
+// updateHiddenUsers applies a block or mute change to the hidden users of the
+// stream.
+func (app *application) updateHiddenUsers(ctx context.Context, hidden *hiddenSet, e event) {
+	var change hideChange
+	if err := json.Unmarshal(e.Data, &change); err != nil {
+		app.requestLogger(ctx).Errorw("invalid hide change", "type", e.Type, "error", err.Error())
+		return
+	}
+
+	users := hidden.muted
+	if e.Type == eventBlockChanged {
+		users = hidden.blocked
+	}
+
+	if change.Hidden {
+		users[change.UserID] = true
+	} else {
+		delete(users, change.UserID)
+	}
+}
+
+// canReceiveEvent hides the events of the users the user blocked or muted, or
+// who blocked the user.
+func canReceiveEvent(user *models.User, hidden *hiddenSet, e event) bool {
+	if e.ActorID == 0 || e.ActorID == user.ID {
+		return true
+	}
+
+	return !hidden.hides(e.ActorID)
+}
+
+// followTopics adds or removes the posts of the followed user from the topics
+// of the stream.
+func (app *application) followTopics(ctx context.Context, sub pubsub.Subscription, e event) {
+	var change followChange
+	if err := json.Unmarshal(e.Data, &change); err != nil {
+		app.requestLogger(ctx).Errorw("invalid follow change", "error", err.Error())
+		return
+	}
+
+	var err error
+	if change.Following {
+		err = sub.Subscribe(ctx, authorTopic(change.FollowedID))
+	} else {
+		err = sub.Unsubscribe(ctx, authorTopic(change.FollowedID))
+	}
+
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error updating the event topics", "followed", change.FollowedID, "error", err.Error())
+	}
+}
+
+// isSuspended reads the user again, a failed read leaves the stream open.
+func (app *application) isSuspended(ctx context.Context, userID int64) bool {
+	user, err := app.getUser(ctx, userID)
+	if err != nil {
+		app.requestLogger(ctx).Warnw("error checking the suspension of the user", "user", userID, "error", err.Error())
+		return false
+	}
+
+	return user.Suspension.IsActive()
+}
+
+// publishFollow tells the streams of the follower that a follow started or
+// ended.
+func (app *application) publishFollow(ctx context.Context, followerID int64, followedID int64, following bool) {
+	app.publish(ctx, userTopic(followerID), eventFollowChanged, 0, followChange{FollowedID: followedID, Following: following})
+}
+
+// publishBlock tells the streams of both users whether a block is left between
+// them.
+func (app *application) publishBlock(ctx context.Context, userID int64, otherID int64, blocked bool) {
+	app.publish(ctx, userTopic(userID), eventBlockChanged, 0, hideChange{UserID: otherID, Hidden: blocked})
+	app.publish(ctx, userTopic(otherID), eventBlockChanged, 0, hideChange{UserID: userID, Hidden: blocked})
+}
+
+// publishMute tells the streams of the user that a mute started or ended.
+func (app *application) publishMute(ctx context.Context, userID int64, mutedID int64, muted bool) {
+	app.publish(ctx, userTopic(userID), eventMuteChanged, 0, hideChange{UserID: mutedID, Hidden: muted})
+}
+
+// publish sends the event to the subscribers of the topic. Failures are
+// logged, real-time delivery is best effort.
+func (app *application) publish(ctx context.Context, topic string, eventType string, actorID int64, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error encoding event", "type", eventType, "error", err.Error())
+		return
+	}
+
+	payload, err := json.Marshal(event{Type: eventType, Data: raw, ActorID: actorID})
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error encoding event", "type", eventType, "error", err.Error())
+		return
+	}
+
+	if err := app.pubsub.Publish(ctx, topic, payload); err != nil {
+		app.requestLogger(ctx).Errorw("error publishing event", "topic", topic, "error", err.Error())
+	}
+}
+
+// publishPost streams the post to the followers of its author. Posts held for
+// review and unlisted posts are never streamed.
+func (app *application) publishPost(ctx context.Context, post *models.Post) {
+	if post.Status != models.StatusPublished || post.Visibility == models.VisibilityUnlisted {
+		return
+	}
+
+	app.publish(ctx, authorTopic(post.UserID), eventPostCreated, post.UserID, post)
+}
+
+func (app *application) publishComment(ctx context.Context, comment *models.Comment) {
+	if comment.Status != models.StatusPublished {
+		return
+	}
+
+	//? Only the public part of the author is streamed
+	c := *comment
+	c.User = models.User{ID: comment.User.ID, Username: comment.User.Username}
+
+	app.publish(ctx, postTopic(comment.PostID), eventCommentCreated, comment.UserID, c)
+}
+
+// streamCounter counts the open event streams of each user.
+type streamCounter struct {
+	sync.Mutex
+	open map[int64]int
+}
+
+// acquire reports whether the user may open another stream, which must be
+// released once closed.
+func (c *streamCounter) acquire(userID int64, max int) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.open[userID] >= max {
+		return false
+	}
+
+	if c.open == nil {
+		c.open = map[int64]int{}
+	}
+	c.open[userID]++
+
+	return true
+}
+
+func (c *streamCounter) release(userID int64) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.open[userID]--; c.open[userID] <= 0 {
+		delete(c.open, userID)
+	}
+}
+
+func parsePostIDs(s string) ([]int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) > maxPostSubscriptions {
+		return nil, fmt.Errorf("at most %d posts can be subscribed to", maxPostSubscriptions)
+	}
+
+	ids := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid post ID")
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	app := newTestApplication(t, config{})
+	srv := httptest.NewServer(app.mount())
+	defer srv.Close()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should not allow unauthenticated requests", func(t *testing.T) {
+		res, err := http.Get(srv.URL + "/v1/events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		checkResponseCode(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("should stream the notifications of the user", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		//? EventSource can't set headers, the token is passed in the query
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v1/events?access_token="+testToken, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		checkResponseCode(t, http.StatusOK, res.StatusCode)
+
+		if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("expected an event stream, got %s", ct)
+		}
+
+		reader := bufio.NewReader(res.Body)
+
+		// Wait for the subscription before publishing
+		if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, ": connected") {
+			t.Fatalf("expected the stream to open, got %q (%v)", line, err)
+		}
+
+		app.notify(ctx, notification{
+			kind:      models.NotifyNewFollower,
+			recipient: &models.User{ID: 1, Username: "gopher"},
+			actor:     &models.User{ID: 2, Username: "follower"},
+		})
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("expected a notification event: %v", err)
+			}
+
+			if strings.HasPrefix(line, "event: ") {
+				if got := strings.TrimSpace(strings.TrimPrefix(line, "event: ")); got != eventNotification {
+					t.Fatalf("expected a %s event, got %s", eventNotification, got)
+				}
+				return
+			}
+		}
+	})
+
+	t.Run("should limit the open streams of a user", func(t *testing.T) {
+		//? The streams of the other tests may not be released yet
+		srv := httptest.NewServer(newTestApplication(t, config{}).mount())
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		open := func(t *testing.T) *http.Response {
+			t.Helper()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v1/events?access_token="+testToken, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			return res
+		}
+
+		for i := 0; i < maxStreamsPerUser; i++ {
+			res := open(t)
+			defer res.Body.Close()
+
+			checkResponseCode(t, http.StatusOK, res.StatusCode)
+		}
+
+		res := open(t)
+		res.Body.Close()
+
+		checkResponseCode(t, http.StatusTooManyRequests, res.StatusCode)
+	})
+
+	t.Run("should follow the changes of the user", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+		srv := httptest.NewServer(app.mount())
+		defer srv.Close()
+
+		app.store.Followers.(*store.MockFollowerStore).Following = []int64{3}
+		app.store.Mutes.(*store.MockMuteStore).Mute(context.Background(), 1, 3)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v1/events?access_token="+testToken, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		// The data of the streamed events
+		events := make(chan string)
+		go func() {
+			scanner := bufio.NewScanner(res.Body)
+			for scanner.Scan() {
+				if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+					select {
+					case events <- data:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			close(events)
+		}()
+
+		app.publishFollow(ctx, 1, 2, true)
+
+		//? The stream picks the follow up asynchronously, post until it does
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case data, ok := <-events:
+				if !ok {
+					t.Fatal("expected a post of the followed user")
+				}
+
+				var post models.Post
+				if err := json.Unmarshal([]byte(data), &post); err != nil {
+					t.Fatal(err)
+				}
+
+				if post.UserID != 2 {
+					t.Fatalf("expected the posts of the muted user to be hidden, got a post of %d", post.UserID)
+				}
+				return
+			case <-ticker.C:
+				app.publishPost(ctx, &models.Post{UserID: 3, Status: models.StatusPublished})
+				app.publishPost(ctx, &models.Post{UserID: 2, Status: models.StatusPublished})
+			}
+		}
+	})
+
+	t.Run("should hide the users blocked or muted while streaming", func(t *testing.T) {
+		app := newTestApplication(t, config{})
+		srv := httptest.NewServer(app.mount())
+		defer srv.Close()
+
+		app.store.Followers.(*store.MockFollowerStore).Following = []int64{2, 3}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v1/events?access_token="+testToken, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		scanner := bufio.NewScanner(res.Body)
+		if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), ": connected") {
+			t.Fatalf("expected the stream to open, got %q", scanner.Text())
+		}
+
+		// The author of the next streamed post
+		nextAuthor := func(t *testing.T) int64 {
+			t.Helper()
+
+			for scanner.Scan() {
+				if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+					var post models.Post
+					if err := json.Unmarshal([]byte(data), &post); err != nil {
+						t.Fatal(err)
+					}
+
+					return post.UserID
+				}
+			}
+
+			t.Fatal("expected a post")
+			return 0
+		}
+
+		//? The events of a stream are delivered in order, the change is applied
+		//? before the posts published after it
+		post := func(authors ...int64) {
+			for _, id := range authors {
+				app.publishPost(ctx, &models.Post{UserID: id, Status: models.StatusPublished})
+			}
+		}
+
+		app.publishMute(ctx, 1, 2, true)
+		post(2, 3)
+		if got := nextAuthor(t); got != 3 {
+			t.Fatalf("expected the posts of the muted user to be hidden, got a post of %d", got)
+		}
+
+		app.publishMute(ctx, 1, 2, false)
+		post(2)
+		if got := nextAuthor(t); got != 2 {
+			t.Fatalf("expected the posts of the unmuted user, got a post of %d", got)
+		}
+
+		app.publishBlock(ctx, 3, 1, true)
+		post(3, 2)
+		if got := nextAuthor(t); got != 2 {
+			t.Fatalf("expected the posts of the user who blocked to be hidden, got a post of %d", got)
+		}
+	})
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"SocialMedia/internal/models"
 	"SocialMedia/internal/store"
+	"context"
 	"net/http"
+	"strconv"
 )
 
+const defaultFeedLimit = 20
+
 // GetUserFeed godoc
 //
 //	@Summary		Get user feed
@@ -26,7 +31,7 @@ func (app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Reques
 
 	// pagination, filters and sort
 	fq := store.PaginatedFeedQuery{
-		Limit:  20,
+		Limit:  defaultFeedLimit,
 		Offset: 0,
 		Sort:   "desc",
 	}
@@ -44,15 +49,43 @@ func (app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Reques
 
 	ctx := r.Context()
 
-	feed, err := app.store.Posts.GetUserFeed(ctx, user.ID, fq)
-
+	feed, err := app.getUserFeed(ctx, user.ID, fq)
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
+	if err := app.attachLinkPreviews(ctx, *feed); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
 	if err := app.jsonResponse(w, http.StatusOK, feed); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 }
+
+// getUserFeed reads the first page of the feed through the cache, it's what the
+// clients load the most. The other pages and the filtered feeds are loaded.
+func (app *application) getUserFeed(ctx context.Context, userID int64, fq store.PaginatedFeedQuery) (*[]models.PostWithMetadata, error) {
+	firstPage := fq.Offset == 0 && fq.Limit == defaultFeedLimit && fq.Sort == "desc" &&
+		len(fq.Tags) == 0 && fq.Search == "" && fq.Since == "" && fq.Until == ""
+	if !firstPage {
+		return app.store.Posts.GetUserFeed(ctx, userID, fq)
+	}
+
+	return app.cacheStorage.Feeds.GetOrLoad(ctx, strconv.FormatInt(userID, 10), func(ctx context.Context) (*[]models.PostWithMetadata, error) {
+		return app.store.Posts.GetUserFeed(ctx, userID, fq)
+	})
+}
+
+// invalidateFeed removes the cached feeds of the users whose follows, blocks
+// or mutes changed, the posts shown to them changed right away.
+func (app *application) invalidateFeed(ctx context.Context, userIDs ...int64) {
+	for _, userID := range userIDs {
+		if err := app.cacheStorage.Feeds.Delete(ctx, strconv.FormatInt(userID, 10)); err != nil {
+			app.requestLogger(ctx).Errorw("failed to invalidate the cached feed", "user", userID, "error", err.Error())
+		}
+	}
+}
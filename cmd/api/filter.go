@@ -0,0 +1,28 @@
+package main
+
+import (
+	"SocialMedia/internal/contentfilter"
+	"SocialMedia/internal/models"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var errContentRejected = errors.New("content rejected")
+
+// filterContent runs the content through the filter pipeline and returns the
+// status it should be stored with.
+func (app *application) filterContent(ctx context.Context, content contentfilter.Content) (string, error) {
+	decision := app.contentFilter.Evaluate(ctx, content)
+
+	switch decision.Verdict {
+	case contentfilter.Reject:
+		app.requestLogger(ctx).Infow("content rejected", "kind", content.Kind, "user", content.UserID, "rule", decision.Rule)
+		return "", fmt.Errorf("%w: %s", errContentRejected, decision.Reason)
+	case contentfilter.Hold:
+		app.requestLogger(ctx).Infow("content held for review", "kind", content.Kind, "user", content.UserID, "rule", decision.Rule)
+		return models.StatusPendingReview, nil
+	default:
+		return models.StatusPublished, nil
+	}
+}
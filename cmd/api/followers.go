@@ -0,0 +1,129 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetFollowRequests godoc
+//
+//	@Summary		Pending follow requests
+//	@Description	Lists the pending follow requests sent to the current user
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]models.FollowRequest
+//	@Security		ApiKeyAuth
+//	@Router			/user/follow-requests [get]
+func (app *application) getFollowRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	requests, err := app.store.Followers.GetRequests(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, requests); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// ApproveFollowRequest godoc
+//
+//	@Summary		Approve a follow request
+//	@Description	Approve the pending follow request sent by the given user
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"Requesting User ID"
+//	@Success		204		{string}	string	"Follow request approved"
+//	@Failure		404		{object}	error	"Follow request not found"
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Security		ApiKeyAuth
+//	@Router			/user/follow-requests/{userID} [put]
+func (app *application) approveFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	requesterID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Followers.ApproveRequest(r.Context(), user.ID, requesterID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.followApproved(r.Context(), user, requesterID)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// RejectFollowRequest godoc
+//
+//	@Summary		Reject a follow request
+//	@Description	Reject the pending follow request sent by the given user
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"Requesting User ID"
+//	@Success		204		{string}	string	"Follow request rejected"
+//	@Failure		404		{object}	error	"Follow request not found"
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Security		ApiKeyAuth
+//	@Router			/user/follow-requests/{userID} [delete]
+func (app *application) rejectFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	requesterID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Followers.RejectRequest(r.Context(), user.ID, requesterID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// followApproved tells everyone about a follow request of a private account
+// that was approved, like a follow of a public one.
+func (app *application) followApproved(ctx context.Context, target *models.User, requesterID int64) {
+	app.invalidateFeed(ctx, requesterID)
+	app.publishFollow(ctx, requesterID, target.ID, true)
+
+	requester, err := app.getUser(ctx, requesterID)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting follower", "user", requesterID, "error", err.Error())
+		return
+	}
+
+	app.notifyFollow(ctx, requester, target.ID, false)
+}
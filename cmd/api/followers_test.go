@@ -0,0 +1,140 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestFollowRequests(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	users := app.store.Users.(*store.MockUserStore)
+	followers := app.store.Followers.(*store.MockFollowerStore)
+	notifications := app.store.Notifications.(*store.MockNotificationStore)
+
+	// The test token is the one of user 1
+	users.Users = map[int64]models.User{
+		1: {ID: 1, Username: "private", IsPrivate: true},
+		2: {ID: 2, Username: "requester", IsPrivate: true},
+	}
+
+	send := func(t *testing.T, method string, path string, body io.Reader) int {
+		t.Helper()
+
+		req, err := http.NewRequest(method, path, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		return executeRequest(req, mux).Code
+	}
+
+	isFollowing := func(userID int64, followedID int64) bool {
+		following, _ := followers.IsFollowing(ctx, userID, followedID)
+		return following
+	}
+
+	t.Run("should ask to follow a private account", func(t *testing.T) {
+		checkResponseCode(t, http.StatusAccepted, send(t, http.MethodPut, "/v1/user/2/follow", nil))
+		checkResponseCode(t, http.StatusConflict, send(t, http.MethodPut, "/v1/user/2/follow", nil))
+
+		if isFollowing(1, 2) {
+			t.Error("expected the follow to wait for the approval")
+		}
+	})
+
+	t.Run("should follow once the request is approved", func(t *testing.T) {
+		if pending, _ := followers.Follow(ctx, 2, 1); !pending {
+			t.Fatal("expected a pending request")
+		}
+
+		checkResponseCode(t, http.StatusNoContent, send(t, http.MethodPut, "/v1/user/follow-requests/2", nil))
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodPut, "/v1/user/follow-requests/2", nil))
+
+		if !isFollowing(2, 1) {
+			t.Error("expected the requester to follow the user")
+		}
+
+		found := false
+		for _, n := range notifications.Notifications {
+			if n.UserID == 1 && n.Kind == models.NotifyNewFollower && n.Actor.ID == 2 {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("expected the user to be notified of the new follower")
+		}
+	})
+
+	t.Run("should not follow once the request is rejected", func(t *testing.T) {
+		followers.Follow(ctx, 3, 1)
+
+		checkResponseCode(t, http.StatusNoContent, send(t, http.MethodDelete, "/v1/user/follow-requests/3", nil))
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodDelete, "/v1/user/follow-requests/3", nil))
+
+		if isFollowing(3, 1) {
+			t.Error("expected the rejected requester not to follow the user")
+		}
+	})
+
+	t.Run("should approve the pending requests when going public", func(t *testing.T) {
+		followers.Follow(ctx, 4, 1)
+
+		body := bytes.NewBufferString(`{"is_private": false}`)
+		checkResponseCode(t, http.StatusOK, send(t, http.MethodPatch, "/v1/user", body))
+
+		if !isFollowing(4, 1) {
+			t.Error("expected the pending requester to follow the user")
+		}
+
+		requests, _ := followers.GetRequests(ctx, 1)
+		if len(requests) != 0 {
+			t.Errorf("expected no pending request left, got %v", requests)
+		}
+
+		// Public now, the follows don't wait
+		if pending, _ := followers.Follow(ctx, 5, 1); pending {
+			t.Error("expected a public account to be followed at once")
+		}
+	})
+
+	t.Run("should list the pending requests", func(t *testing.T) {
+		users.Users[1] = models.User{ID: 1, IsPrivate: true}
+		followers.Follow(ctx, 6, 1)
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/user/follow-requests", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var envelope struct {
+			Data []models.FollowRequest `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(envelope.Data) != 1 || envelope.Data[0].UserID != 6 {
+			t.Errorf("expected the request of user 6, got %v", envelope.Data)
+		}
+	})
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"SocialMedia/internal/health"
+	"SocialMedia/internal/store/cache"
+	"context"
 	"net/http"
+	"sync"
+	"time"
 )
 
+// readinessMaxAge bounds how often the probes, or anyone else, get the
+// dependencies checked.
+const readinessMaxAge = 2 * time.Second
+
 // GetHealth godoc
 //
 //	@Summary		Get health
-//	@Description	Get configuration information of the service
+//	@Description	Get configuration information of the service and the state of the cache
 //	@Tags			ops
 //	@Accept			json
 //	@Produce		json
@@ -19,6 +28,7 @@ func (app *application) healthCheckHandler(w http.ResponseWriter, r *http.Reques
 		"status":  "ok",
 		"env":     app.config.env,
 		"version": version,
+		"cache":   app.cacheState(),
 	}
 
 	if err := app.jsonResponse(w, http.StatusOK, data); err != nil {
@@ -26,3 +36,89 @@ func (app *application) healthCheckHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 }
+
+// GetLiveness godoc
+//
+//	@Summary		Get liveness
+//	@Description	Tells whether the process is up, the dependencies aren't checked so a broken one doesn't get the service restarted
+//	@Tags			ops
+//	@Produce		json
+//	@Success		200	{object}	map[string]string
+//	@Router			/health/live [get]
+func (app *application) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	if err := app.jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetReadiness godoc
+//
+//	@Summary		Get readiness
+//	@Description	Checks the dependencies of the service, unavailable when a critical one is down or the server is shutting down. The report is reused for a few seconds and the errors of the dependencies are only logged.
+//	@Tags			ops
+//	@Produce		json
+//	@Success		200	{object}	health.Report
+//	@Failure		503	{object}	health.Report	"A critical dependency is down"
+//	@Router			/health/ready [get]
+func (app *application) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	report := health.Report{Status: health.StatusShuttingDown}
+	if !app.shuttingDown.Load() {
+		report = app.health.Run(r.Context())
+	}
+
+	if report.Status != health.StatusOK {
+		failures := map[string]string{}
+		for name, component := range report.Components {
+			if component.Error != "" {
+				failures[name] = component.Error
+			}
+		}
+
+		app.requestLogger(r.Context()).Warnw("dependencies failing", "status", report.Status, "errors", failures)
+	}
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	if err := app.jsonResponse(w, status, report); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// cacheState is disabled, ok, or degraded while Redis is skipped.
+func (app *application) cacheState() string {
+	breaker := app.cacheStorage.Breaker
+	if !app.config.redisCfg.enabled || breaker == nil {
+		return "disabled"
+	}
+
+	if breaker.State() == cache.BreakerOpen {
+		return "degraded"
+	}
+	return "ok"
+}
+
+// startCacheMonitor pings Redis in the background while the breaker is open,
+// so the cache is used again as soon as Redis is back.
+func (app *application) startCacheMonitor(ctx context.Context) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+
+	breaker := app.cacheStorage.Breaker
+	if breaker == nil {
+		return wg
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		breaker.Run(ctx, app.config.redisCfg.breaker.probeInterval)
+		app.logger.Infow("cache monitor stopped")
+	}()
+
+	return wg
+}
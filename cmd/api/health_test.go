@@ -0,0 +1,105 @@
+package main
+
+import (
+	"SocialMedia/internal/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadiness(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+
+	ready := func(t *testing.T, expected int) health.Report {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/health/ready", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, expected, rr.Code)
+
+		if strings.Contains(rr.Body.String(), "connection refused") {
+			t.Errorf("expected the errors of the dependencies to be kept out of the report, got %s", rr.Body.String())
+		}
+
+		var body struct {
+			Data health.Report `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		return body.Data
+	}
+
+	databaseUp := true
+	app.health = health.New(
+		health.Check{
+			Name:     "database",
+			Critical: true,
+			Run: func(ctx context.Context) error {
+				if !databaseUp {
+					return errors.New("connection refused")
+				}
+				return nil
+			},
+		},
+		health.Check{
+			Name: "redis",
+			Run: func(ctx context.Context) error {
+				return errors.New("connection refused")
+			},
+		},
+	)
+
+	t.Run("should always be live", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/health/live", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := executeRequest(req, mux)
+
+		checkResponseCode(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("should be ready without the non critical dependencies", func(t *testing.T) {
+		report := ready(t, http.StatusOK)
+
+		if report.Status != health.StatusDegraded {
+			t.Errorf("expected the service to be degraded, got %s", report.Status)
+		}
+		if report.Components["redis"].Status != health.StatusDown {
+			t.Errorf("expected redis to be reported down, got %v", report.Components["redis"])
+		}
+	})
+
+	t.Run("should not be ready when the database is down", func(t *testing.T) {
+		databaseUp = false
+		defer func() { databaseUp = true }()
+
+		report := ready(t, http.StatusServiceUnavailable)
+
+		if report.Components["database"].Status != health.StatusDown {
+			t.Errorf("expected the database to be reported down, got %v", report.Components["database"])
+		}
+	})
+
+	t.Run("should not be ready once shutting down", func(t *testing.T) {
+		app.shuttingDown.Store(true)
+		defer app.shuttingDown.Store(false)
+
+		report := ready(t, http.StatusServiceUnavailable)
+
+		if report.Status != health.StatusShuttingDown {
+			t.Errorf("expected the service to be shutting down, got %s", report.Status)
+		}
+	})
+}
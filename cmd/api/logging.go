@@ -0,0 +1,100 @@
+package main
+
+import (
+	"SocialMedia/internal/logging"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type accessLogKey string
+
+const accessLogCtx accessLogKey = "accessLog"
+
+// accessLog is filled in while the request is served, for its access log line.
+type accessLog struct {
+	userID int64
+}
+
+// accessLogMiddleware logs a line per request once served, and gives the
+// request a logger carrying its request and trace IDs. It must come after the
+// RequestID and tracing middlewares. Only the route pattern is logged, the
+// paths and queries may hold tokens.
+func (app *application) accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		logger := app.logger.With("request_id", middleware.GetReqID(r.Context()))
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			logger = logger.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+		}
+
+		entry := &accessLog{}
+		ctx := logging.NewContext(r.Context(), logger)
+		ctx = context.WithValue(ctx, accessLogCtx, entry)
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		fields := []any{
+			"method", r.Method,
+			"route", routePattern(r),
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"latency", time.Since(start),
+			"ip", r.RemoteAddr,
+		}
+		if entry.userID != 0 {
+			fields = append(fields, "user_id", entry.userID)
+		}
+
+		logger.Infow("request served", fields...)
+	})
+}
+
+// withUser records the authenticated user in the access log of the request,
+// and in the lines of its logger from then on.
+func withUser(ctx context.Context, userID int64) context.Context {
+	if entry, ok := ctx.Value(accessLogCtx).(*accessLog); ok {
+		entry.userID = userID
+	}
+
+	if logger, ok := logging.FromContext(ctx); ok {
+		ctx = logging.NewContext(ctx, logger.With("user_id", userID))
+	}
+
+	return ctx
+}
+
+// requestLogger is the logger of the request of ctx, the logger of the
+// application outside of one.
+func (app *application) requestLogger(ctx context.Context) *zap.SugaredLogger {
+	if logger, ok := logging.FromContext(ctx); ok {
+		return logger
+	}
+
+	return app.logger
+}
+
+// routePattern is the route matched by the request so far, all of it once
+// served.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.RoutePattern() == "" {
+		//? Unknown paths may be anything
+		return "unmatched"
+	}
+
+	return rctx.RoutePattern()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"SocialMedia/internal/store/cache"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLog(t *testing.T) {
+	app := newTestApplication(t, config{})
+	core, logs := observer.New(zapcore.InfoLevel)
+	app.logger = zap.New(core).Sugar()
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.cacheStorage.Users.(*cache.MockUserStore).On("Get", int64(1)).Return(nil, nil)
+	app.cacheStorage.Users.(*cache.MockUserStore).On("Set", mock.Anything).Return(nil)
+
+	t.Run("should log the route and the user of the request", func(t *testing.T) {
+		logs.TakeAll()
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/user/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		entries := logs.FilterMessage("request served").All()
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 access log line, got %d", len(entries))
+		}
+
+		fields := entries[0].ContextMap()
+		if fields["route"] != "/v1/user/{userID}" || fields["status"] != int64(http.StatusOK) || fields["user_id"] != int64(1) {
+			t.Errorf("unexpected access log fields %v", fields)
+		}
+		if fields["request_id"] == "" || fields["bytes"] == nil || fields["latency"] == nil {
+			t.Errorf("expected the request ID, bytes and latency, got %v", fields)
+		}
+	})
+
+	t.Run("should not log the tokens of the paths", func(t *testing.T) {
+		logs.TakeAll()
+
+		req, err := http.NewRequest(http.MethodPut, "/v1/user/activate/secret-activation-token", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		executeRequest(req, mux)
+
+		for _, entry := range logs.All() {
+			for key, value := range entry.ContextMap() {
+				if s, ok := value.(string); ok && strings.Contains(s, "secret-activation-token") {
+					t.Errorf("expected the token to stay out of %q of %q", key, entry.Message)
+				}
+			}
+		}
+	})
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"SocialMedia/cmd/migrate/migrations"
 	"SocialMedia/internal/auth"
+	"SocialMedia/internal/blob"
+	"SocialMedia/internal/contentfilter"
 	"SocialMedia/internal/db"
 	"SocialMedia/internal/env"
+	"SocialMedia/internal/health"
+	"SocialMedia/internal/logging"
 	"SocialMedia/internal/mailer"
+	"SocialMedia/internal/metrics"
+	"SocialMedia/internal/pubsub"
 	"SocialMedia/internal/ratelimiter"
 	"SocialMedia/internal/store"
 	"SocialMedia/internal/store/cache"
+	"SocialMedia/internal/tracing"
+	"SocialMedia/internal/unfurl"
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -37,7 +48,8 @@ const version = "1.3.2" // semver
 // @description
 func main() {
 	// Logger
-	logger := zap.Must(zap.NewProduction()).Sugar()
+	//? Redacted whichever logger the tokens and passwords were added with
+	logger := zap.Must(zap.NewProduction(zap.WrapCore(logging.Redact))).Sugar()
 	defer logger.Sync()
 
 	err := godotenv.Load()
@@ -50,6 +62,16 @@ func main() {
 		logger.Fatal("Invalid MAIL_EXP value")
 	}
 
+	traceSampleRatio, err := strconv.ParseFloat(env.GetString("TRACING_SAMPLE_RATIO", "1"), 64)
+	if err != nil {
+		logger.Fatal("Invalid TRACING_SAMPLE_RATIO value")
+	}
+
+	trustedProxies, err := parseCIDRs(env.GetString("TRUSTED_PROXIES", ""))
+	if err != nil {
+		logger.Fatal("Invalid TRUSTED_PROXIES value")
+	}
+
 	cfg := config{
 		addr:        env.GetString("ADDR", ":8080"),
 		apiURL:      env.GetString("EXTERNAL_URL", "localhost:8080"),
@@ -60,13 +82,40 @@ func main() {
 			maxIdleConns: env.GetInt("DB_MAX_IDLE_CONNS", 30),
 			maxIdleTime:  env.GetString("DB_MAX_IDLE_TIME", "15m"),
 		},
-		env: env.GetString("ENV", "development"),
+		env:           env.GetString("ENV", "development"),
+		shutdownDelay: time.Second * time.Duration(env.GetInt("SHUTDOWN_DELAY_SECONDS", 0)),
+		// Comma separated addresses or networks of the load balancers
+		trustedProxies: trustedProxies,
+		tracing: tracing.Config{
+			Exporter:       env.GetString("TRACING_EXPORTER", "none"), // none, stdout or otlp
+			ServiceName:    "social-api",
+			ServiceVersion: version,
+			SampleRatio:    traceSampleRatio,
+		},
 		mail: mailConfig{
 			exp:       mailExp,
 			fromEmail: env.GetString("FROM_EMAIL", ""),
+			backend:   env.GetString("MAIL_BACKEND", "sendgrid"),
 			sendGrid: sendGridConfig{
 				apiKey: env.GetString("SENDGRID_API_KEY", ""),
 			},
+			smtp: smtpConfig{
+				host:     env.GetString("SMTP_HOST", "localhost"),
+				port:     env.GetInt("SMTP_PORT", 587),
+				username: env.GetString("SMTP_USERNAME", ""),
+				password: env.GetString("SMTP_PASSWORD", ""),
+				startTLS: env.GetBool("SMTP_STARTTLS", true),
+			},
+			fileDir: env.GetString("MAIL_FILE_DIR", "./tmp/mail"),
+			outbox: outboxConfig{
+				workers:      env.GetInt("MAIL_WORKERS", 2),
+				batchSize:    10,
+				pollInterval: time.Second * 5,
+				maxAttempts:  env.GetInt("MAIL_MAX_ATTEMPTS", 5),
+				baseBackoff:  time.Second * 30,
+				maxBackoff:   time.Hour,
+				lease:        time.Minute * 5,
+			},
 		},
 		auth: authConfig{
 			basic: basicConfig{
@@ -84,14 +133,85 @@ func main() {
 			pw:      env.GetString("REDIS_PW", ""),
 			db:      env.GetInt("REDIS_DB", 0),
 			enabled: env.GetBool("REDIS_ENABLED", true),
+			local: localCacheConfig{
+				size: env.GetInt("LOCAL_CACHE_SIZE", 1000),
+				ttl:  time.Second * 10,
+			},
+			breaker: breakerConfig{
+				threshold:     5,
+				probeInterval: time.Second * 5,
+			},
 		},
 		rateLimiter: ratelimiter.Config{
 			RequestsPerTimeFrame: env.GetInt("RATELIMITER_REQUESTS_COUNT", 20),
 			TimeFrame:            time.Second * 5,
 			Enabled:              env.GetBool("RATE_LIMITER_ENABLED", true),
+			Strategy:             env.GetString("RATE_LIMITER_STRATEGY", ratelimiter.StrategySlidingWindow),
+			Burst:                env.GetInt("RATE_LIMITER_BURST", 0),
+			Distributed:          env.GetBool("RATE_LIMITER_DISTRIBUTED", true),
+			Fallback:             env.GetString("RATE_LIMITER_FALLBACK", ratelimiter.FallbackLocal),
+		},
+		rateLimitPolicies: rateLimitConfig{
+			quotas: map[string]ratelimiter.Quota{
+				policyAuth: {
+					Limit:  env.GetInt("RATE_LIMIT_AUTH_COUNT", 10),
+					Window: time.Minute,
+				},
+				policyWrite: {
+					Limit:  env.GetInt("RATE_LIMIT_WRITE_COUNT", 30),
+					Window: time.Minute,
+				},
+			},
+			roleMultipliers: map[string]float64{
+				"moderator": 2,
+				"admin":     5,
+			},
+		},
+		filter: filterConfig{
+			path: env.GetString("CONTENT_FILTER_FILE", ""),
+		},
+		notifications: notificationConfig{
+			unsubscribeSecret: env.GetString("UNSUBSCRIBE_SECRET", "example"),
+			unsubscribeURL:    env.GetString("UNSUBSCRIBE_URL", "http://localhost:8080/v1/notifications/unsubscribe"),
+			digest: digestConfig{
+				interval:  time.Minute * 10,
+				period:    time.Hour * 24,
+				batchSize: 50,
+			},
+		},
+		media: mediaConfig{
+			backend:  env.GetString("MEDIA_STORAGE", "local"),
+			localDir: env.GetString("MEDIA_LOCAL_DIR", "./uploads"),
+			s3: blob.S3Config{
+				Endpoint:  env.GetString("S3_ENDPOINT", "http://localhost:9000"),
+				Region:    env.GetString("S3_REGION", "us-east-1"),
+				Bucket:    env.GetString("S3_BUCKET", "gosocial"),
+				AccessKey: env.GetString("S3_ACCESS_KEY", ""),
+				SecretKey: env.GetString("S3_SECRET_KEY", ""),
+				PathStyle: env.GetBool("S3_PATH_STYLE", true),
+			},
+			publicURL:     env.GetString("MEDIA_PUBLIC_URL", "http://localhost:8080/v1/media"),
+			maxUploadSize: int64(env.GetInt("MEDIA_MAX_UPLOAD_SIZE", 10<<20)), // 10MB
+		},
+		previews: previewConfig{
+			workers:   env.GetInt("UNFURL_WORKERS", 2),
+			queueSize: 100,
+			maxLinks:  3,
+			ttl:       time.Hour * 24,
+			unfurl: unfurl.Options{
+				Timeout:      time.Second * 5,
+				MaxBodySize:  512 << 10, // 512KB
+				MaxRedirects: 3,
+			},
 		},
 	}
 
+	// Tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.tracing)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
 	// Database
 	db, err := db.New(
 		cfg.db.addr,
@@ -108,28 +228,107 @@ func main() {
 
 	// Cache
 	var rdb *redis.Client
+	var breaker *cache.Breaker
 	if cfg.redisCfg.enabled {
 		rdb = cache.NewRedisClient(cfg.redisCfg.addr, cfg.redisCfg.pw, cfg.redisCfg.db)
 
-		// Test the Redis connection
+		breaker = cache.NewBreaker(
+			cfg.redisCfg.breaker.threshold,
+			func(ctx context.Context) error {
+				return rdb.Ping(ctx).Err()
+			},
+			func(state cache.BreakerState) {
+				logger.Warnw("redis circuit breaker changed", "state", state)
+			},
+		)
+
+		// Test the Redis connection, the database is used until it's reachable
 		ctx := context.Background()
 		if err := rdb.Ping(ctx).Err(); err != nil {
-			logger.Panicw("failed to connect to Redis", "error", err.Error())
+			logger.Errorw("failed to connect to Redis, continuing without the cache", "error", err.Error())
+			breaker.Trip()
 		} else {
 			logger.Infow("redis cache connection established", "port", ":8081", "addr", cfg.redisCfg.addr)
 		}
 	}
 
 	// Rate Limiter
-	ratelimiter := ratelimiter.NewFixedWindowLimiter(
-		cfg.rateLimiter.RequestsPerTimeFrame,
-		cfg.rateLimiter.TimeFrame,
-	)
+	rateLimiter, err := newRateLimiter(cfg.rateLimiter, rdb, breaker, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Content filter
+	contentFilter, err := contentfilter.LoadFile(cfg.filter.path)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Metrics
+	metrics := metrics.New()
+	if err := metrics.RegisterDB(db, "postgres"); err != nil {
+		logger.Fatal(err)
+	}
+	if err := metrics.RegisterGauge("cache_breaker_open", "1 while Redis is skipped after failing.", func() float64 {
+		if breaker.State() == cache.BreakerOpen {
+			return 1
+		}
+		return 0
+	}); err != nil {
+		logger.Fatal(err)
+	}
 
 	store := store.NewStorage(db)
-	cacheStorage := cache.NewRedisStorage(rdb)
+	cacheErrors := func(err error) {
+		logger.Warnw("cache error", "error", err.Error())
+	}
+	cacheStorage := cache.NewRedisStorage(rdb, breaker, cache.Hooks{
+		OnError: cacheErrors,
+		OnLookup: func(name string, result cache.Lookup) {
+			metrics.CacheLookup(name, string(result))
+		},
+	})
 
-	mailer := mailer.NewSendGrid(cfg.mail.sendGrid.apiKey, cfg.mail.fromEmail)
+	mailRenderer, err := mailer.NewTemplateRenderer()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	mailer, err := newMailer(cfg.mail, mailRenderer)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.Infow("mailer configured", "backend", cfg.mail.backend)
+
+	blobs, err := newBlobStore(cfg.media)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.Infow("media storage configured", "backend", cfg.media.backend)
+
+	// Real-time events are shared by every instance through Redis when available
+	var events pubsub.PubSub = pubsub.NewInProcess()
+	if rdb != nil {
+		events = pubsub.NewRedis(rdb)
+	}
+
+	// The deletes of cached users reach every instance through the events
+	if rdb != nil && cfg.redisCfg.local.size > 0 {
+		users, err := cache.NewLocalUserStore(
+			context.Background(),
+			cacheStorage.Users,
+			events,
+			cfg.redisCfg.local.size,
+			cfg.redisCfg.local.ttl,
+			cacheErrors,
+		)
+		if err != nil {
+			//? Without the invalidations the instances would keep stale users
+			logger.Errorw("in-memory user cache disabled, failed to subscribe to the invalidations", "error", err.Error())
+		} else {
+			cacheStorage.Users = users
+		}
+	}
 
 	jwtAuthenticator := auth.NewJWTAuthenticator(
 		cfg.auth.token.secret,
@@ -137,6 +336,25 @@ func main() {
 		cfg.auth.token.iss,
 	)
 
+	latestMigration, err := migrations.Latest()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	checks := []health.Check{
+		{Name: "database", Critical: true, Run: db.PingContext},
+		{Name: "migrations", Critical: true, Run: health.Migrations(db, latestMigration)},
+	}
+	if rdb != nil {
+		//? Not critical, the database is read while Redis is down
+		checks = append(checks, health.Check{
+			Name: "redis",
+			Run: func(ctx context.Context) error {
+				return rdb.Ping(ctx).Err()
+			},
+		})
+	}
+
 	app := &application{
 		config:        cfg,
 		store:         store,
@@ -144,10 +362,77 @@ func main() {
 		logger:        logger,
 		mailer:        mailer,
 		authenticator: jwtAuthenticator,
-		rateLimiter:   ratelimiter,
+		rateLimiter:   rateLimiter,
+		contentFilter: contentFilter,
+		signer:        auth.NewSigner(cfg.notifications.unsubscribeSecret),
+		pubsub:        events,
+		blobs:         blobs,
+		unfurler:      unfurl.New(cfg.previews.unfurl),
+		linkQueue:     make(chan []string, cfg.previews.queueSize),
+		health:        health.NewCached(readinessMaxAge, checks...),
+		metrics:       metrics,
 	}
 
 	mux := app.mount()
 
-	logger.Fatal(app.run(mux))
+	err = app.run(mux)
+
+	//? Export the spans of the last requests before exiting
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Errorw("error exporting the last spans", "error", err.Error())
+	}
+	cancel()
+
+	logger.Fatal(err)
+}
+
+func newMailer(cfg mailConfig, renderer *mailer.Renderer) (mailer.Client, error) {
+	switch cfg.backend {
+	case "sendgrid":
+		return mailer.NewSendGrid(cfg.sendGrid.apiKey, cfg.fromEmail, renderer), nil
+	case "smtp":
+		return mailer.NewSMTP(
+			cfg.smtp.host,
+			cfg.smtp.port,
+			cfg.smtp.username,
+			cfg.smtp.password,
+			cfg.fromEmail,
+			cfg.smtp.startTLS,
+			renderer,
+		), nil
+	case "file":
+		return mailer.NewFileMailer(cfg.fileDir, cfg.fromEmail, renderer)
+	case "memory":
+		return mailer.NewInMemoryMailer(cfg.fromEmail, renderer), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", cfg.backend)
+	}
+}
+
+func newBlobStore(cfg mediaConfig) (blob.Store, error) {
+	switch cfg.backend {
+	case "local":
+		return blob.NewLocal(cfg.localDir)
+	case "s3":
+		return blob.NewS3(cfg.s3)
+	default:
+		return nil, fmt.Errorf("unknown media storage %q", cfg.backend)
+	}
+}
+
+// newRateLimiter shares the limit of every instance through Redis when it's
+// enabled, the limit is per instance otherwise. The limiter shares the breaker
+// of the cache, Redis is skipped by both during an outage.
+func newRateLimiter(cfg ratelimiter.Config, rdb *redis.Client, breaker *cache.Breaker, logger *zap.SugaredLogger) (ratelimiter.Limiter, error) {
+	if !cfg.Distributed || rdb == nil {
+		return ratelimiter.New(cfg)
+	}
+
+	return ratelimiter.NewRedisLimiter(rdb, cfg,
+		ratelimiter.WithBreaker(breaker),
+		ratelimiter.WithErrorHandler(func(err error) {
+			logger.Warnw("rate limiter falling back, redis unavailable", "fallback", cfg.Fallback, "error", err.Error())
+		}),
+	)
 }
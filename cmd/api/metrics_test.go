@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	app := newTestApplication(t, config{
+		auth: authConfig{
+			basic: basicConfig{user: "prometheus", pass: "secret"},
+		},
+	})
+	mux := app.mount()
+
+	scrape := func(t *testing.T, user, pass string) (int, string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/metrics", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth(user, pass)
+
+		rr := executeRequest(req, mux)
+
+		return rr.Code, rr.Body.String()
+	}
+
+	t.Run("should require the basic auth credentials", func(t *testing.T) {
+		code, _ := scrape(t, "prometheus", "wrong")
+
+		checkResponseCode(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("should count the requests by route pattern", func(t *testing.T) {
+		for _, path := range []string{"/v1/health", "/v1/health", "/nothing/here"} {
+			req, err := http.NewRequest(http.MethodGet, path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			executeRequest(req, mux)
+		}
+
+		req, err := http.NewRequest("MADEUP", "/v1/health", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		executeRequest(req, mux)
+
+		code, body := scrape(t, "prometheus", "secret")
+		checkResponseCode(t, http.StatusOK, code)
+
+		for _, series := range []string{
+			`social_http_requests_total{method="GET",route="/v1/health",status="200"} 2`,
+			`social_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+			`social_http_request_duration_seconds_count{method="GET",route="/v1/health",status="200"} 2`,
+			`social_http_requests_total{method="OTHER",route="unmatched",status="405"} 1`,
+		} {
+			if !strings.Contains(body, series) {
+				t.Errorf("expected %s in the metrics", series)
+			}
+		}
+
+		if strings.Contains(body, "MADEUP") {
+			t.Error("expected the unknown methods to be grouped")
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -91,17 +92,41 @@ func (app *application) AuthTokenMiddleware() func(http.Handler) http.Handler {
 
 			user, err := app.getUser(ctx, userID)
 			if err != nil {
-				app.logger.Errorw("redis cache err", "error", err.Error())
 				app.unauthorizedErrorResponse(w, r, err)
 				return
 			}
 
+			if user.Suspension.IsActive() {
+				app.accountSuspendedResponse(w, r, user.Suspension)
+				return
+			}
+
 			ctx = context.WithValue(r.Context(), userCtx, user)
+			ctx = withUser(ctx, user.ID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// streamAuthMiddleware authenticates like AuthTokenMiddleware but also accepts
+// the token as the access_token query parameter, since browsers can't set
+// headers on an EventSource.
+func (app *application) streamAuthMiddleware() func(http.Handler) http.Handler {
+	authenticate := app.AuthTokenMiddleware()
+
+	return func(next http.Handler) http.Handler {
+		authenticated := authenticate(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (app *application) checkPostOwnership(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := getUserFromCtx(r)
@@ -127,9 +152,30 @@ func (app *application) checkPostOwnership(requiredRole string, next http.Handle
 	})
 }
 
-func (app *application) checkRolePrecedence(ctx context.Context, user *models.User, roleName string) (bool, error) {
-	role, err := app.store.Roles.GetByName(ctx, roleName)
+// requireRole only lets users with at least the given role through.
+func (app *application) requireRole(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromCtx(r)
 
+		allowed, err := app.checkRolePrecedence(r.Context(), user, requiredRole)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (app *application) checkRolePrecedence(ctx context.Context, user *models.User, roleName string) (bool, error) {
+	role, err := app.cacheStorage.Roles.GetOrLoad(ctx, roleName, func(ctx context.Context) (*models.Role, error) {
+		return app.store.Roles.GetByName(ctx, roleName)
+	})
 	if err != nil {
 		return false, err
 	}
@@ -142,9 +188,11 @@ func (app *application) getUser(ctx context.Context, userID int64) (*models.User
 		return app.store.Users.GetByID(ctx, userID)
 	}
 
+	//* A failing cache only makes the request slower, the user is read from the database
 	user, err := app.cacheStorage.Users.Get(ctx, userID)
 	if err != nil {
-		return nil, err
+		app.requestLogger(ctx).Warnw("failed to read the cached user", "user", userID, "error", err.Error())
+		user = nil
 	}
 
 	if user == nil {
@@ -154,21 +202,107 @@ func (app *application) getUser(ctx context.Context, userID int64) (*models.User
 		}
 
 		if err := app.cacheStorage.Users.Set(ctx, user); err != nil {
-			return nil, err
+			app.requestLogger(ctx).Warnw("failed to cache the user", "user", userID, "error", err.Error())
 		}
 	}
 
 	return user, nil
 }
 
-func (app *application) RateLimiterMiddleware(next http.Handler) http.Handler {
+// realIPMiddleware replaces the address of the request with the one of the
+// client, as forwarded by the trusted proxies. The forwarding headers of any
+// other peer are ignored, a client could set them to get a fresh rate limit
+// on every request.
+func (app *application) realIPMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if app.config.rateLimiter.Enabled {
-			if allow, retryAfter := app.rateLimiter.Allow(r.RemoteAddr); !allow {
-				app.rateLimitExceededResponse(w, r, retryAfter.String())
-				return
-			}
+		if ip := app.forwardedIP(r); ip != "" {
+			r.RemoteAddr = ip
 		}
+
 		next.ServeHTTP(w, r)
 	})
 }
+
+// forwardedIP returns the client address forwarded by a trusted proxy, or
+// nothing when the peer isn't one.
+func (app *application) forwardedIP(r *http.Request) string {
+	if !app.isTrustedProxy(net.ParseIP(clientIP(r))) {
+		return ""
+	}
+
+	for _, header := range []string{"True-Client-IP", "X-Real-IP"} {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(header))); ip != nil {
+			return ip.String()
+		}
+	}
+
+	//? Each proxy appends the address it got the request from, the first one
+	//? from the right that isn't a trusted proxy is the client
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+
+		client = ip.String()
+		if !app.isTrustedProxy(ip) {
+			break
+		}
+	}
+
+	return client
+}
+
+func (app *application) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range app.config.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCIDRs parses a comma separated list of networks, a single address is a
+// network of its own.
+func parseCIDRs(s string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", item)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"SocialMedia/internal/textparse"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultQueueLimit = 20
+
+// GetPendingPosts godoc
+//
+//	@Summary		Posts held for review
+//	@Description	Lists the posts held by the content filter, the oldest first. Reviewed posts leave the queue, reload the first page to get the next ones.
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Posts per page, up to 20"
+//	@Param			offset	query		int		false	"Offset in the queue"
+//	@Param			sort	query		string	false	"Sort by asc or desc creation"
+//	@Success		200		{object}	[]models.Post
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Failure		403		{object}	error	"Not a moderator"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/posts [get]
+func (app *application) getPendingPostsHandler(w http.ResponseWriter, r *http.Request) {
+	fq, ok := app.readQueueQuery(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := app.store.Posts.GetPending(r.Context(), fq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// ApprovePost godoc
+//
+//	@Summary		Approve a held post
+//	@Description	Publish a post held for review, its mentions are notified and it is streamed to the followers of its author
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int	true	"Post ID"
+//	@Success		200		{object}	models.Post
+//	@Failure		403		{object}	error	"Not a moderator"
+//	@Failure		404		{object}	error	"Post not held for review"
+//	@Failure		409		{object}	error	"The post was edited during the review"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/posts/{postID} [put]
+func (app *application) approvePostHandler(w http.ResponseWriter, r *http.Request) {
+	app.reviewPost(w, r, models.StatusPublished)
+}
+
+// RejectPost godoc
+//
+//	@Summary		Reject a held post
+//	@Description	Reject a post held for review, it stays visible to its author only
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int	true	"Post ID"
+//	@Success		200		{object}	models.Post
+//	@Failure		403		{object}	error	"Not a moderator"
+//	@Failure		404		{object}	error	"Post not held for review"
+//	@Failure		409		{object}	error	"The post was edited during the review"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/posts/{postID} [delete]
+func (app *application) rejectPostHandler(w http.ResponseWriter, r *http.Request) {
+	app.reviewPost(w, r, models.StatusRejected)
+}
+
+// GetPendingComments godoc
+//
+//	@Summary		Comments held for review
+//	@Description	Lists the comments held by the content filter, the oldest first. Reviewed comments leave the queue, reload the first page to get the next ones.
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Comments per page, up to 20"
+//	@Param			offset	query		int		false	"Offset in the queue"
+//	@Param			sort	query		string	false	"Sort by asc or desc creation"
+//	@Success		200		{object}	[]models.Comment
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Failure		403		{object}	error	"Not a moderator"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/comments [get]
+func (app *application) getPendingCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	fq, ok := app.readQueueQuery(w, r)
+	if !ok {
+		return
+	}
+
+	comments, err := app.store.Comments.GetPending(r.Context(), fq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, comments); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// ApproveComment godoc
+//
+//	@Summary		Approve a held comment
+//	@Description	Publish a comment held for review, the post author and the mentions are notified
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			commentID	path		int	true	"Comment ID"
+//	@Success		200			{object}	models.Comment
+//	@Failure		403			{object}	error	"Not a moderator"
+//	@Failure		404			{object}	error	"Comment not held for review"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/comments/{commentID} [put]
+func (app *application) approveCommentHandler(w http.ResponseWriter, r *http.Request) {
+	app.reviewComment(w, r, models.StatusPublished)
+}
+
+// RejectComment godoc
+//
+//	@Summary		Reject a held comment
+//	@Description	Reject a comment held for review, it stays visible to its author only
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			commentID	path		int	true	"Comment ID"
+//	@Success		200			{object}	models.Comment
+//	@Failure		403			{object}	error	"Not a moderator"
+//	@Failure		404			{object}	error	"Comment not held for review"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/comments/{commentID} [delete]
+func (app *application) rejectCommentHandler(w http.ResponseWriter, r *http.Request) {
+	app.reviewComment(w, r, models.StatusRejected)
+}
+
+// GetPendingMessages godoc
+//
+//	@Summary		Messages held for review
+//	@Description	Lists the messages held by the content filter, the oldest first. Reviewed messages leave the queue, reload the first page to get the next ones.
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Messages per page, up to 20"
+//	@Param			offset	query		int		false	"Offset in the queue"
+//	@Param			sort	query		string	false	"Sort by asc or desc creation"
+//	@Success		200		{object}	[]models.Message
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Failure		403		{object}	error	"Not a moderator"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/messages [get]
+func (app *application) getPendingMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	fq, ok := app.readQueueQuery(w, r)
+	if !ok {
+		return
+	}
+
+	messages, err := app.store.Conversations.GetPendingMessages(r.Context(), fq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, messages); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// ApproveMessage godoc
+//
+//	@Summary		Approve a held message
+//	@Description	Publish a message held for review, it is streamed to the other participants
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			messageID	path		int	true	"Message ID"
+//	@Success		200			{object}	models.Message
+//	@Failure		403			{object}	error	"Not a moderator"
+//	@Failure		404			{object}	error	"Message not held for review"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/messages/{messageID} [put]
+func (app *application) approveMessageHandler(w http.ResponseWriter, r *http.Request) {
+	app.reviewMessage(w, r, models.StatusPublished)
+}
+
+// RejectMessage godoc
+//
+//	@Summary		Reject a held message
+//	@Description	Reject a message held for review, it stays visible to its sender only
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			messageID	path		int	true	"Message ID"
+//	@Success		200			{object}	models.Message
+//	@Failure		403			{object}	error	"Not a moderator"
+//	@Failure		404			{object}	error	"Message not held for review"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/messages/{messageID} [delete]
+func (app *application) rejectMessageHandler(w http.ResponseWriter, r *http.Request) {
+	app.reviewMessage(w, r, models.StatusRejected)
+}
+
+// readQueueQuery reads the page of a moderation queue, it answers the request
+// when the page is invalid.
+func (app *application) readQueueQuery(w http.ResponseWriter, r *http.Request) (store.PaginatedFeedQuery, bool) {
+	fq := store.PaginatedFeedQuery{
+		Limit:  defaultQueueLimit,
+		Offset: 0,
+		Sort:   "asc",
+	}
+
+	fq, err := fq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return fq, false
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return fq, false
+	}
+
+	return fq, true
+}
+
+// reviewPost moves a held post to the status. The post is read from the
+// database, not the cache, so an edit made since is caught by the version.
+func (app *application) reviewPost(w http.ResponseWriter, r *http.Request, status string) {
+	postID, err := strconv.ParseInt(chi.URLParam(r, "postID"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	post, err := app.store.Posts.GetByID(ctx, postID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if post.Status != models.StatusPendingReview {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	post.Status = status
+	if err := app.store.Posts.SetStatus(ctx, post); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidatePost(ctx, post)
+
+	if post.Status == models.StatusPublished {
+		app.postApproved(ctx, post)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, post); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func (app *application) reviewComment(w http.ResponseWriter, r *http.Request, status string) {
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "commentID"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	comment, err := app.store.Comments.SetStatus(ctx, commentID, status)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if comment.Status == models.StatusPublished {
+		app.commentApproved(ctx, comment)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, comment); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+func (app *application) reviewMessage(w http.ResponseWriter, r *http.Request, status string) {
+	messageID, err := strconv.ParseInt(chi.URLParam(r, "messageID"), 10, 64)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	msg, err := app.store.Conversations.SetMessageStatus(ctx, messageID, status)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if msg.Status == models.StatusPublished {
+		app.messageApproved(ctx, msg)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, msg); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// postApproved does what the creation of the post skipped while it was held:
+// the mentions are notified and the post is streamed.
+func (app *application) postApproved(ctx context.Context, post *models.Post) {
+	app.publishPost(ctx, post)
+
+	author, err := app.getUser(ctx, post.UserID)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting post author", "user", post.UserID, "error", err.Error())
+		return
+	}
+
+	app.notifyMentions(ctx, author, post, post.Content, app.approvedMentions(ctx, post.Content))
+}
+
+// commentApproved notifies the post author and the mentions of the comment and
+// streams it, as its creation would have.
+func (app *application) commentApproved(ctx context.Context, comment *models.Comment) {
+	app.publishComment(ctx, comment)
+
+	post, err := app.getPost(ctx, comment.PostID)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting commented post", "post", comment.PostID, "error", err.Error())
+		return
+	}
+
+	author, err := app.getUser(ctx, comment.UserID)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting comment author", "user", comment.UserID, "error", err.Error())
+		return
+	}
+
+	app.notifyComment(ctx, author, post, comment)
+	app.notifyMentions(ctx, author, post, comment.Content, app.approvedMentions(ctx, comment.Content))
+}
+
+// messageApproved streams the message to the other participants.
+func (app *application) messageApproved(ctx context.Context, msg *models.Message) {
+	conv, err := app.store.Conversations.GetByID(ctx, msg.ConversationID, msg.UserID)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting conversation", "conversation", msg.ConversationID, "error", err.Error())
+		return
+	}
+
+	app.publishMessage(ctx, conv, msg)
+}
+
+// approvedMentions returns the users mentioned in approved content, they were
+// indexed but not notified while it was held.
+func (app *application) approvedMentions(ctx context.Context, content string) []models.User {
+	mentioned, err := app.resolveMentions(ctx, textparse.Parse(content).Mentions)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting mentioned users", "error", err.Error())
+		return nil
+	}
+
+	return mentioned
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/pubsub"
+	"SocialMedia/internal/store"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestModeration(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	moderator := models.User{ID: 1, Role: models.Role{Name: "moderator", Level: 2}}
+
+	users := app.store.Users.(*store.MockUserStore)
+	users.Users = map[int64]models.User{1: moderator}
+
+	posts := app.store.Posts.(*store.MockPostStore)
+	posts.Posts = map[int64]models.Post{
+		10: {ID: 10, UserID: 2, Status: models.StatusPendingReview, Visibility: models.VisibilityPublic},
+		11: {ID: 11, UserID: 2, Status: models.StatusPendingReview, Visibility: models.VisibilityPublic},
+		12: {ID: 12, UserID: 2, Status: models.StatusPublished, Visibility: models.VisibilityPublic},
+	}
+
+	notifications := app.store.Notifications.(*store.MockNotificationStore)
+
+	send := func(t *testing.T, method string, path string) int {
+		t.Helper()
+
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		return executeRequest(req, mux).Code
+	}
+
+	subscribe := func(t *testing.T, topic string) pubsub.Subscription {
+		t.Helper()
+
+		sub, err := app.pubsub.Subscribe(ctx, topic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sub.Close() })
+
+		return sub
+	}
+
+	received := func(sub pubsub.Subscription) bool {
+		select {
+		case <-sub.Messages():
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+
+	t.Run("should only let moderators review", func(t *testing.T) {
+		users.Users[1] = models.User{ID: 1, Role: models.Role{Name: "user", Level: 1}}
+		defer func() { users.Users[1] = moderator }()
+
+		checkResponseCode(t, http.StatusForbidden, send(t, http.MethodGet, "/v1/moderation/posts"))
+		checkResponseCode(t, http.StatusForbidden, send(t, http.MethodPut, "/v1/moderation/posts/10"))
+	})
+
+	t.Run("should list the held posts", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/moderation/posts", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var envelope struct {
+			Data []models.Post `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(envelope.Data) != 2 || envelope.Data[0].ID != 10 || envelope.Data[1].ID != 11 {
+			t.Errorf("expected the posts 10 and 11, got %v", envelope.Data)
+		}
+	})
+
+	t.Run("should publish an approved post", func(t *testing.T) {
+		sub := subscribe(t, authorTopic(2))
+
+		checkResponseCode(t, http.StatusOK, send(t, http.MethodPut, "/v1/moderation/posts/10"))
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodPut, "/v1/moderation/posts/10"))
+
+		if posts.Posts[10].Status != models.StatusPublished {
+			t.Errorf("expected the post to be published, got %s", posts.Posts[10].Status)
+		}
+
+		if !received(sub) {
+			t.Error("expected the post to be streamed")
+		}
+	})
+
+	t.Run("should keep a rejected post hidden", func(t *testing.T) {
+		sub := subscribe(t, authorTopic(2))
+
+		checkResponseCode(t, http.StatusOK, send(t, http.MethodDelete, "/v1/moderation/posts/11"))
+
+		if posts.Posts[11].Status != models.StatusRejected {
+			t.Errorf("expected the post to be rejected, got %s", posts.Posts[11].Status)
+		}
+
+		if received(sub) {
+			t.Error("expected the rejected post not to be streamed")
+		}
+	})
+
+	t.Run("should only review held posts", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodDelete, "/v1/moderation/posts/12"))
+	})
+
+	t.Run("should notify the post author of an approved comment", func(t *testing.T) {
+		comment := &models.Comment{PostID: 12, UserID: 3, Content: "held", Status: models.StatusPendingReview}
+		if err := app.store.Comments.Create(ctx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		sub := subscribe(t, postTopic(12))
+		path := "/v1/moderation/comments/" + strconv.FormatInt(comment.ID, 10)
+
+		checkResponseCode(t, http.StatusOK, send(t, http.MethodPut, path))
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodDelete, path))
+
+		if !received(sub) {
+			t.Error("expected the comment to be streamed")
+		}
+
+		found := false
+		for _, n := range notifications.Notifications {
+			if n.UserID == 2 && n.Kind == models.NotifyComment && n.Actor.ID == 3 {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("expected the post author to be notified of the comment")
+		}
+	})
+
+	t.Run("should stream an approved message", func(t *testing.T) {
+		conv := &models.Conversation{CreatorID: 3}
+		if err := app.store.Conversations.Create(ctx, conv, []int64{4}); err != nil {
+			t.Fatal(err)
+		}
+
+		held := &models.Message{ConversationID: conv.ID, UserID: 3, Content: "held", Status: models.StatusPendingReview}
+		rejected := &models.Message{ConversationID: conv.ID, UserID: 3, Content: "rejected", Status: models.StatusPendingReview}
+		for _, msg := range []*models.Message{held, rejected} {
+			if err := app.store.Conversations.CreateMessage(ctx, msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		sub := subscribe(t, userTopic(4))
+
+		checkResponseCode(t, http.StatusOK, send(t, http.MethodDelete, "/v1/moderation/messages/"+strconv.FormatInt(rejected.ID, 10)))
+		if received(sub) {
+			t.Error("expected the rejected message not to be streamed")
+		}
+
+		checkResponseCode(t, http.StatusOK, send(t, http.MethodPut, "/v1/moderation/messages/"+strconv.FormatInt(held.ID, 10)))
+		if !received(sub) {
+			t.Error("expected the approved message to be streamed")
+		}
+
+		pending, err := app.store.Conversations.GetPendingMessages(ctx, store.PaginatedFeedQuery{Limit: 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 0 {
+			t.Errorf("expected no message left in the queue, got %v", pending)
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// unsubscribeAll is the unsubscribe kind of the digest emails, it turns every
+// email notification off.
+const unsubscribeAll = "all"
+
+var errInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+type UpdateNotificationPreferencesPayload struct {
+	NewFollower *string `json:"new_follower" validate:"omitempty,oneof=instant daily off"`
+	Comment     *string `json:"comment" validate:"omitempty,oneof=instant daily off"`
+	Mention     *string `json:"mention" validate:"omitempty,oneof=instant daily off"`
+}
+
+// GetNotificationPreferences godoc
+//
+//	@Summary		Get notification preferences
+//	@Description	Get how the current user is notified by email of new followers, comments on their posts and mentions
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	models.NotificationPreferences
+//	@Security		ApiKeyAuth
+//	@Router			/user/notification-preferences [get]
+func (app *application) getNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	prefs, err := app.store.NotificationPreferences.Get(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, prefs); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// UpdateNotificationPreferences godoc
+//
+//	@Summary		Update notification preferences
+//	@Description	Choose between instant emails, the daily digest or no email at all for each kind of activity
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		UpdateNotificationPreferencesPayload	true	"Preferences to update"
+//	@Success		200		{object}	models.NotificationPreferences
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Security		ApiKeyAuth
+//	@Router			/user/notification-preferences [patch]
+func (app *application) updateNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	var payload UpdateNotificationPreferencesPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	prefs, err := app.store.NotificationPreferences.Get(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if payload.NewFollower != nil {
+		prefs.NewFollower = *payload.NewFollower
+	}
+
+	if payload.Comment != nil {
+		prefs.Comment = *payload.Comment
+	}
+
+	if payload.Mention != nil {
+		prefs.Mention = *payload.Mention
+	}
+
+	if err := app.store.NotificationPreferences.Update(ctx, prefs); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, prefs); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// unsubscribeConfirmation is the page of the unsubscribe link, the form posts
+// back to the same link. Link scanners and prefetchers only ever GET it.
+var unsubscribeConfirmation = template.Must(template.New("unsubscribe").Parse(`<!doctype html>
+<html lang="en">
+  <head>
+    <meta name="viewport" content="width=device-width" />
+    <title>Unsubscribe</title>
+  </head>
+  <body>
+    <form method="post">
+      <p>{{if eq . "all"}}Stop receiving notification emails?{{else}}Stop receiving these notification emails?{{end}}</p>
+      <button type="submit">Unsubscribe</button>
+    </form>
+  </body>
+</html>
+`))
+
+// ConfirmUnsubscribe godoc
+//
+//	@Summary		Confirm unsubscribing from notification emails
+//	@Description	Page of the unsubscribe link sent in the notification emails, it only asks for a confirmation which is posted back to the same link.
+//	@Tags			notifications
+//	@Produce		html
+//	@Param			token	query		string	true	"Signed unsubscribe token"
+//	@Success		200		{string}	string	"Confirmation page"
+//	@Failure		400		{object}	error	"Invalid token"
+//	@Router			/notifications/unsubscribe [get]
+func (app *application) confirmUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	_, kind, err := app.parseUnsubscribeToken(r.URL.Query().Get("token"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := unsubscribeConfirmation.Execute(w, kind); err != nil {
+		app.requestLogger(r.Context()).Errorw("error rendering the unsubscribe page", "error", err.Error())
+	}
+}
+
+// Unsubscribe godoc
+//
+//	@Summary		Unsubscribe from notification emails
+//	@Description	Turns off the emails of the unsubscribe link, it works without logging in. Posted by the confirmation page and by mail clients implementing the RFC 8058 one-click unsubscribe.
+//	@Tags			notifications
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			token	query		string				true	"Signed unsubscribe token"
+//	@Success		200		{object}	map[string]string	"Unsubscribed"
+//	@Failure		400		{object}	error				"Invalid token"
+//	@Router			/notifications/unsubscribe [post]
+func (app *application) unsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, kind, err := app.parseUnsubscribeToken(r.URL.Query().Get("token"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	prefs, err := app.store.NotificationPreferences.Get(ctx, userID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	switch kind {
+	case models.NotifyNewFollower:
+		prefs.NewFollower = models.DeliveryOff
+	case models.NotifyComment:
+		prefs.Comment = models.DeliveryOff
+	case models.NotifyMention:
+		prefs.Mention = models.DeliveryOff
+	default:
+		prefs.NewFollower = models.DeliveryOff
+		prefs.Comment = models.DeliveryOff
+		prefs.Mention = models.DeliveryOff
+	}
+
+	if err := app.store.NotificationPreferences.Update(ctx, prefs); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, map[string]string{"status": "unsubscribed", "kind": kind}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// unsubscribeURL returns the signed one-click link turning off the emails of
+// the given kind for the user.
+func (app *application) unsubscribeURL(userID int64, kind string) string {
+	token := app.signer.Sign(fmt.Sprintf("unsubscribe:%d:%s", userID, kind))
+
+	return app.config.notifications.unsubscribeURL + "?token=" + url.QueryEscape(token)
+}
+
+func (app *application) parseUnsubscribeToken(token string) (int64, string, error) {
+	payload, err := app.signer.Verify(token)
+	if err != nil {
+		return 0, "", errInvalidUnsubscribeToken
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 || parts[0] != "unsubscribe" {
+		return 0, "", errInvalidUnsubscribeToken
+	}
+
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, "", errInvalidUnsubscribeToken
+	}
+
+	switch parts[2] {
+	case models.NotifyNewFollower, models.NotifyComment, models.NotifyMention, unsubscribeAll:
+		return userID, parts[2], nil
+	default:
+		return 0, "", errInvalidUnsubscribeToken
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestUnsubscribe(t *testing.T) {
+	cfg := config{
+		notifications: notificationConfig{
+			unsubscribeURL: "http://localhost:8080/v1/notifications/unsubscribe",
+		},
+	}
+
+	app := newTestApplication(t, cfg)
+	mux := app.mount()
+
+	prefStore := app.store.NotificationPreferences.(*store.MockNotificationPreferenceStore)
+
+	link := app.unsubscribeURL(7, models.NotifyComment)
+	if !strings.HasPrefix(link, cfg.notifications.unsubscribeURL) {
+		t.Fatalf("unexpected unsubscribe link %s", link)
+	}
+
+	t.Run("should only ask for a confirmation when the link is opened", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, strings.TrimPrefix(link, "http://localhost:8080"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := executeRequest(req, mux)
+
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		if !strings.Contains(rr.Body.String(), `<form method="post">`) {
+			t.Errorf("expected a confirmation form, got %s", rr.Body.String())
+		}
+
+		prefs, _ := prefStore.Get(context.Background(), 7)
+		if prefs.Comment != models.DeliveryInstant {
+			t.Errorf("expected the preferences to be left as is, got %+v", prefs)
+		}
+	})
+
+	t.Run("should turn off the emails of the signed kind without logging in", func(t *testing.T) {
+		body := strings.NewReader("List-Unsubscribe=One-Click")
+
+		req, err := http.NewRequest(http.MethodPost, strings.TrimPrefix(link, "http://localhost:8080"), body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := executeRequest(req, mux)
+
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		prefs, _ := prefStore.Get(context.Background(), 7)
+		if prefs.Comment != models.DeliveryOff || prefs.Mention != models.DeliveryInstant {
+			t.Errorf("expected only comment emails to be turned off, got %+v", prefs)
+		}
+	})
+
+	t.Run("should reject a tampered token", func(t *testing.T) {
+		token := app.signer.Sign("unsubscribe:7:all")
+		tampered := strings.Replace(token, ".", "x.", 1)
+
+		req, err := http.NewRequest(http.MethodPost, "/v1/notifications/unsubscribe?token="+url.QueryEscape(tampered), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := executeRequest(req, mux)
+
+		checkResponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestNotifyByEmail(t *testing.T) {
+	app := newTestApplication(t, config{})
+	ctx := context.Background()
+
+	outbox := app.store.Outbox.(*store.MockOutboxStore)
+	prefStore := app.store.NotificationPreferences.(*store.MockNotificationPreferenceStore)
+
+	follower := &models.User{ID: 1, Username: "gopher"}
+
+	t.Run("should queue an instant email by default", func(t *testing.T) {
+		app.notifyFollow(ctx, follower, 2, false)
+
+		if len(outbox.Pending) != 1 || outbox.Pending[0].Template != notificationTemplates[models.NotifyNewFollower] {
+			t.Fatalf("expected a new follower email to be queued, got %v", outbox.Pending)
+		}
+	})
+
+	t.Run("should leave daily notifications to the digest", func(t *testing.T) {
+		outbox.Pending = nil
+
+		prefs := models.DefaultNotificationPreferences(2)
+		prefs.NewFollower = models.DeliveryDaily
+		prefStore.Update(ctx, prefs)
+
+		app.notifyFollow(ctx, follower, 2, false)
+
+		if len(outbox.Pending) != 0 {
+			t.Errorf("expected no email to be queued, got %v", outbox.Pending)
+		}
+	})
+}
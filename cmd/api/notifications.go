@@ -0,0 +1,167 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type NotificationsPage struct {
+	Notifications []models.Notification `json:"notifications"`
+	// Empty on the last page
+	NextCursor  string `json:"next_cursor,omitempty"`
+	UnreadCount int    `json:"unread_count"`
+}
+
+// GetNotifications godoc
+//
+//	@Summary		List notifications
+//	@Description	List the notifications of the current user, the most recently updated first. Pass next_cursor as cursor to get the next page. A notification updated while paging moves to the top, past the cursor, so the next pages skip it: reload the first page to catch up.
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Notifications per page, up to 50"
+//	@Param			cursor	query		string	false	"Cursor of the page"
+//	@Success		200		{object}	NotificationsPage
+//	@Failure		400		{object}	error	"Invalid cursor"
+//	@Security		ApiKeyAuth
+//	@Router			/notifications [get]
+func (app *application) getNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	cq := store.CursorQuery{
+		Limit: 20,
+	}
+
+	cq, err := cq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(cq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	notifications, err := app.store.Notifications.GetByUserID(ctx, user.ID, cq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	unread, err := app.store.Notifications.CountUnread(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	page := NotificationsPage{
+		Notifications: notifications,
+		UnreadCount:   unread,
+	}
+
+	//? A full page means there may be more. The cursor is on the time of the last update so
+	//? the groups that get new actors move up, the pages after the cursor don't see them again
+	if len(notifications) == cq.Limit {
+		last := notifications[len(notifications)-1]
+		page.NextCursor = store.EncodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetUnreadNotificationsCount godoc
+//
+//	@Summary		Count unread notifications
+//	@Description	Get the number of unread notifications of the current user
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	map[string]int
+//	@Security		ApiKeyAuth
+//	@Router			/notifications/unread-count [get]
+func (app *application) getUnreadNotificationsCountHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	unread, err := app.store.Notifications.CountUnread(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, map[string]int{"unread_count": unread}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// MarkNotificationRead godoc
+//
+//	@Summary		Mark a notification as read
+//	@Description	Mark a notification of the current user as read, later events start a new notification instead of being grouped with it
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			notificationID	path		int		true	"Notification ID"
+//	@Success		204				{string}	string	"Notification marked as read"
+//	@Failure		400				{object}	error	"Invalid request"
+//	@Failure		404				{object}	error	"Notification not found"
+//	@Security		ApiKeyAuth
+//	@Router			/notifications/{notificationID}/read [put]
+func (app *application) markNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	notificationID, err := strconv.ParseInt(chi.URLParam(r, "notificationID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Notifications.MarkRead(r.Context(), user.ID, notificationID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// MarkAllNotificationsRead godoc
+//
+//	@Summary		Mark all notifications as read
+//	@Description	Mark every notification of the current user as read
+//	@Tags			notifications
+//	@Accept			json
+//	@Produce		json
+//	@Success		204	{string}	string	"Notifications marked as read"
+//	@Security		ApiKeyAuth
+//	@Router			/notifications/read [put]
+func (app *application) markAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	if err := app.store.Notifications.MarkAllRead(r.Context(), user.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
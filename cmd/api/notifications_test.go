@@ -0,0 +1,121 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestNotifications(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+	ctx := context.Background()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notificationStore := app.store.Notifications.(*store.MockNotificationStore)
+
+	recipient := &models.User{ID: 1, Username: "author"}
+	post := &models.Post{ID: 10, UserID: 1, Title: "Hello"}
+
+	getPage := func(t *testing.T) NotificationsPage {
+		req, err := http.NewRequest(http.MethodGet, "/v1/notifications", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var body struct {
+			Data NotificationsPage `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		return body.Data
+	}
+
+	t.Run("should group comments on the same post", func(t *testing.T) {
+		for _, actorID := range []int64{2, 3, 2} {
+			app.notify(ctx, notification{
+				kind:      models.NotifyComment,
+				recipient: recipient,
+				actor:     &models.User{ID: actorID, Username: "gopher"},
+				post:      post,
+			})
+		}
+
+		page := getPage(t)
+
+		if len(page.Notifications) != 1 {
+			t.Fatalf("expected a single grouped notification, got %d", len(page.Notifications))
+		}
+
+		if page.Notifications[0].ActorCount != 2 {
+			t.Errorf("expected 2 distinct actors, got %d", page.Notifications[0].ActorCount)
+		}
+
+		if page.UnreadCount != 1 {
+			t.Errorf("expected 1 unread notification, got %d", page.UnreadCount)
+		}
+	})
+
+	t.Run("should start a new group once read", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, "/v1/notifications/read", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusNoContent, rr.Code)
+
+		app.notify(ctx, notification{
+			kind:      models.NotifyComment,
+			recipient: recipient,
+			actor:     &models.User{ID: 4, Username: "gopher"},
+			post:      post,
+		})
+
+		page := getPage(t)
+
+		if len(page.Notifications) != 2 || page.UnreadCount != 1 {
+			t.Errorf("expected 2 notifications with 1 unread, got %d with %d unread", len(page.Notifications), page.UnreadCount)
+		}
+	})
+
+	t.Run("should not notify users of their own actions", func(t *testing.T) {
+		before := len(notificationStore.Notifications)
+
+		app.notify(ctx, notification{
+			kind:      models.NotifyComment,
+			recipient: recipient,
+			actor:     recipient,
+			post:      post,
+		})
+
+		if len(notificationStore.Notifications) != before {
+			t.Errorf("expected no notification to be created")
+		}
+	})
+
+	t.Run("should reject an invalid cursor", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/notifications?cursor=nope", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusBadRequest, rr.Code)
+	})
+}
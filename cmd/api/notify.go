@@ -0,0 +1,227 @@
+package main
+
+import (
+	"SocialMedia/internal/mailer"
+	"SocialMedia/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// maxMentionNotifications caps the users notified for a single post or comment
+const maxMentionNotifications = 10
+
+var notificationTemplates = map[string]string{
+	models.NotifyNewFollower: mailer.NewFollowerTemplate,
+	models.NotifyComment:     mailer.CommentTemplate,
+	models.NotifyMention:     mailer.MentionTemplate,
+}
+
+// notification is an activity a user should hear about.
+type notification struct {
+	kind      string
+	recipient *models.User
+	actor     *models.User
+	post      *models.Post // nil for follows
+	content   string
+}
+
+// groupKey identifies the notifications merged together while unread: all
+// the new followers, or the comments and mentions of a single post.
+func (n notification) groupKey() string {
+	if n.post == nil {
+		return n.kind
+	}
+
+	return fmt.Sprintf("%s:%d", n.kind, n.post.ID)
+}
+
+// notificationMail is the data of the instant notification emails.
+type notificationMail struct {
+	Username       string
+	ActorName      string
+	PostTitle      string
+	Excerpt        string
+	URL            string
+	UnsubscribeURL string
+}
+
+// notify records the in-app notification and emails it according to the
+// recipient's preferences. Failures are logged, they never fail the action
+// that triggered them.
+func (app *application) notify(ctx context.Context, n notification) {
+	if n.recipient.ID == n.actor.ID {
+		return
+	}
+
+	inApp := &models.Notification{
+		UserID:   n.recipient.ID,
+		Kind:     n.kind,
+		GroupKey: n.groupKey(),
+		Actor:    models.User{ID: n.actor.ID, Username: n.actor.Username},
+	}
+
+	if n.post != nil {
+		inApp.PostID = &n.post.ID
+	}
+
+	if err := app.store.Notifications.Create(ctx, inApp); err != nil {
+		app.requestLogger(ctx).Errorw("error creating notification", "user", n.recipient.ID, "kind", n.kind, "error", err.Error())
+	} else {
+		app.publish(ctx, userTopic(n.recipient.ID), eventNotification, 0, inApp)
+	}
+
+	prefs, err := app.store.NotificationPreferences.Get(ctx, n.recipient.ID)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting notification preferences", "user", n.recipient.ID, "error", err.Error())
+		return
+	}
+
+	//? Daily deliveries are picked up by the digest job
+	if prefs.Delivery(n.kind) != models.DeliveryInstant {
+		return
+	}
+
+	data := notificationMail{
+		Username:       n.recipient.Username,
+		ActorName:      n.actor.Username,
+		Excerpt:        excerpt(n.content),
+		URL:            app.userURL(n.actor.ID),
+		UnsubscribeURL: app.unsubscribeURL(n.recipient.ID, n.kind),
+	}
+
+	if n.post != nil {
+		data.PostTitle = n.post.Title
+		data.URL = app.postURL(n.post.ID)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error encoding notification email", "user", n.recipient.ID, "error", err.Error())
+		return
+	}
+
+	mail := &models.OutboxMail{
+		Template: notificationTemplates[n.kind],
+		Username: n.recipient.Username,
+		Email:    n.recipient.Email,
+		Locale:   n.recipient.Language,
+		Data:     raw,
+	}
+
+	if err := app.store.Outbox.Enqueue(ctx, mail); err != nil {
+		app.requestLogger(ctx).Errorw("error queuing notification email", "user", n.recipient.ID, "kind", n.kind, "error", err.Error())
+	}
+}
+
+// notifyFollow tells the followed user about the new follower, or about the
+// follow request when the account is private.
+func (app *application) notifyFollow(ctx context.Context, follower *models.User, followedID int64, pending bool) {
+	recipient, err := app.getUser(ctx, followedID)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting followed user", "user", followedID, "error", err.Error())
+		return
+	}
+
+	kind := models.NotifyNewFollower
+	if pending {
+		kind = models.NotifyFollowRequest
+	}
+
+	app.notify(ctx, notification{
+		kind:      kind,
+		recipient: recipient,
+		actor:     follower,
+	})
+}
+
+func (app *application) notifyComment(ctx context.Context, author *models.User, post *models.Post, comment *models.Comment) {
+	if post.UserID == author.ID {
+		return
+	}
+
+	recipient, err := app.getUser(ctx, post.UserID)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting post author", "user", post.UserID, "error", err.Error())
+		return
+	}
+
+	app.notify(ctx, notification{
+		kind:      models.NotifyComment,
+		recipient: recipient,
+		actor:     author,
+		post:      post,
+		content:   comment.Content,
+	})
+}
+
+// notifyMentions notifies the users mentioned in the content of a post or a
+// comment, as long as they are allowed to see it.
+func (app *application) notifyMentions(ctx context.Context, author *models.User, post *models.Post, content string, mentioned []models.User) {
+	for i := range mentioned {
+		recipient := &mentioned[i]
+
+		if recipient.ID == author.ID {
+			continue
+		}
+
+		visible, err := app.canViewPost(ctx, recipient, post)
+		if err != nil {
+			app.requestLogger(ctx).Errorw("error checking post visibility", "post", post.ID, "user", recipient.ID, "error", err.Error())
+			continue
+		}
+
+		//? Comments may be written by someone else than the post author
+		blocked, err := app.store.Blocks.IsBlocked(ctx, author.ID, recipient.ID)
+		if err != nil {
+			app.requestLogger(ctx).Errorw("error checking blocks", "user", recipient.ID, "error", err.Error())
+			continue
+		}
+
+		if !visible || blocked {
+			continue
+		}
+
+		app.notify(ctx, notification{
+			kind:      models.NotifyMention,
+			recipient: recipient,
+			actor:     author,
+			post:      post,
+			content:   content,
+		})
+	}
+}
+
+// resolveMentions returns the users matching the mentioned usernames, up to
+// maxMentionNotifications of them.
+func (app *application) resolveMentions(ctx context.Context, usernames []string) ([]models.User, error) {
+	if len(usernames) == 0 {
+		return []models.User{}, nil
+	}
+
+	if len(usernames) > maxMentionNotifications {
+		usernames = usernames[:maxMentionNotifications]
+	}
+
+	return app.store.Users.GetByUsernames(ctx, usernames)
+}
+
+func (app *application) postURL(postID int64) string {
+	return fmt.Sprintf("%s/posts/%d", app.config.frontendURL, postID)
+}
+
+func (app *application) userURL(userID int64) string {
+	return fmt.Sprintf("%s/users/%d", app.config.frontendURL, userID)
+}
+
+// excerpt shortens the content for emails.
+func excerpt(content string) string {
+	const maxLength = 200
+
+	runes := []rune(content)
+	if len(runes) <= maxLength {
+		return content
+	}
+
+	return string(runes[:maxLength]) + "…"
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type outboxConfig struct {
+	workers      int
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	lease        time.Duration
+}
+
+// startMailWorkers starts the pool of workers delivering the emails queued in
+// the outbox. The workers stop once ctx is cancelled, wait on the returned
+// WaitGroup for the in-flight deliveries to finish.
+func (app *application) startMailWorkers(ctx context.Context) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < app.config.mail.outbox.workers; i++ {
+		wg.Add(1)
+
+		go func(id int) {
+			defer wg.Done()
+			app.runMailWorker(ctx, id)
+		}(i)
+	}
+
+	app.logger.Infow("mail workers started", "workers", app.config.mail.outbox.workers)
+
+	return wg
+}
+
+func (app *application) runMailWorker(ctx context.Context, id int) {
+	ticker := time.NewTicker(app.config.mail.outbox.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while there is work, otherwise wait for the next tick
+		if n := app.processMailBatch(ctx); n > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			app.logger.Infow("mail worker stopped", "worker", id)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processMailBatch claims a batch of due emails and delivers them, it returns
+// the number of emails processed.
+func (app *application) processMailBatch(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	cfg := app.config.mail.outbox
+
+	mails, err := app.store.Outbox.Claim(ctx, cfg.batchSize, cfg.lease)
+	if err != nil {
+		app.logger.Errorw("error claiming outbox mails", "error", err.Error())
+		return 0
+	}
+
+	for _, mail := range mails {
+		//? Deliveries that already started are finished even during a shutdown
+		app.deliverMail(context.WithoutCancel(ctx), mail)
+	}
+
+	return len(mails)
+}
+
+func (app *application) deliverMail(ctx context.Context, mail models.OutboxMail) {
+	cfg := app.config.mail.outbox
+	isProdEnv := app.config.env == "production"
+
+	var data map[string]any
+	err := json.Unmarshal(mail.Data, &data)
+
+	if err == nil {
+		var status int
+		status, err = app.mailer.Send(mail.Template, mail.Username, mail.Email, mail.Locale, data, !isProdEnv)
+		if err == nil {
+			app.logger.Infow("mail delivered", "id", mail.ID, "template", mail.Template, "status code", status)
+			app.metrics.Mail(mail.Template, "sent")
+
+			if err := app.store.Outbox.MarkSent(ctx, &mail); err != nil {
+				app.logOutboxUpdate(&mail, "sent", err)
+			}
+			return
+		}
+	}
+
+	if mail.Attempts >= cfg.maxAttempts {
+		app.logger.Errorw("mail moved to dead letter", "id", mail.ID, "attempts", mail.Attempts, "error", err.Error())
+		app.metrics.Mail(mail.Template, "dead")
+
+		if err := app.store.Outbox.MarkDead(ctx, &mail, err.Error()); err != nil {
+			app.logOutboxUpdate(&mail, "dead", err)
+		}
+		return
+	}
+
+	retryAt := time.Now().Add(backoff(mail.Attempts, cfg.baseBackoff, cfg.maxBackoff))
+	app.logger.Warnw("mail delivery failed", "id", mail.ID, "attempts", mail.Attempts, "retry at", retryAt, "error", err.Error())
+	app.metrics.Mail(mail.Template, "retried")
+
+	if err := app.store.Outbox.MarkFailed(ctx, &mail, err.Error(), retryAt); err != nil {
+		app.logOutboxUpdate(&mail, "failed", err)
+	}
+}
+
+// logOutboxUpdate logs an email whose status couldn't be recorded. Losing the
+// lease only means the email was claimed again, it may be delivered twice.
+func (app *application) logOutboxUpdate(mail *models.OutboxMail, status string, err error) {
+	if errors.Is(err, store.ErrConflict) {
+		app.logger.Warnw("mail lease lost", "id", mail.ID, "attempts", mail.Attempts, "status", status)
+		return
+	}
+
+	app.logger.Errorw("error marking mail as "+status, "id", mail.ID, "error", err.Error())
+}
+
+// backoff is an exponential backoff with jitter, capped at max.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := base << (attempt - 1)
+	if d <= 0 || d > max {
+		d = max
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"SocialMedia/internal/mailer"
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMailWorker(t *testing.T) {
+	cfg := config{
+		mail: mailConfig{
+			outbox: outboxConfig{
+				batchSize:   10,
+				maxAttempts: 3,
+				baseBackoff: time.Second,
+				maxBackoff:  time.Minute,
+			},
+		},
+	}
+
+	app := newTestApplication(t, cfg)
+	ctx := context.Background()
+
+	t.Run("should deliver queued mails", func(t *testing.T) {
+		outbox := app.store.Outbox.(*store.MockOutboxStore)
+		inbox := app.mailer.(*mailer.InMemoryMailer)
+		inbox.Reset()
+
+		outbox.Enqueue(ctx, &models.OutboxMail{
+			Template: mailer.UserWelcomeTemplate,
+			Username: "gopher",
+			Email:    "gopher@example.com",
+			Data:     []byte(`{"Username":"gopher","ActivationURL":"http://localhost:3000/confirm/token"}`),
+		})
+
+		if n := app.processMailBatch(ctx); n != 1 {
+			t.Fatalf("expected 1 mail to be processed, got %d", n)
+		}
+
+		messages := inbox.Messages()
+		if len(messages) != 1 || messages[0].To.Address != "gopher@example.com" {
+			t.Fatalf("expected the mail to be delivered to gopher@example.com, got %v", messages)
+		}
+
+		if len(outbox.Sent) != 1 {
+			t.Errorf("expected the mail to be marked as sent")
+		}
+	})
+
+	t.Run("should retry and then dead letter failing mails", func(t *testing.T) {
+		outbox := app.store.Outbox.(*store.MockOutboxStore)
+
+		failing := models.OutboxMail{ID: 42, Template: "missing.tmpl", Email: "gopher@example.com"}
+
+		failing.Attempts = 1
+		app.deliverMail(ctx, failing)
+
+		if len(outbox.Failed) != 1 || len(outbox.Dead) != 0 {
+			t.Fatalf("expected the mail to be retried, failed: %v dead: %v", outbox.Failed, outbox.Dead)
+		}
+
+		failing.Attempts = cfg.mail.outbox.maxAttempts
+		app.deliverMail(ctx, failing)
+
+		if len(outbox.Dead) != 1 {
+			t.Fatalf("expected the mail to be dead lettered, dead: %v", outbox.Dead)
+		}
+	})
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"SocialMedia/internal/contentfilter"
 	"SocialMedia/internal/models"
 	"SocialMedia/internal/store"
+	"SocialMedia/internal/textparse"
 	"context"
 	"errors"
 	"net/http"
@@ -16,15 +18,17 @@ type postKey string
 const postCtx postKey = "post"
 
 type CreatePostPayload struct {
-	Title   string   `json:"title" validate:"required,max=100"`
-	Content string   `json:"content" validate:"required,max=1000"`
-	Tags    []string `json:"tags"`
+	Title      string   `json:"title" validate:"required,max=100"`
+	Content    string   `json:"content" validate:"required,max=1000"`
+	Tags       []string `json:"tags"`
+	Visibility string   `json:"visibility" validate:"omitempty,oneof=public followers unlisted"`
 }
 
 type UpdatePostPayload struct {
-	Title   *string   `json:"title" validate:"omitempty,max=100"`
-	Content *string   `json:"content" validate:"omitempty,max=1000"`
-	Tags    *[]string `json:"tags" validate:"omitempty"`
+	Title      *string   `json:"title" validate:"omitempty,max=100"`
+	Content    *string   `json:"content" validate:"omitempty,max=1000"`
+	Tags       *[]string `json:"tags" validate:"omitempty"`
+	Visibility *string   `json:"visibility" validate:"omitempty,oneof=public followers unlisted"`
 }
 
 // CreatePosts godoc
@@ -36,7 +40,9 @@ type UpdatePostPayload struct {
 //	@Produce		json
 //	@Param			body	body		CreatePostPayload	true	"Request body with post details"
 //	@Success		201		{object}	models.Post			"Created post information"
+//	@Success		202		{object}	models.Post			"Post held for review"
 //	@Failure		400		{object}	error				"Invalid request"
+//	@Failure		422		{object}	error				"Rejected by the content filter"
 //	@Security		ApiKeyAuth
 //	@Router			/posts [post]
 func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request) {
@@ -52,22 +58,49 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	user := getUserFromCtx(r)
+	ctx := r.Context()
 
-	post := &models.Post{
-		Title:   payload.Title,
-		Content: payload.Content,
-		Tags:    payload.Tags,
-		UserID:  user.ID,
+	status, err := app.filterContent(ctx, contentfilter.Content{
+		Kind:   contentfilter.KindPost,
+		UserID: user.ID,
+		Title:  payload.Title,
+		Body:   payload.Content,
+	})
+	if err != nil {
+		app.contentRejectedResponse(w, r, err)
+		return
 	}
 
-	ctx := r.Context()
+	entities := textparse.Parse(payload.Content)
+
+	post := &models.Post{
+		Title:      payload.Title,
+		Content:    payload.Content,
+		Tags:       postTags(payload.Tags, nil, entities.Hashtags),
+		UserID:     user.ID,
+		Status:     status,
+		Visibility: payload.Visibility,
+	}
 
 	if err := app.store.Posts.Create(ctx, post); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
-	if err := app.jsonResponse(w, http.StatusCreated, post); err != nil {
+	app.invalidatePost(ctx, post)
+
+	mentioned := app.indexPost(ctx, post, entities)
+
+	// Held posts are stored but only visible to their author until reviewed
+	code := http.StatusCreated
+	if post.Status == models.StatusPendingReview {
+		code = http.StatusAccepted
+	} else {
+		app.notifyMentions(ctx, user, post, post.Content, mentioned)
+		app.publishPost(ctx, post)
+	}
+
+	if err := app.jsonResponse(w, code, post); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -86,9 +119,24 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{id} [get]
 func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
 	post := getPostFromCtx(r)
 
-	comments, err := app.store.Comments.GetByPostID(r.Context(), post.ID)
+	ctx := r.Context()
+
+	visible, err := app.canViewPost(ctx, user, post)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	//* Hide the post entirely instead of returning forbidden so the viewer can't tell it exists
+	if !visible {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	comments, err := app.store.Comments.GetByPostID(ctx, post.ID, user.ID)
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -96,6 +144,14 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 
 	post.Comments = *comments
 
+	previews, err := app.store.LinkPreviews.GetByPostIDs(ctx, []int64{post.ID})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	post.LinkPreviews = previewsOf(previews, post.ID)
+
 	if err := app.jsonResponse(w, http.StatusOK, post); err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -127,6 +183,12 @@ func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	app.invalidatePost(r.Context(), post)
+
+	for _, a := range post.Attachments {
+		app.deleteBlobs(r.Context(), a.Key, a.ThumbnailKey)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -143,6 +205,7 @@ func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request
 //	@Failure		404		{object}	error				"Post not found"
 //	@Failure		400		{object}	error				"Invalid request"
 //	@Failure		409		{object}	error				"Conflict occurred while updating"
+//	@Failure		422		{object}	error				"Rejected by the content filter"
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{id} [patch]
 func (app *application) patchPostHandler(w http.ResponseWriter, r *http.Request) {
@@ -159,6 +222,8 @@ func (app *application) patchPostHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	previous := textparse.Hashtags(post.Content)
+
 	if payload.Content != nil {
 		post.Content = *payload.Content
 	}
@@ -169,7 +234,32 @@ func (app *application) patchPostHandler(w http.ResponseWriter, r *http.Request)
 		post.Tags = *payload.Tags
 	}
 
-	if err := app.updatePost(r.Context(), post); err != nil {
+	entities := textparse.Parse(post.Content)
+	post.Tags = postTags(post.Tags, previous, entities.Hashtags)
+	if payload.Visibility != nil {
+		post.Visibility = *payload.Visibility
+	}
+
+	ctx := r.Context()
+
+	status, err := app.filterContent(ctx, contentfilter.Content{
+		Kind:   contentfilter.KindPost,
+		UserID: post.UserID,
+		Title:  post.Title,
+		Body:   post.Content,
+		Edit:   true,
+	})
+	if err != nil {
+		app.contentRejectedResponse(w, r, err)
+		return
+	}
+
+	//? An edit can put a post on hold but never lifts an existing hold, the moderators do
+	if status == models.StatusPendingReview {
+		post.Status = status
+	}
+
+	if err := app.updatePost(ctx, post); err != nil {
 		switch err {
 		case store.ErrConflict:
 			app.conflictResponse(w, r, err)
@@ -179,6 +269,17 @@ func (app *application) patchPostHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	//? Only the users mentioned by the edit are notified
+	mentioned := app.indexPost(ctx, post, entities)
+	if post.Status == models.StatusPublished && len(mentioned) > 0 {
+		author, err := app.getUser(ctx, post.UserID)
+		if err != nil {
+			app.requestLogger(r.Context()).Errorw("error getting post author", "user", post.UserID, "error", err.Error())
+		} else {
+			app.notifyMentions(ctx, author, post, post.Content, mentioned)
+		}
+	}
+
 	if err := app.jsonResponse(w, http.StatusAccepted, post); err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -197,7 +298,7 @@ func (app *application) postsContextMiddleware(next http.Handler) http.Handler {
 
 		ctx := r.Context()
 
-		post, err := app.store.Posts.GetByID(ctx, postID)
+		post, err := app.getPost(ctx, postID)
 		if err != nil {
 			switch {
 			case errors.Is(err, store.ErrNotFound):
@@ -213,6 +314,42 @@ func (app *application) postsContextMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// canViewPost hides posts that are held for review from everyone but their
+// author, as well as posts the viewer can't see because of the author's
+// privacy, the post visibility or a block. Unlisted posts are visible to
+// anyone that has their ID.
+func (app *application) canViewPost(ctx context.Context, user *models.User, post *models.Post) (bool, error) {
+	if post.UserID == user.ID {
+		return true, nil
+	}
+
+	if post.Status != models.StatusPublished {
+		return false, nil
+	}
+
+	author, err := app.getUser(ctx, post.UserID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	allowed, err := app.canViewUserContent(ctx, user, author)
+	if err != nil || !allowed {
+		return false, err
+	}
+
+	//? Following a private account was already checked above
+	if post.Visibility == models.VisibilityFollowers && !author.IsPrivate {
+		return app.store.Followers.IsFollowing(ctx, user.ID, author.ID)
+	}
+
+	return true, nil
+}
+
 func getPostFromCtx(r *http.Request) *models.Post {
 	post, _ := r.Context().Value(postCtx).(*models.Post)
 
@@ -225,5 +362,23 @@ func (app *application) updatePost(ctx context.Context, post *models.Post) error
 	}
 
 	app.cacheStorage.Users.Delete(ctx, post.UserID)
+	app.invalidatePost(ctx, post)
 	return nil
 }
+
+// getPost reads the post through the cache, every post route looks it up.
+func (app *application) getPost(ctx context.Context, postID int64) (*models.Post, error) {
+	return app.cacheStorage.Posts.GetOrLoad(ctx, strconv.FormatInt(postID, 10), func(ctx context.Context) (*models.Post, error) {
+		return app.store.Posts.GetByID(ctx, postID)
+	})
+}
+
+// invalidatePost removes the post and the cached feed of its author, the feeds
+// of the followers expire on their own.
+func (app *application) invalidatePost(ctx context.Context, post *models.Post) {
+	if err := app.cacheStorage.Posts.Delete(ctx, strconv.FormatInt(post.ID, 10)); err != nil {
+		app.requestLogger(ctx).Errorw("failed to invalidate the cached post", "post", post.ID, "error", err.Error())
+	}
+
+	app.invalidateFeed(ctx, post.UserID)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"testing"
+)
+
+func TestCanViewPost(t *testing.T) {
+	app := newTestApplication(t, config{})
+
+	ctx := context.Background()
+	users := app.store.Users.(*store.MockUserStore)
+	followers := app.store.Followers.(*store.MockFollowerStore)
+
+	const (
+		publicAuthor  = 2
+		privateAuthor = 3
+		stranger      = 4
+		follower      = 5
+		blocked       = 6
+	)
+
+	users.Users = map[int64]models.User{
+		publicAuthor:  {ID: publicAuthor},
+		privateAuthor: {ID: privateAuthor, IsPrivate: true},
+	}
+
+	followers.Follows = map[[2]int64]bool{
+		{follower, publicAuthor}:  true,
+		{follower, privateAuthor}: true,
+	}
+
+	for _, author := range []int64{publicAuthor, privateAuthor} {
+		if err := app.store.Blocks.Block(ctx, author, blocked); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post := func(author int64, visibility string) *models.Post {
+		return &models.Post{ID: 1, UserID: author, Status: models.StatusPublished, Visibility: visibility}
+	}
+
+	held := post(publicAuthor, models.VisibilityPublic)
+	held.Status = models.StatusPendingReview
+
+	tests := []struct {
+		name    string
+		post    *models.Post
+		visible map[int64]bool
+	}{
+		{
+			name:    "public post",
+			post:    post(publicAuthor, models.VisibilityPublic),
+			visible: map[int64]bool{publicAuthor: true, stranger: true, follower: true, blocked: false},
+		},
+		{
+			name:    "followers post",
+			post:    post(publicAuthor, models.VisibilityFollowers),
+			visible: map[int64]bool{publicAuthor: true, stranger: false, follower: true, blocked: false},
+		},
+		{
+			name:    "unlisted post",
+			post:    post(publicAuthor, models.VisibilityUnlisted),
+			visible: map[int64]bool{publicAuthor: true, stranger: true, follower: true, blocked: false},
+		},
+		{
+			name:    "public post of a private author",
+			post:    post(privateAuthor, models.VisibilityPublic),
+			visible: map[int64]bool{privateAuthor: true, stranger: false, follower: true, blocked: false},
+		},
+		{
+			name:    "followers post of a private author",
+			post:    post(privateAuthor, models.VisibilityFollowers),
+			visible: map[int64]bool{privateAuthor: true, stranger: false, follower: true, blocked: false},
+		},
+		{
+			name:    "unlisted post of a private author",
+			post:    post(privateAuthor, models.VisibilityUnlisted),
+			visible: map[int64]bool{privateAuthor: true, stranger: false, follower: true, blocked: false},
+		},
+		{
+			name:    "post held for review",
+			post:    held,
+			visible: map[int64]bool{publicAuthor: true, stranger: false, follower: false, blocked: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for viewer, want := range tt.visible {
+				got, err := app.canViewPost(ctx, &models.User{ID: viewer}, tt.post)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got != want {
+					t.Errorf("viewer %d: expected visible to be %v, got %v", viewer, want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/unfurl"
+	"context"
+	"sync"
+	"time"
+)
+
+type previewConfig struct {
+	workers   int
+	queueSize int
+	// Links of a post that get a preview, in order of appearance
+	maxLinks int
+	// Previews are fetched again once they are older than ttl, failures included
+	ttl    time.Duration
+	unfurl unfurl.Options
+}
+
+// indexLinks records the links of the post and queues them for the unfurl
+// workers, posting never waits on the pages being fetched.
+func (app *application) indexLinks(ctx context.Context, post *models.Post, urls []string) {
+	urls = urls[:min(len(urls), app.config.previews.maxLinks)]
+
+	if err := app.store.LinkPreviews.SetPostLinks(ctx, post.ID, urls); err != nil {
+		app.requestLogger(ctx).Errorw("error saving links", "post", post.ID, "error", err.Error())
+		return
+	}
+
+	if len(urls) == 0 {
+		return
+	}
+
+	select {
+	case app.linkQueue <- urls:
+	default:
+		//? The links get their preview the next time they are shared
+		app.requestLogger(ctx).Warnw("link preview queue is full", "post", post.ID)
+	}
+}
+
+// startUnfurlWorkers starts the workers fetching the previews of the queued
+// links. They stop once ctx is cancelled, wait on the returned WaitGroup for
+// the in-flight fetches to finish.
+func (app *application) startUnfurlWorkers(ctx context.Context) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < app.config.previews.workers; i++ {
+		wg.Add(1)
+
+		go func(id int) {
+			defer wg.Done()
+			app.runUnfurlWorker(ctx, id)
+		}(i)
+	}
+
+	app.logger.Infow("unfurl workers started", "workers", app.config.previews.workers)
+
+	return wg
+}
+
+func (app *application) runUnfurlWorker(ctx context.Context, id int) {
+	for {
+		select {
+		case <-ctx.Done():
+			app.logger.Infow("unfurl worker stopped", "worker", id)
+			return
+		case urls := <-app.linkQueue:
+			app.unfurlLinks(ctx, urls)
+		}
+	}
+}
+
+// unfurlLinks fetches and caches the previews of the links that have none or
+// an expired one.
+func (app *application) unfurlLinks(ctx context.Context, urls []string) {
+	stale, err := app.store.LinkPreviews.GetStale(ctx, urls, app.config.previews.ttl)
+	if err != nil {
+		app.logger.Errorw("error getting stale link previews", "error", err.Error())
+		return
+	}
+
+	for _, url := range stale {
+		preview := &models.LinkPreview{URL: url}
+
+		p, err := app.unfurler.Unfurl(ctx, url)
+		switch {
+		case ctx.Err() != nil:
+			// Shutting down, the link isn't broken
+			return
+		case err != nil:
+			//? Failures are cached too so a broken link isn't fetched for every post sharing it
+			app.logger.Infow("link preview unavailable", "url", url, "error", err.Error())
+			preview.Failed = true
+		default:
+			preview.Title = p.Title
+			preview.Description = p.Description
+			preview.ImageURL = p.ImageURL
+			preview.SiteName = p.SiteName
+		}
+
+		if err := app.store.LinkPreviews.Save(ctx, preview); err != nil {
+			app.logger.Errorw("error saving link preview", "url", url, "error", err.Error())
+		}
+	}
+}
+
+// attachLinkPreviews fills the link previews of the posts.
+func (app *application) attachLinkPreviews(ctx context.Context, posts []models.PostWithMetadata) error {
+	ids := make([]int64, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	previews, err := app.store.LinkPreviews.GetByPostIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].LinkPreviews = previewsOf(previews, posts[i].ID)
+	}
+
+	return nil
+}
+
+func previewsOf(previews map[int64][]models.LinkPreview, postID int64) []models.LinkPreview {
+	if p, ok := previews[postID]; ok {
+		return p
+	}
+
+	return []models.LinkPreview{}
+}
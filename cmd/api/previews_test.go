@@ -0,0 +1,90 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLinkPreviews(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/article" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><meta property="og:title" content="Gophers everywhere"><meta property="og:image" content="/gopher.png"></head></html>`))
+	}))
+	defer site.Close()
+
+	app := newTestApplication(t, config{
+		previews: previewConfig{maxLinks: 2, ttl: time.Hour},
+	})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// The third link is over the limit of the post
+	app.indexLinks(ctx, &models.Post{ID: 7}, []string{site.URL + "/article", site.URL + "/missing", site.URL + "/ignored"})
+
+	var urls []string
+	select {
+	case urls = <-app.linkQueue:
+	default:
+		t.Fatal("expected the links to be queued")
+	}
+
+	if len(urls) != 2 {
+		t.Fatalf("expected 2 links to unfurl, got %v", urls)
+	}
+
+	app.unfurlLinks(ctx, urls)
+
+	t.Run("should cache the previews and the failures", func(t *testing.T) {
+		stale, err := app.store.LinkPreviews.GetStale(ctx, urls, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stale) != 0 {
+			t.Errorf("expected every link to be cached, got %v stale", stale)
+		}
+	})
+
+	t.Run("should attach the previews to the post", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/posts/7", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var envelope struct {
+			Data models.Post `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+			t.Fatal(err)
+		}
+
+		previews := envelope.Data.LinkPreviews
+		if len(previews) != 1 {
+			t.Fatalf("expected the preview of the article only, got %+v", previews)
+		}
+
+		if previews[0].Title != "Gophers everywhere" || previews[0].ImageURL != site.URL+"/gopher.png" {
+			t.Errorf("unexpected preview %+v", previews[0])
+		}
+	})
+}
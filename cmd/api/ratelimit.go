@@ -0,0 +1,104 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/ratelimiter"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Rate limit policies, every policy counts the requests separately
+const (
+	policyDefault = "default"
+	// Login, registration and activation, guessing a password should be slow
+	policyAuth = "auth"
+	// Requests creating content, on top of the default policy
+	policyWrite = "write"
+)
+
+type rateLimitConfig struct {
+	// Quotas of the policies, the default quota of the limiter when not set
+	quotas map[string]ratelimiter.Quota
+	// roleMultipliers scale the quotas of the authenticated users by role
+	roleMultipliers map[string]float64
+}
+
+// rateLimit limits the requests of the routes with the quota of the policy.
+// Authenticated users are counted by ID, everyone else by IP, so it should come
+// after the authentication middleware.
+func (app *application) rateLimit(policy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !app.config.rateLimiter.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user := getUserFromCtx(r)
+
+			key := policy + ":ip:" + clientIP(r)
+			if user != nil {
+				key = policy + ":user:" + strconv.FormatInt(user.ID, 10)
+			}
+
+			res := app.rateLimiter.Allow(key, app.rateLimitQuota(policy, user))
+			setRateLimitHeaders(w, res)
+
+			if !res.Allowed {
+				app.metrics.RateLimited(policy)
+				app.rateLimitExceededResponse(w, r, res.RetryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (app *application) rateLimitQuota(policy string, user *models.User) ratelimiter.Quota {
+	quota, ok := app.config.rateLimitPolicies.quotas[policy]
+	if !ok || quota.Limit <= 0 {
+		quota = app.config.rateLimiter.Quota()
+	}
+
+	if user != nil {
+		if factor, ok := app.config.rateLimitPolicies.roleMultipliers[user.Role.Name]; ok {
+			quota = quota.Scale(factor)
+		}
+	}
+
+	return quota
+}
+
+// setRateLimitHeaders sets the RateLimit headers of the IETF draft. When
+// several policies apply, the one with the fewest remaining requests is shown.
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimiter.Result) {
+	h := w.Header()
+
+	if current, err := strconv.Atoi(h.Get("RateLimit-Remaining")); err == nil && current < res.Remaining {
+		return
+	}
+
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+}
+
+// clientIP is the address of the client without the port, realIPMiddleware
+// has already replaced it with the forwarded one when behind a trusted proxy.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// seconds rounds up, so a client waiting that long is never too early.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
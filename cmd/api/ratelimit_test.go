@@ -0,0 +1,157 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/ratelimiter"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitPolicies(t *testing.T) {
+	cfg := config{
+		rateLimiter: ratelimiter.Config{
+			RequestsPerTimeFrame: 3,
+			TimeFrame:            time.Minute,
+			Enabled:              true,
+		},
+		rateLimitPolicies: rateLimitConfig{
+			quotas: map[string]ratelimiter.Quota{
+				policyAuth: {Limit: 1, Window: time.Minute},
+			},
+		},
+		trustedProxies: []*net.IPNet{{IP: net.IPv4(192, 0, 2, 1), Mask: net.CIDRMask(32, 32)}},
+	}
+
+	app := newTestApplication(t, cfg)
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The requests come from the trusted proxy unless sent by a peer
+	sendFrom := func(t *testing.T, peer, method, url, ip, token string) *http.Response {
+		req, err := http.NewRequest(method, url, strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = peer + ":1234"
+		req.Header.Set("X-Forwarded-For", ip)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		return executeRequest(req, mux).Result()
+	}
+
+	send := func(t *testing.T, method, url, ip, token string) *http.Response {
+		return sendFrom(t, "192.0.2.1", method, url, ip, token)
+	}
+
+	t.Run("should send the rate limit headers", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "/v1/health", "10.0.0.1", "")
+		checkResponseCode(t, http.StatusOK, resp.StatusCode)
+
+		expected := map[string]string{
+			"RateLimit-Limit":     "3",
+			"RateLimit-Remaining": "2",
+			"RateLimit-Reset":     "60",
+		}
+		for header, value := range expected {
+			if got := resp.Header.Get(header); got != value {
+				t.Errorf("expected %s to be %q, got %q", header, value, got)
+			}
+		}
+	})
+
+	t.Run("should limit the authentication separately", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/v1/authentication/token", "10.0.0.2", "")
+		if resp.StatusCode == http.StatusTooManyRequests {
+			t.Fatal("expected the first attempt to be allowed")
+		}
+
+		resp = send(t, http.MethodPost, "/v1/authentication/token", "10.0.0.2", "")
+		checkResponseCode(t, http.StatusTooManyRequests, resp.StatusCode)
+
+		if got := resp.Header.Get("Retry-After"); got != "60" {
+			t.Errorf("expected to retry after 60 seconds, got %q", got)
+		}
+
+		resp = send(t, http.MethodGet, "/v1/health", "10.0.0.2", "")
+		checkResponseCode(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("should ignore the forwarded address of an untrusted peer", func(t *testing.T) {
+		resp := sendFrom(t, "198.51.100.1", http.MethodPost, "/v1/authentication/token", "10.0.0.7", "")
+		if resp.StatusCode == http.StatusTooManyRequests {
+			t.Fatal("expected the first attempt to be allowed")
+		}
+
+		resp = sendFrom(t, "198.51.100.1", http.MethodPost, "/v1/authentication/token", "10.0.0.8", "")
+		checkResponseCode(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	t.Run("should skip the trusted proxies of the forwarded chain", func(t *testing.T) {
+		resp := send(t, http.MethodPost, "/v1/authentication/token", "10.0.0.9, 203.0.113.1, 192.0.2.1", "")
+		if resp.StatusCode == http.StatusTooManyRequests {
+			t.Fatal("expected the first attempt to be allowed")
+		}
+
+		// Only the address seen by the proxy counts, not the ones the client added
+		resp = send(t, http.MethodPost, "/v1/authentication/token", "10.0.0.10, 203.0.113.1", "")
+		checkResponseCode(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	t.Run("should count authenticated users by ID", func(t *testing.T) {
+		for _, ip := range []string{"10.0.0.3", "10.0.0.4", "10.0.0.5"} {
+			resp := send(t, http.MethodGet, "/v1/tags/trending", ip, testToken)
+			checkResponseCode(t, http.StatusOK, resp.StatusCode)
+		}
+
+		resp := send(t, http.MethodGet, "/v1/tags/trending", "10.0.0.6", testToken)
+		checkResponseCode(t, http.StatusTooManyRequests, resp.StatusCode)
+
+		// The IP of the user still has its own quota
+		resp = send(t, http.MethodGet, "/v1/health", "10.0.0.6", "")
+		checkResponseCode(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestRateLimitQuota(t *testing.T) {
+	app := newTestApplication(t, config{
+		rateLimiter: ratelimiter.Config{RequestsPerTimeFrame: 10, TimeFrame: time.Minute},
+		rateLimitPolicies: rateLimitConfig{
+			quotas: map[string]ratelimiter.Quota{
+				policyWrite: {Limit: 4, Window: time.Hour},
+			},
+			roleMultipliers: map[string]float64{"admin": 2.5},
+		},
+	})
+
+	admin := &models.User{ID: 1, Role: models.Role{Name: "admin"}}
+	user := &models.User{ID: 2, Role: models.Role{Name: "user"}}
+
+	cases := []struct {
+		policy string
+		user   *models.User
+		limit  int
+		window time.Duration
+	}{
+		{policyDefault, nil, 10, time.Minute},
+		{policyDefault, user, 10, time.Minute},
+		{policyDefault, admin, 25, time.Minute},
+		{policyWrite, user, 4, time.Hour},
+		{policyWrite, admin, 10, time.Hour},
+	}
+
+	for _, c := range cases {
+		quota := app.rateLimitQuota(c.policy, c.user)
+		if quota.Limit != c.limit || quota.Window != c.window {
+			t.Errorf("%s: expected %d per %s, got %d per %s", c.policy, c.limit, c.window, quota.Limit, quota.Window)
+		}
+	}
+}
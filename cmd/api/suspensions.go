@@ -0,0 +1,177 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SuspendUserPayload struct {
+	Reason   string `json:"reason" validate:"required,max=500"`
+	Duration string `json:"duration" validate:"omitempty,max=20"` // e.g. 168h, omit for a permanent ban
+}
+
+// SuspendUser godoc
+//
+//	@Summary		Suspend a user
+//	@Description	Suspend a user for the given duration, or ban them permanently when no duration is provided. Requires the moderator role.
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int					true	"Target User ID"
+//	@Param			body	body		SuspendUserPayload	true	"Suspension details"
+//	@Success		201		{object}	models.Suspension	"Suspension created"
+//	@Failure		400		{object}	error				"Invalid request"
+//	@Failure		403		{object}	error				"Insufficient role"
+//	@Failure		404		{object}	error				"Target user not found"
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/suspension [put]
+func (app *application) suspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	moderator := getUserFromCtx(r)
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload SuspendUserPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	suspension := &models.Suspension{
+		UserID:      userID,
+		ModeratorID: moderator.ID,
+		Reason:      payload.Reason,
+	}
+
+	if payload.Duration != "" {
+		duration, err := time.ParseDuration(payload.Duration)
+		if err != nil || duration <= 0 {
+			app.badRequestResponse(w, r, errors.New("duration must be a positive duration such as 168h"))
+			return
+		}
+
+		expiresAt := time.Now().Add(duration)
+		suspension.ExpiresAt = &expiresAt
+	}
+
+	ctx := r.Context()
+
+	target, err := app.store.Users.GetByID(ctx, userID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if !canModerate(moderator, target) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Suspensions.Create(ctx, suspension); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// Drop the cached user so the suspension takes effect on the next request
+	app.invalidateUser(ctx, userID)
+
+	if err := app.jsonResponse(w, http.StatusCreated, suspension); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// LiftSuspension godoc
+//
+//	@Summary		Lift a suspension
+//	@Description	Lift the active suspension or ban of a user. Requires the moderator role.
+//	@Tags			moderation
+//	@Produce		json
+//	@Param			userID	path		int		true	"Target User ID"
+//	@Success		204		{string}	string	"Suspension lifted"
+//	@Failure		403		{object}	error	"Insufficient role"
+//	@Failure		404		{object}	error	"Target user not found or no active suspension"
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/suspension [delete]
+func (app *application) liftSuspensionHandler(w http.ResponseWriter, r *http.Request) {
+	moderator := getUserFromCtx(r)
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	target, err := app.store.Users.GetByID(ctx, userID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if !canModerate(moderator, target) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Suspensions.Lift(ctx, userID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidateUser(ctx, userID)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// canModerate tells whether the moderator may suspend the target or lift their
+// suspension, only users with a lower role than the moderator's can be.
+func canModerate(moderator *models.User, target *models.User) bool {
+	return target.ID != moderator.ID && target.Role.Level < moderator.Role.Level
+}
+
+// invalidateUser removes the user from the cache so changes to the account
+// are picked up right away.
+func (app *application) invalidateUser(ctx context.Context, userID int64) {
+	if !app.config.redisCfg.enabled {
+		return
+	}
+
+	if err := app.cacheStorage.Users.Delete(ctx, userID); err != nil {
+		app.requestLogger(ctx).Errorw("failed to invalidate the cached user", "user", userID, "error", err.Error())
+	}
+}
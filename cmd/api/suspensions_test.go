@@ -0,0 +1,116 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSuspensions(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moderator := models.User{ID: 1, Role: models.Role{Name: "moderator", Level: 2}}
+
+	users := app.store.Users.(*store.MockUserStore)
+	users.Users = map[int64]models.User{
+		1: moderator,
+		3: {ID: 3, Role: models.Role{Name: "moderator", Level: 2}},
+	}
+
+	send := func(t *testing.T, method string, path string, body io.Reader) int {
+		t.Helper()
+
+		req, err := http.NewRequest(method, path, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		return executeRequest(req, mux).Code
+	}
+
+	suspend := func(t *testing.T, userID string, payload string) int {
+		t.Helper()
+
+		return send(t, http.MethodPut, "/v1/user/"+userID+"/suspension", bytes.NewBufferString(payload))
+	}
+
+	t.Run("should suspend a user", func(t *testing.T) {
+		checkResponseCode(t, http.StatusCreated, suspend(t, "2", `{"reason": "spam", "duration": "168h"}`))
+
+		if !users.Users[2].Suspension.IsActive() {
+			t.Error("expected the user to be suspended")
+		}
+	})
+
+	t.Run("should reject invalid durations", func(t *testing.T) {
+		checkResponseCode(t, http.StatusBadRequest, suspend(t, "4", `{"reason": "spam", "duration": "-1h"}`))
+		checkResponseCode(t, http.StatusBadRequest, suspend(t, "4", `{"reason": "spam", "duration": "a week"}`))
+	})
+
+	t.Run("should not suspend users of the same role", func(t *testing.T) {
+		checkResponseCode(t, http.StatusForbidden, suspend(t, "3", `{"reason": "spam"}`))
+		checkResponseCode(t, http.StatusForbidden, suspend(t, "1", `{"reason": "spam"}`))
+	})
+
+	t.Run("should lift a suspension", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNoContent, send(t, http.MethodDelete, "/v1/user/2/suspension", nil))
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodDelete, "/v1/user/2/suspension", nil))
+
+		if users.Users[2].Suspension != nil {
+			t.Error("expected the suspension to be lifted")
+		}
+	})
+
+	t.Run("should not lift the suspensions of users of the same role", func(t *testing.T) {
+		suspended := users.Users[3]
+		suspended.Suspension = &models.Suspension{UserID: 3, Reason: "spam"}
+		users.Users[3] = suspended
+
+		checkResponseCode(t, http.StatusForbidden, send(t, http.MethodDelete, "/v1/user/3/suspension", nil))
+
+		if users.Users[3].Suspension == nil {
+			t.Error("expected the suspension to stay")
+		}
+	})
+
+	t.Run("should only let moderators suspend", func(t *testing.T) {
+		users.Users[1] = models.User{ID: 1, Role: models.Role{Name: "user", Level: 1}}
+		defer func() { users.Users[1] = moderator }()
+
+		checkResponseCode(t, http.StatusForbidden, suspend(t, "2", `{"reason": "spam"}`))
+	})
+
+	t.Run("should refuse the requests of a suspended user", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		suspended := moderator
+		suspended.Suspension = &models.Suspension{UserID: 1, Reason: "spam", ExpiresAt: &expiresAt}
+
+		users.Users[1] = suspended
+		defer func() { users.Users[1] = moderator }()
+
+		checkResponseCode(t, http.StatusForbidden, send(t, http.MethodGet, "/v1/user/2", nil))
+	})
+
+	t.Run("should lift a suspension once it expires", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Second)
+		expired := moderator
+		expired.Suspension = &models.Suspension{UserID: 1, Reason: "spam", ExpiresAt: &expiresAt}
+
+		users.Users[1] = expired
+		defer func() { users.Users[1] = moderator }()
+
+		checkResponseCode(t, http.StatusOK, send(t, http.MethodGet, "/v1/user/2", nil))
+	})
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"SocialMedia/internal/textparse"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TrendingQuery is the sliding window the trending hashtags are computed over.
+type TrendingQuery struct {
+	Window time.Duration `json:"window" validate:"gte=1h,lte=168h"`
+	Limit  int           `json:"limit" validate:"gte=1,lte=50"`
+}
+
+// GetTagPosts godoc
+//
+//	@Summary		Posts of a hashtag
+//	@Description	Lists the posts using the hashtag that the current user is allowed to see
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag		path		string	true	"Hashtag, with or without the leading #"
+//	@Param			limit	query		int		false	"Limit post per request"
+//	@Param			offset	query		int		false	"Offset by the previous post"
+//	@Param			sort	query		string	false	"Sort post by asc or desc"
+//	@Param			search	query		string	false	"Search by title or content"
+//	@Success		200		{object}	[]models.PostWithMetadata
+//	@Failure		400		{object}	error	"Invalid hashtag"
+//	@Security		ApiKeyAuth
+//	@Router			/tags/{tag}/posts [get]
+func (app *application) getTagPostsHandler(w http.ResponseWriter, r *http.Request) {
+	viewer := getUserFromCtx(r)
+
+	tag, ok := textparse.NormalizeHashtag(chi.URLParam(r, "tag"))
+	if !ok {
+		app.badRequestResponse(w, r, errors.New("invalid hashtag"))
+		return
+	}
+
+	fq := store.PaginatedFeedQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	fq, err := fq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	posts, err := app.store.Hashtags.GetPosts(ctx, tag, viewer.ID, fq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.attachLinkPreviews(ctx, *posts); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetTrendingTags godoc
+//
+//	@Summary		Trending hashtags
+//	@Description	Ranks the hashtags of public posts and their comments by the number of users who used them over the window
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			window	query		string	false	"Sliding window, from 1h to 168h, defaults to 24h"
+//	@Param			limit	query		int		false	"Number of hashtags, up to 50"
+//	@Success		200		{object}	[]models.TrendingTag
+//	@Failure		400		{object}	error	"Invalid window"
+//	@Security		ApiKeyAuth
+//	@Router			/tags/trending [get]
+func (app *application) getTrendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	tq := TrendingQuery{
+		Window: 24 * time.Hour,
+		Limit:  10,
+	}
+
+	qs := r.URL.Query()
+
+	if window := qs.Get("window"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		tq.Window = d
+	}
+
+	if limit := qs.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		tq.Limit = l
+	}
+
+	if err := Validate.Struct(tq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	tags, err := app.store.Hashtags.GetTrending(r.Context(), tq.Window, tq.Limit)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, tags); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// indexPost stores the mentions and hashtags of the post and returns the users
+// mentioned for the first time. Failures are logged, the post is saved already.
+func (app *application) indexPost(ctx context.Context, post *models.Post, e textparse.Entities) []models.User {
+	app.indexLinks(ctx, post, e.URLs)
+
+	if err := app.store.Hashtags.SetPostHashtags(ctx, post.ID, e.Hashtags); err != nil {
+		app.requestLogger(ctx).Errorw("error saving hashtags", "post", post.ID, "error", err.Error())
+	}
+
+	users, err := app.resolveMentions(ctx, e.Mentions)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting mentioned users", "post", post.ID, "error", err.Error())
+		return nil
+	}
+
+	added, err := app.store.Mentions.SetPostMentions(ctx, post.ID, userIDs(users))
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error saving mentions", "post", post.ID, "error", err.Error())
+		return nil
+	}
+
+	mentioned := []models.User{}
+	for _, u := range users {
+		for _, id := range added {
+			if u.ID == id {
+				mentioned = append(mentioned, u)
+				break
+			}
+		}
+	}
+
+	return mentioned
+}
+
+// indexComment stores the mentions and hashtags of the comment and returns the
+// mentioned users.
+func (app *application) indexComment(ctx context.Context, comment *models.Comment, e textparse.Entities) []models.User {
+	if err := app.store.Hashtags.AddCommentHashtags(ctx, comment.ID, e.Hashtags); err != nil {
+		app.requestLogger(ctx).Errorw("error saving hashtags", "comment", comment.ID, "error", err.Error())
+	}
+
+	users, err := app.resolveMentions(ctx, e.Mentions)
+	if err != nil {
+		app.requestLogger(ctx).Errorw("error getting mentioned users", "comment", comment.ID, "error", err.Error())
+		return nil
+	}
+
+	if err := app.store.Mentions.AddCommentMentions(ctx, comment.ID, userIDs(users)); err != nil {
+		app.requestLogger(ctx).Errorw("error saving mentions", "comment", comment.ID, "error", err.Error())
+		return nil
+	}
+
+	return users
+}
+
+// postTags merges the hashtags of the content into the tags given by the
+// client. Tags that came from the hashtags of the previous content are
+// dropped first, so removing a hashtag from the content removes the tag.
+func postTags(tags []string, previous []string, hashtags []string) []string {
+	removed := map[string]bool{}
+	for _, tag := range previous {
+		removed[tag] = true
+	}
+
+	seen := map[string]bool{}
+	merged := []string{}
+
+	for _, tag := range tags {
+		key := strings.ToLower(tag)
+		if removed[key] || seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		merged = append(merged, tag)
+	}
+
+	for _, tag := range hashtags {
+		if seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		merged = append(merged, tag)
+	}
+
+	return merged
+}
+
+func userIDs(users []models.User) []int64 {
+	ids := make([]int64, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+
+	return ids
+}
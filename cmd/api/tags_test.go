@@ -0,0 +1,83 @@
+package main
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTrendingTags(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashtagStore := app.store.Hashtags.(*store.MockHashtagStore)
+	hashtagStore.Trending = []models.TrendingTag{{Tag: "go", Users: 3, Uses: 5}}
+
+	get := func(t *testing.T, url string) int {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		return executeRequest(req, mux).Code
+	}
+
+	t.Run("should default to a day", func(t *testing.T) {
+		checkResponseCode(t, http.StatusOK, get(t, "/v1/tags/trending"))
+
+		if hashtagStore.Window != 24*time.Hour {
+			t.Errorf("expected a 24h window, got %s", hashtagStore.Window)
+		}
+	})
+
+	t.Run("should use the given window", func(t *testing.T) {
+		checkResponseCode(t, http.StatusOK, get(t, "/v1/tags/trending?window=6h"))
+
+		if hashtagStore.Window != 6*time.Hour {
+			t.Errorf("expected a 6h window, got %s", hashtagStore.Window)
+		}
+	})
+
+	t.Run("should reject invalid windows", func(t *testing.T) {
+		for _, window := range []string{"soon", "1m", "720h"} {
+			checkResponseCode(t, http.StatusBadRequest, get(t, "/v1/tags/trending?window="+window))
+		}
+	})
+
+	t.Run("should reject invalid hashtags", func(t *testing.T) {
+		checkResponseCode(t, http.StatusOK, get(t, "/v1/tags/GoLang/posts"))
+		checkResponseCode(t, http.StatusBadRequest, get(t, "/v1/tags/42/posts"))
+	})
+}
+
+func TestPostTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		previous []string
+		hashtags []string
+		expected []string
+	}{
+		{"hashtags are added to the tags", []string{"news"}, nil, []string{"go"}, []string{"news", "go"}},
+		{"tags are not duplicated", []string{"Go"}, nil, []string{"go"}, []string{"Go"}},
+		{"removed hashtags are dropped", []string{"news", "go"}, []string{"go"}, []string{"golang"}, []string{"news", "golang"}},
+		{"kept hashtags stay", []string{"go"}, []string{"go"}, []string{"go"}, []string{"go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postTags(tt.tags, tt.previous, tt.hashtags); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -2,9 +2,16 @@ package main
 
 import (
 	"SocialMedia/internal/auth"
+	"SocialMedia/internal/blob"
+	"SocialMedia/internal/contentfilter"
+	"SocialMedia/internal/health"
+	"SocialMedia/internal/mailer"
+	"SocialMedia/internal/metrics"
+	"SocialMedia/internal/pubsub"
 	"SocialMedia/internal/ratelimiter"
 	"SocialMedia/internal/store"
 	"SocialMedia/internal/store/cache"
+	"SocialMedia/internal/unfurl"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -24,10 +31,20 @@ func newTestApplication(t *testing.T, cfg config) *application {
 	testAuth := &auth.TestAuthenticator{}
 
 	// Rate limiter
-	rateLimiter := ratelimiter.NewFixedWindowLimiter(
-		cfg.rateLimiter.RequestsPerTimeFrame,
-		cfg.rateLimiter.TimeFrame,
-	)
+	rateLimiter, err := ratelimiter.New(cfg.rateLimiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mailRenderer, err := mailer.NewTemplateRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := blob.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	return &application{
 		logger:        logger,
@@ -36,6 +53,15 @@ func newTestApplication(t *testing.T, cfg config) *application {
 		authenticator: testAuth,
 		config:        cfg,
 		rateLimiter:   rateLimiter,
+		contentFilter: contentfilter.New(),
+		mailer:        mailer.NewInMemoryMailer("test@gosocial.local", mailRenderer),
+		signer:        auth.NewSigner("test"),
+		pubsub:        pubsub.NewInProcess(),
+		blobs:         blobs,
+		unfurler:      unfurl.New(unfurl.Options{AllowPrivateNetworks: true}),
+		linkQueue:     make(chan []string, 10),
+		health:        health.New(),
+		metrics:       metrics.New(),
 	}
 }
 
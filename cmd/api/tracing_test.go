@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	app := newTestApplication(t, config{})
+	core, logs := observer.New(zapcore.DebugLevel)
+	app.logger = zap.New(core).Sugar()
+	mux := app.mount()
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	send := func(t *testing.T, path string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+
+		executeRequest(req, mux)
+	}
+
+	t.Run("should continue the trace of the caller in a span named after the route", func(t *testing.T) {
+		exporter.Reset()
+
+		send(t, "/v1/health")
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("expected 1 span, got %d", len(spans))
+		}
+
+		span := spans[0]
+		if span.Name != "GET /v1/health" {
+			t.Errorf("expected the span to be named after the route, got %q", span.Name)
+		}
+		if span.SpanContext.TraceID().String() != traceID || span.Parent.SpanID().String() != parentSpanID {
+			t.Errorf("expected the span to continue the trace of the caller, got %s", span.SpanContext.TraceID())
+		}
+	})
+
+	t.Run("should log the trace ID", func(t *testing.T) {
+		send(t, "/v1/user/1")
+
+		entries := logs.FilterMessage("unauthorized error").All()
+		if len(entries) != 1 {
+			t.Fatalf("expected the unauthorized request to be logged, got %d entries", len(entries))
+		}
+
+		if got := entries[0].ContextMap()["trace_id"]; got != traceID {
+			t.Errorf("expected the trace ID in the log line, got %v", got)
+		}
+	})
+
+	t.Run("should not record the tokens of the paths", func(t *testing.T) {
+		exporter.Reset()
+
+		send(t, "/v1/user/activate/secret-activation-token")
+
+		for _, span := range exporter.GetSpans() {
+			for _, attr := range span.Attributes {
+				if strings.Contains(attr.Value.Emit(), "secret-activation-token") {
+					t.Errorf("expected the token to stay out of %q of span %q", attr.Key, span.Name)
+				}
+			}
+		}
+	})
+}
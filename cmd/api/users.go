@@ -3,6 +3,8 @@ package main
 import (
 	"SocialMedia/internal/models"
 	"SocialMedia/internal/store"
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -27,7 +29,7 @@ const userCtx userKey = "users"
 func (app *application) getUserProfile(w http.ResponseWriter, r *http.Request) {
 	user := getUserFromCtx(r)
 
-	if err := app.jsonResponse(w, http.StatusOK, user); err != nil {
+	if err := app.jsonResponse(w, http.StatusOK, withSuspension(user)); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -67,24 +69,55 @@ func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := app.jsonResponse(w, http.StatusOK, user); err != nil {
+	//* Suspensions are only shown to the user and to moderators
+	var response any = user
+
+	viewer := getUserFromCtx(r)
+	if viewer.ID == user.ID {
+		response = withSuspension(user)
+	} else if user.Suspension != nil {
+		isModerator, err := app.checkRolePrecedence(ctx, viewer, "moderator")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if isModerator {
+			response = withSuspension(user)
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, response); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 }
 
+// userWithSuspension is a user along with its active suspension, which is
+// left out of the JSON of models.User.
+type userWithSuspension struct {
+	*models.User
+	Suspension *models.Suspension `json:"suspension,omitempty"`
+}
+
+func withSuspension(user *models.User) userWithSuspension {
+	return userWithSuspension{User: user, Suspension: user.Suspension}
+}
+
 // FollowUser godoc
 //
 //	@Summary		Follow a user
-//	@Description	Follow a user by providing the target user's ID in the path and the current user's ID in the request body.
+//	@Description	Follow a user by providing the target user's ID in the path. Following a private account creates a pending follow request.
 //	@Tags			user
 //	@Accept			json
 //	@Produce		json
-//	@Param			userID	path		int		true	"Target User ID"
-//	@Success		204		{string}	string	"User followed successfully"
-//	@Failure		409		{object}	error	"Already following the user"
-//	@Failure		404		{object}	error	"Target user not found"
-//	@Failure		400		{object}	error	"Invalid request"
+//	@Param			userID	path		int					true	"Target User ID"
+//	@Success		204		{string}	string				"User followed successfully"
+//	@Success		202		{object}	map[string]string	"Follow request pending approval"
+//	@Failure		409		{object}	error				"Already following the user"
+//	@Failure		403		{object}	error				"Blocked by or blocking the target user"
+//	@Failure		404		{object}	error				"Target user not found"
+//	@Failure		400		{object}	error				"Invalid request"
 //	@Security		ApiKeyAuth
 //	@Router			/user/{userID}/follow [put]
 func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -98,16 +131,39 @@ func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request
 
 	ctx := r.Context()
 
-	if err := app.store.Followers.Follow(ctx, user.ID, followedID); err != nil {
+	if followedID == user.ID {
+		app.badRequestResponse(w, r, errors.New("cannot follow yourself"))
+		return
+	}
+
+	pending, err := app.store.Followers.Follow(ctx, user.ID, followedID)
+	if err != nil {
 		switch err {
 		case store.ErrConflict:
 			app.conflictResponse(w, r, err)
+		case store.ErrBlocked:
+			app.forbiddenResponse(w, r)
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
 		default:
 			app.internalServerError(w, r, err)
 		}
 		return
 	}
 
+	app.notifyFollow(ctx, user, followedID, pending)
+
+	//? Private accounts have to approve the follow request first
+	if pending {
+		if err := app.jsonResponse(w, http.StatusAccepted, map[string]string{"status": "pending"}); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidateFeed(ctx, user.ID)
+	app.publishFollow(ctx, user.ID, followedID, true)
+
 	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -117,7 +173,7 @@ func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request
 // UnfollowUser godoc
 //
 //	@Summary		Unfollow a user
-//	@Description	Unfollow a user by providing the target user's ID in the path and the current user's ID in the request body.
+//	@Description	Unfollow a user, or cancel a pending follow request, by providing the target user's ID in the path.
 //	@Tags			user
 //	@Accept			json
 //	@Produce		json
@@ -146,6 +202,200 @@ func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	app.invalidateFeed(r.Context(), user.ID)
+	app.publishFollow(r.Context(), user.ID, unfollowedID, false)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// BlockUser godoc
+//
+//	@Summary		Block a user
+//	@Description	Block a user by ID. Removes the follow relationship in both directions and hides each other's content.
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"Target User ID"
+//	@Success		204		{string}	string	"User blocked successfully"
+//	@Failure		404		{object}	error	"Target user not found"
+//	@Failure		409		{object}	error	"User already blocked"
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/block [put]
+func (app *application) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	blockedID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if blockedID == user.ID {
+		app.badRequestResponse(w, r, errors.New("cannot block yourself"))
+		return
+	}
+
+	if err := app.store.Blocks.Block(r.Context(), user.ID, blockedID); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	//? Blocking removed the follows in both directions
+	app.invalidateFeed(r.Context(), user.ID, blockedID)
+	app.publishFollow(r.Context(), user.ID, blockedID, false)
+	app.publishFollow(r.Context(), blockedID, user.ID, false)
+	app.publishBlock(r.Context(), user.ID, blockedID, true)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// UnblockUser godoc
+//
+//	@Summary		Unblock a user
+//	@Description	Unblock a previously blocked user by ID.
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"Target User ID"
+//	@Success		204		{string}	string	"User unblocked successfully"
+//	@Failure		404		{object}	error	"User is not blocked"
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/block [delete]
+func (app *application) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	blockedID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Blocks.Unblock(r.Context(), user.ID, blockedID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidateFeed(r.Context(), user.ID, blockedID)
+
+	//? The other user may have blocked the user as well, the streams keep
+	//? hiding them then, or when it can't be told
+	blocked, err := app.store.Blocks.IsBlocked(r.Context(), user.ID, blockedID)
+	if err != nil {
+		app.requestLogger(r.Context()).Errorw("error checking blocks", "user", user.ID, "error", err.Error())
+		blocked = true
+	}
+	app.publishBlock(r.Context(), user.ID, blockedID, blocked)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// MuteUser godoc
+//
+//	@Summary		Mute a user
+//	@Description	Mute a user by ID. The muted user's posts are hidden from the current user's feed.
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"Target User ID"
+//	@Success		204		{string}	string	"User muted successfully"
+//	@Failure		404		{object}	error	"Target user not found"
+//	@Failure		409		{object}	error	"User already muted"
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/mute [put]
+func (app *application) muteUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	mutedID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if mutedID == user.ID {
+		app.badRequestResponse(w, r, errors.New("cannot mute yourself"))
+		return
+	}
+
+	if err := app.store.Mutes.Mute(r.Context(), user.ID, mutedID); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidateFeed(r.Context(), user.ID)
+	app.publishMute(r.Context(), user.ID, mutedID, true)
+
+	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// UnmuteUser godoc
+//
+//	@Summary		Unmute a user
+//	@Description	Unmute a previously muted user by ID.
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"Target User ID"
+//	@Success		204		{string}	string	"User unmuted successfully"
+//	@Failure		404		{object}	error	"User is not muted"
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/mute [delete]
+func (app *application) unmuteUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+	mutedID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Mutes.Unmute(r.Context(), user.ID, mutedID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidateFeed(r.Context(), user.ID)
+	app.publishMute(r.Context(), user.ID, mutedID, false)
+
 	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -182,6 +432,178 @@ func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+type UpdateUserPayload struct {
+	IsPrivate *bool   `json:"is_private"`
+	Language  *string `json:"language" validate:"omitempty,bcp47_language_tag"`
+}
+
+// UpdateUser godoc
+//
+//	@Summary		Update account settings
+//	@Description	Update the settings of the current user. Making a private account public approves its pending follow requests.
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		UpdateUserPayload	true	"Settings to update"
+//	@Success		200		{object}	models.User
+//	@Failure		400		{object}	error	"Invalid request"
+//	@Security		ApiKeyAuth
+//	@Router			/user [patch]
+func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromCtx(r)
+
+	var payload UpdateUserPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.IsPrivate != nil {
+		user.IsPrivate = *payload.IsPrivate
+	}
+
+	if payload.Language != nil {
+		user.Language = *payload.Language
+	}
+
+	ctx := r.Context()
+
+	approved, err := app.store.Users.UpdateSettings(ctx, user)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidateUser(ctx, user.ID)
+
+	//? Going public approved the pending follow requests
+	for _, requesterID := range approved {
+		app.followApproved(ctx, user, requesterID)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, user); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetUserPosts godoc
+//
+//	@Summary		Posts of a user
+//	@Description	Lists the posts of a user. Posts of private accounts are only visible to approved followers.
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path		int		true	"Target User ID"
+//	@Param			limit	query		int		false	"Limit post per request"
+//	@Param			offset	query		int		false	"Offset by the previous post"
+//	@Param			sort	query		string	false	"Sort post by asc or desc"
+//	@Param			search	query		string	false	"Search by title or content"
+//	@Success		200		{object}	[]models.PostWithMetadata
+//	@Failure		403		{object}	error	"Private account"
+//	@Failure		404		{object}	error	"User not found"
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/posts [get]
+func (app *application) getUserPostsHandler(w http.ResponseWriter, r *http.Request) {
+	viewer := getUserFromCtx(r)
+	authorID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	fq := store.PaginatedFeedQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	fq, err = fq.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	author, err := app.getUser(ctx, authorID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	allowed, err := app.canViewUserContent(ctx, viewer, author)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	posts, err := app.store.Posts.GetByUserID(ctx, author.ID, viewer.ID, fq)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.attachLinkPreviews(ctx, *posts); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, posts); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// canViewUserContent reports whether the viewer may see the content of the
+// author, taking blocks and private accounts into account.
+func (app *application) canViewUserContent(ctx context.Context, viewer *models.User, author *models.User) (bool, error) {
+	if viewer.ID == author.ID {
+		return true, nil
+	}
+
+	blocked, err := app.store.Blocks.IsBlocked(ctx, viewer.ID, author.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if blocked {
+		return false, nil
+	}
+
+	if !author.IsPrivate {
+		return true, nil
+	}
+
+	return app.store.Followers.IsFollowing(ctx, viewer.ID, author.ID)
+}
+
 func getUserFromCtx(r *http.Request) *models.User {
 	user, _ := r.Context().Value(userCtx).(*models.User)
 
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
 	"SocialMedia/internal/store/cache"
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"testing"
 
@@ -78,6 +83,27 @@ func TestGetUser(t *testing.T) {
 		mockCacheStore.Calls = nil // Reset mock expectations
 	})
 
+	t.Run("should read the user from the database if the cache fails", func(t *testing.T) {
+		app := newTestApplication(t, withRedis)
+		mux := app.mount()
+
+		mockCacheStore := app.cacheStorage.Users.(*cache.MockUserStore)
+
+		mockCacheStore.On("Get", int64(1)).Return(nil, errors.New("connection refused"))
+		mockCacheStore.On("Set", mock.Anything).Return(errors.New("connection refused"))
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/user/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+
+		checkResponseCode(t, http.StatusOK, rr.Code)
+	})
+
 	t.Run("should NOT hit the cache if it is not enabled", func(t *testing.T) {
 		withRedis := config{
 			redisCfg: redisConfig{
@@ -106,3 +132,119 @@ func TestGetUser(t *testing.T) {
 		mockCacheStore.Calls = nil // Reset mock expectations
 	})
 }
+
+func TestGetUserSuspension(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := app.store.Users.(*store.MockUserStore)
+	users.Users = map[int64]models.User{
+		2: {ID: 2, Suspension: &models.Suspension{UserID: 2, Reason: "spam"}},
+	}
+
+	getUser := func(t *testing.T) map[string]any {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/user/2", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		rr := executeRequest(req, mux)
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		var body struct {
+			Data map[string]any `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		return body.Data
+	}
+
+	t.Run("should not show the suspension to other users", func(t *testing.T) {
+		if _, ok := getUser(t)["suspension"]; ok {
+			t.Error("expected the suspension to be hidden")
+		}
+	})
+
+	t.Run("should show the suspension to moderators", func(t *testing.T) {
+		users.Users[1] = models.User{ID: 1, Role: models.Role{Name: "moderator", Level: 2}}
+
+		if _, ok := getUser(t)["suspension"]; !ok {
+			t.Error("expected the suspension to be shown")
+		}
+	})
+}
+
+func TestBlocksAndMutes(t *testing.T) {
+	app := newTestApplication(t, config{})
+	mux := app.mount()
+
+	testToken, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.store.Users.(*store.MockUserStore).Missing = map[int64]bool{404: true}
+
+	send := func(t *testing.T, method string, path string) int {
+		t.Helper()
+
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		return executeRequest(req, mux).Code
+	}
+
+	t.Run("should block a user and remove the follows in both directions", func(t *testing.T) {
+		followers := app.store.Followers.(*store.MockFollowerStore)
+		followers.Follow(context.Background(), 1, 2)
+		followers.Follow(context.Background(), 2, 1)
+
+		checkResponseCode(t, http.StatusNoContent, send(t, http.MethodPut, "/v1/user/2/block"))
+
+		for _, pair := range [][2]int64{{1, 2}, {2, 1}} {
+			if following, _ := followers.IsFollowing(context.Background(), pair[0], pair[1]); following {
+				t.Errorf("expected %d to no longer follow %d", pair[0], pair[1])
+			}
+		}
+
+		checkResponseCode(t, http.StatusConflict, send(t, http.MethodPut, "/v1/user/2/block"))
+	})
+
+	t.Run("should unblock a blocked user", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNoContent, send(t, http.MethodDelete, "/v1/user/2/block"))
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodDelete, "/v1/user/2/block"))
+	})
+
+	t.Run("should mute and unmute a user", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNoContent, send(t, http.MethodPut, "/v1/user/2/mute"))
+		checkResponseCode(t, http.StatusConflict, send(t, http.MethodPut, "/v1/user/2/mute"))
+
+		checkResponseCode(t, http.StatusNoContent, send(t, http.MethodDelete, "/v1/user/2/mute"))
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodDelete, "/v1/user/2/mute"))
+	})
+
+	t.Run("should not block or mute yourself", func(t *testing.T) {
+		checkResponseCode(t, http.StatusBadRequest, send(t, http.MethodPut, "/v1/user/1/block"))
+		checkResponseCode(t, http.StatusBadRequest, send(t, http.MethodPut, "/v1/user/1/mute"))
+	})
+
+	t.Run("should not block or mute users that don't exist", func(t *testing.T) {
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodPut, "/v1/user/404/block"))
+		checkResponseCode(t, http.StatusNotFound, send(t, http.MethodPut, "/v1/user/404/mute"))
+	})
+}
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS comments
+DROP COLUMN status;
+
+ALTER TABLE IF EXISTS posts
+DROP COLUMN status;
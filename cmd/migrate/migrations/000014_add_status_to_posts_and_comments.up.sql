@@ -0,0 +1,5 @@
+ALTER TABLE posts
+ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'published';
+
+ALTER TABLE comments
+ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'published';
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_suspensions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    moderator_id BIGINT,
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMP(0) WITH TIME ZONE, -- NULL means a permanent ban
+    lifted_at TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (moderator_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_suspensions_user_id on user_suspensions (user_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS follow_requests;
+
+ALTER TABLE IF EXISTS users
+DROP COLUMN is_private;
@@ -0,0 +1,14 @@
+ALTER TABLE users
+ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+    user_id BIGINT NOT NULL,
+    target_id BIGINT NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, target_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (target_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_follow_requests_target_id on follow_requests (target_id);
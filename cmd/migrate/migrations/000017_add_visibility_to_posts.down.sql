@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS posts
+DROP COLUMN visibility;
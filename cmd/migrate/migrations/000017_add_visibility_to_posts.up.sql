@@ -0,0 +1,5 @@
+ALTER TABLE posts
+ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'public';
+
+ALTER TABLE posts
+ADD CONSTRAINT posts_visibility_check CHECK (visibility IN ('public', 'followers', 'unlisted'));
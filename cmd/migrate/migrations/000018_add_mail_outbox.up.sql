@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS mail_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    template VARCHAR(255) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    email citext NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sending, sent or dead
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP(0) WITH TIME ZONE,
+    sent_at TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mail_outbox_status_next_attempt on mail_outbox (status, next_attempt_at);
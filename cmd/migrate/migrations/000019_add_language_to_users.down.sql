@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS mail_outbox
+DROP COLUMN locale;
+
+ALTER TABLE IF EXISTS users
+DROP COLUMN language;
@@ -0,0 +1,5 @@
+ALTER TABLE users
+ADD COLUMN language VARCHAR(35) NOT NULL DEFAULT 'en';
+
+ALTER TABLE mail_outbox
+ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT 'en';
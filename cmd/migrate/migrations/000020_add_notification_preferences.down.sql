@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_followers_follower_id_created_at;
+DROP INDEX IF EXISTS idx_comments_post_id_created_at;
+
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id BIGINT PRIMARY KEY,
+    new_follower VARCHAR(10) NOT NULL DEFAULT 'instant',
+    comment VARCHAR(10) NOT NULL DEFAULT 'instant',
+    mention VARCHAR(10) NOT NULL DEFAULT 'instant',
+    last_digest_at TIMESTAMP(0) WITH TIME ZONE,
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT notification_preferences_new_follower_check CHECK (new_follower IN ('instant', 'daily', 'off')),
+    CONSTRAINT notification_preferences_comment_check CHECK (comment IN ('instant', 'daily', 'off')),
+    CONSTRAINT notification_preferences_mention_check CHECK (mention IN ('instant', 'daily', 'off'))
+);
+
+-- Comments are looked up by post author and date for the digest emails
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_created_at on comments (post_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_followers_follower_id_created_at on followers (follower_id, created_at);
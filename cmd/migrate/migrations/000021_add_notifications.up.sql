@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    -- Events with the same group key are merged while the notification is unread
+    group_key VARCHAR(255) NOT NULL,
+    actor_ids BIGINT[] NOT NULL DEFAULT '{}',
+    actor_id BIGINT,
+    post_id BIGINT,
+    read_at TIMESTAMP(0) WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users (id) ON DELETE SET NULL,
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_unread_group on notifications (user_id, group_key) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_updated_at on notifications (user_id, updated_at DESC, id DESC);
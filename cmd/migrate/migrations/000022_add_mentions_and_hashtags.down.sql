@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS comment_hashtags;
+DROP TABLE IF EXISTS post_hashtags;
+DROP TABLE IF EXISTS comment_mentions;
+DROP TABLE IF EXISTS post_mentions;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS post_mentions (
+    post_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (post_id, user_id),
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (comment_id, user_id),
+    FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS post_hashtags (
+    post_id BIGINT NOT NULL,
+    tag VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (post_id, tag),
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS comment_hashtags (
+    comment_id BIGINT NOT NULL,
+    tag VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (comment_id, tag),
+    FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_mentions_user_id_created_at on post_mentions (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_comment_mentions_user_id_created_at on comment_mentions (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_post_hashtags_tag_created_at on post_hashtags (tag, created_at);
+CREATE INDEX IF NOT EXISTS idx_post_hashtags_created_at on post_hashtags (created_at);
+CREATE INDEX IF NOT EXISTS idx_comment_hashtags_created_at on comment_hashtags (created_at);
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS conversations (
+    id BIGSERIAL PRIMARY KEY,
+    creator_id BIGINT,
+    title VARCHAR(100) NOT NULL DEFAULT '',
+    is_group BOOLEAN NOT NULL DEFAULT FALSE,
+    -- "<lowest user ID>:<highest user ID>" of one-to-one conversations, so there is a single one per pair
+    direct_key VARCHAR(50) UNIQUE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    -- Time of the last message, conversations are paginated by it
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (creator_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS conversation_participants (
+    conversation_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    last_read_message_id BIGINT,
+    last_read_at TIMESTAMP(0) WITH TIME ZONE,
+    joined_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (conversation_id, user_id),
+    FOREIGN KEY (conversation_id) REFERENCES conversations (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id BIGSERIAL PRIMARY KEY,
+    conversation_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    content TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'published',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (conversation_id) REFERENCES conversations (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_participants_user_id on conversation_participants (user_id);
+CREATE INDEX IF NOT EXISTS idx_conversations_updated_at on conversations (updated_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_id_created_at on messages (conversation_id, created_at DESC, id DESC);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS post_attachments (
+    id BIGSERIAL PRIMARY KEY,
+    post_id BIGINT NOT NULL,
+    key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    url TEXT NOT NULL,
+    thumbnail_url TEXT NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    alt_text VARCHAR(1000) NOT NULL DEFAULT '',
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_attachments_post_id on post_attachments (post_id, id);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS link_previews (
+    url TEXT PRIMARY KEY,
+    title VARCHAR(300) NOT NULL DEFAULT '',
+    description VARCHAR(1000) NOT NULL DEFAULT '',
+    image_url TEXT NOT NULL DEFAULT '',
+    site_name VARCHAR(100) NOT NULL DEFAULT '',
+    failed BOOLEAN NOT NULL DEFAULT FALSE,
+    fetched_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS post_links (
+    post_id BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    position INT NOT NULL,
+
+    PRIMARY KEY (post_id, url),
+    FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_post_attachments_thumbnail_key;
+DROP INDEX IF EXISTS idx_post_attachments_key;
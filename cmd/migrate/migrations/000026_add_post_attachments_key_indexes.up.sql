@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_attachments_key on post_attachments (key);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_attachments_thumbnail_key on post_attachments (thumbnail_key);
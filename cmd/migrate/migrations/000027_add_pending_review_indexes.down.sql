@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_messages_pending_review;
+DROP INDEX IF EXISTS idx_comments_pending_review;
+DROP INDEX IF EXISTS idx_posts_pending_review;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_posts_pending_review on posts (created_at, id) WHERE status = 'pending_review';
+CREATE INDEX IF NOT EXISTS idx_comments_pending_review on comments (created_at, id) WHERE status = 'pending_review';
+CREATE INDEX IF NOT EXISTS idx_messages_pending_review on messages (created_at, id) WHERE status = 'pending_review';
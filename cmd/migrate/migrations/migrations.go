@@ -0,0 +1,39 @@
+// Package migrations embeds the SQL migrations applied with the migrate CLI, so
+// the API knows which version of the schema it expects.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Latest returns the version of the newest migration.
+func Latest() (uint, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		// 000025_add_link_previews.up.sql
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			return 0, fmt.Errorf("unexpected migration %q", entry.Name())
+		}
+
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected migration %q: %w", entry.Name(), err)
+		}
+
+		latest = max(latest, uint(version))
+	}
+
+	return latest, nil
+}
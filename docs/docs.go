@@ -63,6 +63,10 @@ const docTemplate = `{
                         "description": "User not found",
                         "schema": {}
                     },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -116,14 +120,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/conversations": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get configuration information of the service",
+                "description": "List the conversations of the current user with their last message and unread count, the most recently active first. Pass next_cursor as cursor to get the next page. A conversation that gets a message while paging moves to the top, past the cursor, so the next pages skip it: reload the first page to catch up.",
                 "consumes": [
                     "application/json"
                 ],
@@ -131,30 +135,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "ops"
+                    "conversations"
+                ],
+                "summary": "List conversations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conversations per page, up to 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get health",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.ConversationsPage"
                         }
+                    },
+                    "400": {
+                        "description": "Invalid cursor",
+                        "schema": {}
                     }
                 }
-            }
-        },
-        "/posts": {
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a post by providing necessary information.",
+                "description": "Start a conversation with one user, or a group with up to 9 others. Two users only have one conversation, it is returned when it already exists.",
                 "consumes": [
                     "application/json"
                 ],
@@ -162,42 +179,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "conversations"
                 ],
-                "summary": "Create a post",
+                "summary": "Start a conversation",
                 "parameters": [
                     {
-                        "description": "Request body with post details",
+                        "description": "Participants of the conversation",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.CreatePostPayload"
+                            "$ref": "#/definitions/main.CreateConversationPayload"
                         }
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Existing conversation",
+                        "schema": {
+                            "$ref": "#/definitions/models.Conversation"
+                        }
+                    },
                     "201": {
-                        "description": "Created post information",
+                        "description": "Created conversation",
                         "schema": {
-                            "$ref": "#/definitions/models.Post"
+                            "$ref": "#/definitions/models.Conversation"
                         }
                     },
                     "400": {
                         "description": "Invalid request",
                         "schema": {}
+                    },
+                    "403": {
+                        "description": "A participant is blocked",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Participant not found",
+                        "schema": {}
                     }
                 }
             }
         },
-        "/posts/{id}": {
+        "/conversations/{conversationID}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get post details and respective comments by id.",
+                "description": "Get a conversation of the current user, the participants carry the read receipts",
                 "consumes": [
                     "application/json"
                 ],
@@ -205,14 +236,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "conversations"
                 ],
-                "summary": "Get a post by id",
+                "summary": "Get a conversation",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Post ID",
-                        "name": "id",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
                         "in": "path",
                         "required": true
                     }
@@ -221,22 +252,24 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Post"
+                            "$ref": "#/definitions/models.Conversation"
                         }
                     },
                     "404": {
-                        "description": "Post not found",
+                        "description": "Conversation not found",
                         "schema": {}
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/conversations/{conversationID}/messages": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a post by id.",
+                "description": "List the messages of a conversation, the most recent first. Pass next_cursor as cursor to get older messages.",
                 "consumes": [
                     "application/json"
                 ],
@@ -244,38 +277,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "conversations"
                 ],
-                "summary": "Delete a post",
+                "summary": "List messages",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Post ID",
-                        "name": "id",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Messages per page, up to 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Post deleted successfully",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/main.MessagesPage"
                         }
                     },
+                    "400": {
+                        "description": "Invalid cursor",
+                        "schema": {}
+                    },
                     "404": {
-                        "description": "Post not found",
+                        "description": "Conversation not found",
                         "schema": {}
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update post details by id.",
+                "description": "Send a message to a conversation. Messages go through the same content filter as posts.",
                 "consumes": [
                     "application/json"
                 ],
@@ -283,57 +332,67 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "posts"
+                    "conversations"
                 ],
-                "summary": "Update a post",
+                "summary": "Send a message",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Post ID",
-                        "name": "id",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Request body with post details",
+                        "description": "Message",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.UpdatePostPayload"
+                            "$ref": "#/definitions/main.SendMessagePayload"
                         }
                     }
                 ],
                 "responses": {
+                    "201": {
+                        "description": "Sent message",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
                     "202": {
-                        "description": "Updated post information",
+                        "description": "Message held for review",
                         "schema": {
-                            "$ref": "#/definitions/models.Post"
+                            "$ref": "#/definitions/models.Message"
                         }
                     },
                     "400": {
                         "description": "Invalid request",
                         "schema": {}
                     },
+                    "403": {
+                        "description": "The other user is blocked",
+                        "schema": {}
+                    },
                     "404": {
-                        "description": "Post not found",
+                        "description": "Conversation not found",
                         "schema": {}
                     },
-                    "409": {
-                        "description": "Conflict occurred while updating",
+                    "422": {
+                        "description": "Rejected by the content filter",
                         "schema": {}
                     }
                 }
             }
         },
-        "/user": {
-            "get": {
+        "/conversations/{conversationID}/read": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Fetches profile of the current user",
+                "description": "Move the read receipt of the current user to the last message of the conversation",
                 "consumes": [
                     "application/json"
                 ],
@@ -341,68 +400,91 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "conversations"
+                ],
+                "summary": "Mark a conversation as read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conversation ID",
+                        "name": "conversationID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "User profile",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "Conversation marked as read",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Invalid request",
+                        "description": "Conversation not found",
                         "schema": {}
                     }
                 }
             }
         },
-        "/user/activate/{token}": {
-            "put": {
-                "description": "Activates/Register a user by invitation token",
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events stream of the new posts of followed users, the notifications of the current user and the comments of the given posts. Browsers can't set headers on an EventSource, so the token may be passed as access_token instead.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "authentication"
+                    "events"
                 ],
-                "summary": "Activates/Register a user",
+                "summary": "Stream real-time events",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Invitation token",
-                        "name": "token",
-                        "in": "path",
-                        "required": true
+                        "description": "Comma separated IDs of the posts to receive the comments of",
+                        "name": "posts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Token, when the Authorization header can't be set",
+                        "name": "access_token",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "User activated",
+                    "200": {
+                        "description": "Event stream",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "No users found associated to the token provided",
+                    "400": {
+                        "description": "Invalid request",
                         "schema": {}
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "429": {
+                        "description": "Too many open event streams",
                         "schema": {}
                     }
                 }
             }
         },
-        "/user/feed": {
+        "/health": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get user feed respective to the pagination, filters and sort",
+                "description": "Get configuration information of the service and the state of the cache",
                 "consumes": [
                     "application/json"
                 ],
@@ -410,111 +492,124 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "feed"
+                    "ops"
                 ],
-                "summary": "Get user feed",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Limit post per request",
-                        "name": "limit",
-                        "in": "path"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Offset by the previous post",
-                        "name": "offset",
-                        "in": "path"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Sort post by asc or desc",
-                        "name": "sort",
-                        "in": "path"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Search by title or content",
-                        "name": "search",
-                        "in": "path"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by relative tags",
-                        "name": "tags",
-                        "in": "path"
+                "summary": "Get health",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
+                }
+            }
+        },
+        "/health/live": {
+            "get": {
+                "description": "Tells whether the process is up, the dependencies aren't checked so a broken one doesn't get the service restarted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ops"
                 ],
+                "summary": "Get liveness",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.PostWithMetadata"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
-                    },
-                    "404": {
-                        "description": "Post not found",
-                        "schema": {}
                     }
                 }
             }
         },
-        "/user/{userID}": {
+        "/health/ready": {
             "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Fetches user profile by given ID",
-                "consumes": [
+                "description": "Checks the dependencies of the service, unavailable when a critical one is down or the server is shutting down. The report is reused for a few seconds and the errors of the dependencies are only logged.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "ops"
+                ],
+                "summary": "Get readiness",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "A critical dependency is down",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/{key}": {
+            "get": {
+                "description": "Serve an attached image or its thumbnail. The URLs are returned with the attachments of the posts. The images of posts that aren't public need the token of a user who can view the post, in the Authorization header or the access_token query parameter.",
                 "produces": [
-                    "application/json"
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif"
                 ],
                 "tags": [
-                    "user"
+                    "media"
                 ],
-                "summary": "Profile by ID",
+                "summary": "Get a stored image",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Target User ID",
-                        "name": "userID",
+                        "type": "string",
+                        "description": "Key of the image",
+                        "name": "key",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT of the viewer, for the images of posts that aren't public",
+                        "name": "access_token",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Image",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "file"
                         }
                     },
-                    "400": {
-                        "description": "Malformed param",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {}
                     },
                     "404": {
-                        "description": "Invalid request",
+                        "description": "Image not found",
                         "schema": {}
                     }
                 }
             }
         },
-        "/user/{userID}/follow": {
-            "put": {
+        "/moderation/comments": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Follow a user by providing the target user's ID in the path and the current user's ID in the request body.",
+                "description": "Lists the comments held by the content filter, the oldest first. Reviewed comments leave the queue, reload the first page to get the next ones.",
                 "consumes": [
                     "application/json"
                 ],
@@ -522,9 +617,1830 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "moderation"
                 ],
-                "summary": "Follow a user",
+                "summary": "Comments held for review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comments per page, up to 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset in the queue",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by asc or desc creation",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Comment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/moderation/comments/{commentID}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Publish a comment held for review, the post author and the mentions are notified",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Approve a held comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Comment not held for review",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reject a comment held for review, it stays visible to its author only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Reject a held comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "commentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Comment not held for review",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/moderation/messages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the messages held by the content filter, the oldest first. Reviewed messages leave the queue, reload the first page to get the next ones.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Messages held for review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Messages per page, up to 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset in the queue",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by asc or desc creation",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Message"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/moderation/messages/{messageID}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Publish a message held for review, it is streamed to the other participants",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Approve a held message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Message not held for review",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reject a message held for review, it stays visible to its sender only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Reject a held message",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Message ID",
+                        "name": "messageID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Message not held for review",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/moderation/posts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the posts held by the content filter, the oldest first. Reviewed posts leave the queue, reload the first page to get the next ones.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Posts held for review",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Posts per page, up to 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset in the queue",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by asc or desc creation",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Post"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/moderation/posts/{postID}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Publish a post held for review, its mentions are notified and it is streamed to the followers of its author",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Approve a held post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "postID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Post not held for review",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "The post was edited during the review",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reject a post held for review, it stays visible to its author only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Reject a held post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "postID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a moderator",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Post not held for review",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "The post was edited during the review",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "List the notifications of the current user, the most recently updated first. Pass next_cursor as cursor to get the next page. A notification updated while paging moves to the top, past the cursor, so the next pages skip it: reload the first page to catch up.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "List notifications",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notifications per page, up to 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.NotificationsPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/notifications/read": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Mark every notification of the current user as read",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark all notifications as read",
+                "responses": {
+                    "204": {
+                        "description": "Notifications marked as read",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/unread-count": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the number of unread notifications of the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Count unread notifications",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/unsubscribe": {
+            "get": {
+                "description": "Page of the unsubscribe link sent in the notification emails, it only asks for a confirmation which is posted back to the same link.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Confirm unsubscribing from notification emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Signed unsubscribe token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Confirmation page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "description": "Turns off the emails of the unsubscribe link, it works without logging in. Posted by the confirmation page and by mail clients implementing the RFC 8058 one-click unsubscribe.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Unsubscribe from notification emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Signed unsubscribe token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unsubscribed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/notifications/{notificationID}/read": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Mark a notification of the current user as read, later events start a new notification instead of being grouped with it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark a notification as read",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification ID",
+                        "name": "notificationID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Notification marked as read",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/posts": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a post by providing necessary information.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Create a post",
+                "parameters": [
+                    {
+                        "description": "Request body with post details",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreatePostPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created post information",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "202": {
+                        "description": "Post held for review",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "422": {
+                        "description": "Rejected by the content filter",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/posts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get post details and respective comments by id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Get a post by id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a post by id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Delete a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Post deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update post details by id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Update a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Request body with post details",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpdatePostPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Updated post information",
+                        "schema": {
+                            "$ref": "#/definitions/models.Post"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict occurred while updating",
+                        "schema": {}
+                    },
+                    "422": {
+                        "description": "Rejected by the content filter",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/posts/{id}/attachments": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Upload a JPEG, PNG or GIF image as multipart form data. The type is detected from the content, the image is re-encoded without its metadata (EXIF location included) and a thumbnail is generated.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Attach an image to a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Description of the image",
+                        "name": "alt_text",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Attached image",
+                        "schema": {
+                            "$ref": "#/definitions/models.Attachment"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Too many attachments",
+                        "schema": {}
+                    },
+                    "413": {
+                        "description": "Image too large",
+                        "schema": {}
+                    },
+                    "415": {
+                        "description": "Unsupported image type",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/posts/{id}/attachments/{attachmentID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove an image from a post along with its stored files.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Delete an attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "attachmentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Attachment deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Attachment not found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/posts/{id}/comments": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a comment on a post by id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posts"
+                ],
+                "summary": "Comment on a post",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Post ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Request body with comment details",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateCommentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created comment information",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "202": {
+                        "description": "Comment held for review",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {}
+                    },
+                    "422": {
+                        "description": "Rejected by the content filter",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/tags/trending": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ranks the hashtags of public posts and their comments by the number of users who used them over the window",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Trending hashtags",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Sliding window, from 1h to 168h, defaults to 24h",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of hashtags, up to 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TrendingTag"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid window",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/tags/{tag}/posts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the posts using the hashtag that the current user is allowed to see",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Posts of a hashtag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hashtag, with or without the leading #",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit post per request",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset by the previous post",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort post by asc or desc",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search by title or content",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PostWithMetadata"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid hashtag",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches profile of the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "User profile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "404": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update the settings of the current user. Making a private account public approves its pending follow requests.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update account settings",
+                "parameters": [
+                    {
+                        "description": "Settings to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpdateUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/activate/{token}": {
+            "put": {
+                "description": "Activates/Register a user by invitation token",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authentication"
+                ],
+                "summary": "Activates/Register a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invitation token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User activated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "No users found associated to the token provided",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/feed": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get user feed respective to the pagination, filters and sort",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "feed"
+                ],
+                "summary": "Get user feed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit post per request",
+                        "name": "limit",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset by the previous post",
+                        "name": "offset",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Sort post by asc or desc",
+                        "name": "sort",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search by title or content",
+                        "name": "search",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by relative tags",
+                        "name": "tags",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PostWithMetadata"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Post not found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/follow-requests": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the pending follow requests sent to the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Pending follow requests",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FollowRequest"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/user/follow-requests/{userID}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Approve the pending follow request sent by the given user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Approve a follow request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Requesting User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Follow request approved",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Follow request not found",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reject the pending follow request sent by the given user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Reject a follow request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Requesting User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Follow request rejected",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Follow request not found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/notification-preferences": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get how the current user is notified by email of new followers, comments on their posts and mentions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get notification preferences",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationPreferences"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Choose between instant emails, the daily digest or no email at all for each kind of activity",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Update notification preferences",
+                "parameters": [
+                    {
+                        "description": "Preferences to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpdateNotificationPreferencesPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationPreferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/{userID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches user profile by given ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Profile by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed param",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/{userID}/block": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Block a user by ID. Removes the follow relationship in both directions and hides each other's content.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Block a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User blocked successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Target user not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "User already blocked",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unblock a previously blocked user by ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Unblock a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User unblocked successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "User is not blocked",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/{userID}/follow": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Follow a user by providing the target user's ID in the path. Following a private account creates a pending follow request.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Follow a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Follow request pending approval",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "204": {
+                        "description": "User followed successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Blocked by or blocking the target user",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Target user not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Already following the user",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/{userID}/mute": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Mute a user by ID. The muted user's posts are hidden from the current user's feed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Mute a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User muted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Target user not found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "User already muted",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unmute a previously muted user by ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Unmute a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User unmuted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "User is not muted",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/{userID}/posts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the posts of a user. Posts of private accounts are only visible to approved followers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Posts of a user",
                 "parameters": [
                     {
                         "type": "integer",
@@ -532,25 +2448,146 @@ const docTemplate = `{
                         "name": "userID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit post per request",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset by the previous post",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort post by asc or desc",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search by title or content",
+                        "name": "search",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "User followed successfully",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PostWithMetadata"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Private account",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/user/{userID}/suspension": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Suspend a user for the given duration, or ban them permanently when no duration is provided. Requires the moderator role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Suspend a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Suspension details",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SuspendUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Suspension created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Suspension"
                         }
                     },
                     "400": {
                         "description": "Invalid request",
                         "schema": {}
                     },
+                    "403": {
+                        "description": "Insufficient role",
+                        "schema": {}
+                    },
                     "404": {
                         "description": "Target user not found",
                         "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lift the active suspension or ban of a user. Requires the moderator role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Lift a suspension",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Target User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Suspension lifted",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
-                    "409": {
-                        "description": "Already following the user",
+                    "403": {
+                        "description": "Insufficient role",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Target user not found or no active suspension",
                         "schema": {}
                     }
                 }
@@ -563,7 +2600,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Unfollow a user by providing the target user's ID in the path and the current user's ID in the request body.",
+                "description": "Unfollow a user, or cancel a pending follow request, by providing the target user's ID in the path.",
                 "consumes": [
                     "application/json"
                 ],
@@ -603,6 +2640,99 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "health.Component": {
+            "type": "object",
+            "properties": {
+                "critical": {
+                    "type": "boolean"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "$ref": "#/definitions/health.Status"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.Component"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/health.Status"
+                }
+            }
+        },
+        "health.Status": {
+            "type": "string",
+            "enum": [
+                "ok",
+                "degraded",
+                "down",
+                "shutting_down"
+            ],
+            "x-enum-varnames": [
+                "StatusOK",
+                "StatusDegraded",
+                "StatusDown",
+                "StatusShuttingDown"
+            ]
+        },
+        "main.ConversationsPage": {
+            "type": "object",
+            "properties": {
+                "conversations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Conversation"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Empty on the last page",
+                    "type": "string"
+                }
+            }
+        },
+        "main.CreateCommentPayload": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "main.CreateConversationPayload": {
+            "type": "object",
+            "required": [
+                "participant_ids"
+            ],
+            "properties": {
+                "participant_ids": {
+                    "description": "Users to talk to, a group is created for more than one",
+                    "type": "array",
+                    "maxItems": 9,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "description": "Only groups have a title",
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
         "main.CreatePostPayload": {
             "type": "object",
             "required": [
@@ -622,48 +2752,150 @@ const docTemplate = `{
                 },
                 "title": {
                     "type": "string",
-                    "maxLength": 100
+                    "maxLength": 100
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "public",
+                        "followers",
+                        "unlisted"
+                    ]
+                }
+            }
+        },
+        "main.CreateUserTokenPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 3
+                }
+            }
+        },
+        "main.MessagesPage": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Message"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Empty on the last page",
+                    "type": "string"
+                }
+            }
+        },
+        "main.NotificationsPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "Empty on the last page",
+                    "type": "string"
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Notification"
+                    }
+                },
+                "unread_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.RegisterUserPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "language": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 3
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.SendMessagePayload": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 1000
                 }
             }
         },
-        "main.CreateUserTokenPayload": {
+        "main.SuspendUserPayload": {
             "type": "object",
             "required": [
-                "email",
-                "password"
+                "reason"
             ],
             "properties": {
-                "email": {
+                "duration": {
+                    "description": "e.g. 168h, omit for a permanent ban",
                     "type": "string",
-                    "maxLength": 255
+                    "maxLength": 20
                 },
-                "password": {
+                "reason": {
                     "type": "string",
-                    "maxLength": 72,
-                    "minLength": 3
+                    "maxLength": 500
                 }
             }
         },
-        "main.RegisterUserPayload": {
+        "main.UpdateNotificationPreferencesPayload": {
             "type": "object",
-            "required": [
-                "email",
-                "password",
-                "username"
-            ],
             "properties": {
-                "email": {
+                "comment": {
                     "type": "string",
-                    "maxLength": 255
+                    "enum": [
+                        "instant",
+                        "daily",
+                        "off"
+                    ]
                 },
-                "password": {
+                "mention": {
                     "type": "string",
-                    "maxLength": 72,
-                    "minLength": 3
+                    "enum": [
+                        "instant",
+                        "daily",
+                        "off"
+                    ]
                 },
-                "username": {
+                "new_follower": {
                     "type": "string",
-                    "maxLength": 100
+                    "enum": [
+                        "instant",
+                        "daily",
+                        "off"
+                    ]
                 }
             }
         },
@@ -683,6 +2915,60 @@ const docTemplate = `{
                 "title": {
                     "type": "string",
                     "maxLength": 100
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "public",
+                        "followers",
+                        "unlisted"
+                    ]
+                }
+            }
+        },
+        "main.UpdateUserPayload": {
+            "type": "object",
+            "properties": {
+                "is_private": {
+                    "type": "boolean"
+                },
+                "language": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Attachment": {
+            "type": "object",
+            "properties": {
+                "alt_text": {
+                    "type": "string"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "post_id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
                 }
             }
         },
@@ -701,6 +2987,108 @@ const docTemplate = `{
                 "post_id": {
                     "type": "integer"
                 },
+                "status": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Conversation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_group": {
+                    "type": "boolean"
+                },
+                "last_message": {
+                    "$ref": "#/definitions/models.Message"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Participant"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unread_count": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "description": "time of the last message",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FollowRequest": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LinkPreview": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "site_name": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Message": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "conversation_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
                 "user": {
                     "$ref": "#/definitions/models.User"
                 },
@@ -709,9 +3097,86 @@ const docTemplate = `{
                 }
             }
         },
+        "models.Notification": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "the latest actor",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    ]
+                },
+                "actor_count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "post_id": {
+                    "type": "integer"
+                },
+                "read_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.NotificationPreferences": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "mention": {
+                    "type": "string"
+                },
+                "new_follower": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Participant": {
+            "type": "object",
+            "properties": {
+                "joined_at": {
+                    "type": "string"
+                },
+                "last_read_at": {
+                    "type": "string"
+                },
+                "last_read_message_id": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
         "models.Post": {
             "type": "object",
             "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
                 "comments": {
                     "type": "array",
                     "items": {
@@ -727,6 +3192,15 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "link_previews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkPreview"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
                 "tags": {
                     "type": "array",
                     "items": {
@@ -747,12 +3221,21 @@ const docTemplate = `{
                 },
                 "version": {
                     "type": "integer"
+                },
+                "visibility": {
+                    "type": "string"
                 }
             }
         },
         "models.PostWithMetadata": {
             "type": "object",
             "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Attachment"
+                    }
+                },
                 "comments": {
                     "type": "array",
                     "items": {
@@ -771,6 +3254,15 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "link_previews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LinkPreview"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
                 "tags": {
                     "type": "array",
                     "items": {
@@ -791,6 +3283,9 @@ const docTemplate = `{
                 },
                 "version": {
                     "type": "integer"
+                },
+                "visibility": {
+                    "type": "string"
                 }
             }
         },
@@ -811,6 +3306,44 @@ const docTemplate = `{
                 }
             }
         },
+        "models.Suspension": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "nil for a permanent ban",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "moderator_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrendingTag": {
+            "type": "object",
+            "properties": {
+                "tag": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "integer"
+                },
+                "uses": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.User": {
             "type": "object",
             "properties": {
@@ -826,6 +3359,12 @@ const docTemplate = `{
                 "is_active": {
                     "type": "boolean"
                 },
+                "is_private": {
+                    "type": "boolean"
+                },
+                "language": {
+                    "type": "string"
+                },
                 "role": {
                     "$ref": "#/definitions/models.Role"
                 },
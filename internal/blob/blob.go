@@ -0,0 +1,54 @@
+// Package blob stores binary objects, such as the media attached to posts,
+// behind a single interface with a local filesystem and an S3 compatible
+// implementation.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Object is a stored blob, Body must be closed by the caller.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey rejects the keys that could escape the storage root. Keys are
+// slash separated paths made of letters, digits, dots, dashes and underscores.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return false
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+
+		for _, r := range segment {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			case r == '.', r == '-', r == '_':
+			default:
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Local keeps the blobs as files under a directory. The content type is
+// derived from the extension of the key.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Local{dir: dir}, nil
+}
+
+func (s *Local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	name := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	//? Written to a temporary file first so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *Local) Get(ctx context.Context, key string) (*Object, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &Object{
+		Body:        f,
+		ContentType: contentType,
+		Size:        info.Size(),
+	}, nil
+}
+
+func (s *Local) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,122 @@
+package contentfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config mirrors the JSON policy file. Every section is optional, a missing
+// section disables the rule.
+//
+//	{
+//		"banned_words": {"action": "reject", "words": ["spam"]},
+//		"link_blacklist": {"action": "reject", "domains": ["bit.ly"]},
+//		"duplicate": {"action": "hold", "window": "10m", "max_repeats": 2},
+//		"max_mentions": {"action": "hold", "limit": 5}
+//	}
+type Config struct {
+	BannedWords *struct {
+		Action string   `json:"action"`
+		Words  []string `json:"words"`
+	} `json:"banned_words"`
+	LinkBlacklist *struct {
+		Action  string   `json:"action"`
+		Domains []string `json:"domains"`
+	} `json:"link_blacklist"`
+	Duplicate *struct {
+		Action     string `json:"action"`
+		Window     string `json:"window"`
+		MaxRepeats int    `json:"max_repeats"`
+	} `json:"duplicate"`
+	MaxMentions *struct {
+		Action string `json:"action"`
+		Limit  int    `json:"limit"`
+	} `json:"max_mentions"`
+}
+
+// LoadFile builds a pipeline from a JSON policy file. An empty path returns a
+// pipeline that allows everything.
+func LoadFile(path string) (*Pipeline, error) {
+	if path == "" {
+		return New(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid content filter file %s: %w", path, err)
+	}
+
+	return FromConfig(cfg)
+}
+
+func FromConfig(cfg Config) (*Pipeline, error) {
+	var rules []Rule
+
+	if c := cfg.BannedWords; c != nil {
+		action, err := parseVerdict(c.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, NewBannedWordsRule(action, c.Words))
+	}
+
+	if c := cfg.LinkBlacklist; c != nil {
+		action, err := parseVerdict(c.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, NewLinkBlacklistRule(action, c.Domains))
+	}
+
+	if c := cfg.Duplicate; c != nil {
+		action, err := parseVerdict(c.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		window, err := time.ParseDuration(c.Window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duplicate window: %w", err)
+		}
+
+		maxRepeats := c.MaxRepeats
+		if maxRepeats < 1 {
+			maxRepeats = 1
+		}
+
+		rules = append(rules, NewDuplicateRule(action, window, maxRepeats))
+	}
+
+	if c := cfg.MaxMentions; c != nil {
+		action, err := parseVerdict(c.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, NewMaxMentionsRule(action, c.Limit))
+	}
+
+	return New(rules...), nil
+}
+
+func parseVerdict(action string) (Verdict, error) {
+	switch action {
+	case "reject", "":
+		return Reject, nil
+	case "hold":
+		return Hold, nil
+	case "allow":
+		return Allow, nil
+	default:
+		return Allow, fmt.Errorf("unknown content filter action %q", action)
+	}
+}
@@ -0,0 +1,87 @@
+package contentfilter
+
+import (
+	"context"
+	"strings"
+)
+
+type Verdict int
+
+const (
+	Allow Verdict = iota
+	Hold
+	Reject
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+type Kind string
+
+const (
+	KindPost    Kind = "post"
+	KindComment Kind = "comment"
+	KindMessage Kind = "message"
+)
+
+// Content is the user submitted text that goes through the pipeline.
+type Content struct {
+	Kind   Kind
+	UserID int64
+	Title  string
+	Body   string
+	// Edit is set when existing content is changed rather than submitted
+	Edit bool
+}
+
+func (c Content) Text() string {
+	return strings.TrimSpace(c.Title + "\n" + c.Body)
+}
+
+type Decision struct {
+	Verdict Verdict
+	Rule    string
+	Reason  string
+}
+
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, c Content) Decision
+}
+
+type Pipeline struct {
+	rules []Rule
+}
+
+func New(rules ...Rule) *Pipeline {
+	return &Pipeline{rules: rules}
+}
+
+// Evaluate runs every rule against the content. The first rejection wins,
+// otherwise the content is held if any rule asked for a review.
+func (p *Pipeline) Evaluate(ctx context.Context, c Content) Decision {
+	decision := Decision{Verdict: Allow}
+
+	for _, rule := range p.rules {
+		d := rule.Evaluate(ctx, c)
+
+		switch d.Verdict {
+		case Reject:
+			return d
+		case Hold:
+			if decision.Verdict == Allow {
+				decision = d
+			}
+		}
+	}
+
+	return decision
+}
@@ -0,0 +1,78 @@
+package contentfilter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	pipeline := New(
+		NewBannedWordsRule(Reject, []string{"spam", "buy now"}),
+		NewLinkBlacklistRule(Reject, []string{"bad.example"}),
+		NewMaxMentionsRule(Hold, 2),
+	)
+
+	tests := []struct {
+		name    string
+		body    string
+		verdict Verdict
+	}{
+		{"clean content is allowed", "hello gophers", Allow},
+		{"banned word is rejected", "this is SPAM!", Reject},
+		{"banned word inside another word is allowed", "spammer", Allow},
+		{"banned phrase is rejected", "please buy now", Reject},
+		{"blacklisted subdomain is rejected", "see https://www.bad.example/x", Reject},
+		{"too many mentions are held", "@a @b @c", Hold},
+		{"reject wins over hold", "@a @b @c spam", Reject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := pipeline.Evaluate(context.Background(), Content{Kind: KindPost, UserID: 1, Body: tt.body})
+			if d.Verdict != tt.verdict {
+				t.Errorf("expected %s, got %s (%s)", tt.verdict, d.Verdict, d.Reason)
+			}
+		})
+	}
+}
+
+func TestDuplicateRule(t *testing.T) {
+	rule := NewDuplicateRule(Hold, time.Minute, 1)
+	ctx := context.Background()
+
+	if d := rule.Evaluate(ctx, Content{UserID: 1, Body: "same  text"}); d.Verdict != Allow {
+		t.Fatalf("expected first submission to be allowed, got %s", d.Verdict)
+	}
+
+	if d := rule.Evaluate(ctx, Content{UserID: 2, Body: "same text"}); d.Verdict != Allow {
+		t.Fatalf("expected another user's submission to be allowed, got %s", d.Verdict)
+	}
+
+	if d := rule.Evaluate(ctx, Content{UserID: 1, Body: "Same text"}); d.Verdict != Hold {
+		t.Fatalf("expected repeated submission to be held, got %s", d.Verdict)
+	}
+
+	if d := rule.Evaluate(ctx, Content{UserID: 2, Body: "same text", Edit: true}); d.Verdict != Allow {
+		t.Fatalf("expected an edit to be allowed, got %s", d.Verdict)
+	}
+}
+
+func TestDuplicateRuleSweep(t *testing.T) {
+	rule := NewDuplicateRule(Hold, time.Minute, 1)
+	ctx := context.Background()
+
+	now := time.Now()
+	rule.now = func() time.Time { return now }
+
+	for userID := range int64(10) {
+		rule.Evaluate(ctx, Content{UserID: userID, Body: "hello"})
+	}
+
+	now = now.Add(time.Minute)
+	rule.Evaluate(ctx, Content{UserID: 1, Body: "hello"})
+
+	if len(rule.seen) != 1 {
+		t.Errorf("expected the idle users to be forgotten, %d left", len(rule.seen))
+	}
+}
@@ -0,0 +1,237 @@
+package contentfilter
+
+import (
+	"SocialMedia/internal/textparse"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+var linkRegex = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// BannedWordsRule matches whole words (or phrases when they contain a space)
+// case-insensitively.
+type BannedWordsRule struct {
+	action  Verdict
+	words   map[string]struct{}
+	phrases []string
+}
+
+func NewBannedWordsRule(action Verdict, words []string) *BannedWordsRule {
+	rule := &BannedWordsRule{
+		action: action,
+		words:  make(map[string]struct{}),
+	}
+
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" {
+			continue
+		}
+
+		if strings.ContainsFunc(w, unicode.IsSpace) {
+			rule.phrases = append(rule.phrases, w)
+			continue
+		}
+
+		rule.words[w] = struct{}{}
+	}
+
+	return rule
+}
+
+func (r *BannedWordsRule) Name() string {
+	return "banned_words"
+}
+
+func (r *BannedWordsRule) Evaluate(ctx context.Context, c Content) Decision {
+	text := strings.ToLower(c.Text())
+
+	for _, phrase := range r.phrases {
+		if strings.Contains(text, phrase) {
+			return Decision{Verdict: r.action, Rule: r.Name(), Reason: "content contains a banned phrase"}
+		}
+	}
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, w := range words {
+		if _, ok := r.words[w]; ok {
+			return Decision{Verdict: r.action, Rule: r.Name(), Reason: "content contains a banned word"}
+		}
+	}
+
+	return Decision{Verdict: Allow}
+}
+
+// LinkBlacklistRule matches links pointing to a blacklisted domain or any of
+// its subdomains.
+type LinkBlacklistRule struct {
+	action  Verdict
+	domains []string
+}
+
+func NewLinkBlacklistRule(action Verdict, domains []string) *LinkBlacklistRule {
+	rule := &LinkBlacklistRule{action: action}
+
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www.")
+		if d != "" {
+			rule.domains = append(rule.domains, d)
+		}
+	}
+
+	return rule
+}
+
+func (r *LinkBlacklistRule) Name() string {
+	return "link_blacklist"
+}
+
+func (r *LinkBlacklistRule) Evaluate(ctx context.Context, c Content) Decision {
+	for _, link := range linkRegex.FindAllString(c.Text(), -1) {
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+
+		host := strings.ToLower(u.Hostname())
+
+		for _, d := range r.domains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return Decision{Verdict: r.action, Rule: r.Name(), Reason: fmt.Sprintf("links to %s are not allowed", d)}
+			}
+		}
+	}
+
+	return Decision{Verdict: Allow}
+}
+
+// MaxMentionsRule limits the number of distinct users mentioned in a single
+// piece of content.
+type MaxMentionsRule struct {
+	action Verdict
+	limit  int
+}
+
+func NewMaxMentionsRule(action Verdict, limit int) *MaxMentionsRule {
+	return &MaxMentionsRule{action: action, limit: limit}
+}
+
+func (r *MaxMentionsRule) Name() string {
+	return "max_mentions"
+}
+
+func (r *MaxMentionsRule) Evaluate(ctx context.Context, c Content) Decision {
+	mentions := make(map[string]struct{})
+
+	for _, m := range textparse.Mentions(c.Text()) {
+		mentions[strings.ToLower(m)] = struct{}{}
+	}
+
+	if len(mentions) > r.limit {
+		return Decision{Verdict: r.action, Rule: r.Name(), Reason: fmt.Sprintf("content mentions more than %d users", r.limit)}
+	}
+
+	return Decision{Verdict: Allow}
+}
+
+// DuplicateRule flags users that keep submitting the same content within the
+// configured window. State is kept in memory per API instance, the users who
+// stopped submitting are forgotten once per window. Edits aren't submissions,
+// saving the same post again isn't a repeat.
+type DuplicateRule struct {
+	sync.Mutex
+	action     Verdict
+	window     time.Duration
+	maxRepeats int
+	seen       map[int64][]submission
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+type submission struct {
+	fingerprint [sha256.Size]byte
+	at          time.Time
+}
+
+func NewDuplicateRule(action Verdict, window time.Duration, maxRepeats int) *DuplicateRule {
+	return &DuplicateRule{
+		action:     action,
+		window:     window,
+		maxRepeats: maxRepeats,
+		seen:       make(map[int64][]submission),
+		now:        time.Now,
+	}
+}
+
+func (r *DuplicateRule) Name() string {
+	return "duplicate"
+}
+
+func (r *DuplicateRule) Evaluate(ctx context.Context, c Content) Decision {
+	if c.Edit {
+		return Decision{Verdict: Allow}
+	}
+
+	now := r.now()
+	fingerprint := sha256.Sum256([]byte(strings.Join(strings.Fields(strings.ToLower(c.Body)), " ")))
+
+	r.Lock()
+	defer r.Unlock()
+
+	if now.Sub(r.lastSweep) >= r.window {
+		r.sweep(now)
+	}
+
+	// Drop the submissions that fell out of the window
+	recent := r.recent(c.UserID, now)
+
+	repeats := 0
+	for _, s := range recent {
+		if s.fingerprint == fingerprint {
+			repeats++
+		}
+	}
+
+	r.seen[c.UserID] = append(recent, submission{fingerprint: fingerprint, at: now})
+
+	if repeats >= r.maxRepeats {
+		return Decision{Verdict: r.action, Rule: r.Name(), Reason: "the same content was submitted too many times"}
+	}
+
+	return Decision{Verdict: Allow}
+}
+
+// recent returns the submissions of the user still within the window.
+func (r *DuplicateRule) recent(userID int64, now time.Time) []submission {
+	recent := r.seen[userID][:0]
+	for _, s := range r.seen[userID] {
+		if now.Sub(s.at) < r.window {
+			recent = append(recent, s)
+		}
+	}
+
+	return recent
+}
+
+// sweep forgets the users without submissions within the window.
+func (r *DuplicateRule) sweep(now time.Time) {
+	for userID := range r.seen {
+		if recent := r.recent(userID, now); len(recent) > 0 {
+			r.seen[userID] = recent
+		} else {
+			delete(r.seen, userID)
+		}
+	}
+
+	r.lastSweep = now
+}
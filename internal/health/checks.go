@@ -0,0 +1,35 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Migrations fails while the schema is behind the latest migration, or was
+// left dirty by a failed one. A schema ahead of latest is fine, it's the one of
+// a newer version being rolled out.
+func Migrations(db *sql.DB, latest uint) func(context.Context) error {
+	return func(ctx context.Context) error {
+		var version uint
+		var dirty bool
+
+		//? The table of the migrate CLI
+		query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+		err := db.QueryRowContext(ctx, query).Scan(&version, &dirty)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("no migration applied, %d pending", latest)
+		case err != nil:
+			return err
+		case dirty:
+			return fmt.Errorf("migration %d failed, the schema is dirty", version)
+		case version < latest:
+			return fmt.Errorf("schema at version %d, %d pending", version, latest-version)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultTimeout bounds the checks that don't set their own timeout.
+const defaultTimeout = 2 * time.Second
+
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded is a failing non critical check, the service still works
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+	// StatusShuttingDown is reported once the server stopped taking requests
+	StatusShuttingDown Status = "shutting_down"
+)
+
+type Check struct {
+	Name string
+	// Critical checks make the service unready when they fail, the others only
+	// degrade it
+	Critical bool
+	Timeout  time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Component struct {
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	// Error is logged, never served, it can tell hosts and credentials away
+	Error string `json:"-"`
+}
+
+type Report struct {
+	Status     Status               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// Ready tells whether the service should receive traffic.
+func (r Report) Ready() bool {
+	return r.Status == StatusOK || r.Status == StatusDegraded
+}
+
+// Checker runs the checks of the dependencies of the service.
+type Checker struct {
+	checks []Check
+	// The last report is reused for maxAge, so the dependencies are checked at
+	// most once per maxAge however often the report is asked for
+	maxAge time.Duration
+
+	mu     sync.Mutex
+	last   Report
+	lastAt time.Time
+}
+
+func New(checks ...Check) *Checker {
+	return &Checker{checks: checks}
+}
+
+// NewCached returns a checker that reuses its report for maxAge.
+func NewCached(maxAge time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, maxAge: maxAge}
+}
+
+// Run runs every check concurrently, each within its timeout, unless the last
+// report is recent enough. Concurrent callers share the same run.
+func (c *Checker) Run(ctx context.Context) Report {
+	if c.maxAge <= 0 {
+		return c.run(ctx)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.lastAt.IsZero() && time.Since(c.lastAt) < c.maxAge {
+		return c.last
+	}
+
+	//? The report is shared, the caller going away must not fail it
+	c.last = c.run(context.WithoutCancel(ctx))
+	c.lastAt = time.Now()
+
+	return c.last
+}
+
+func (c *Checker) run(ctx context.Context) Report {
+	report := Report{
+		Status:     StatusOK,
+		Components: make(map[string]Component, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			component := run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Components[check.Name] = component
+			if component.Status == StatusOK {
+				return
+			}
+
+			switch {
+			case check.Critical:
+				report.Status = StatusDown
+			case report.Status == StatusOK:
+				report.Status = StatusDegraded
+			}
+		}(check)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func run(ctx context.Context, check Check) Component {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	latency := time.Since(start)
+
+	component := Component{
+		Status:    StatusOK,
+		Critical:  check.Critical,
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+
+	return component
+}
@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name   string
+		checks []Check
+		status Status
+		ready  bool
+	}{
+		{
+			name: "should be ok when every check passes",
+			checks: []Check{
+				{Name: "database", Critical: true, Run: ok},
+				{Name: "redis", Run: ok},
+			},
+			status: StatusOK,
+			ready:  true,
+		},
+		{
+			name: "should be degraded when a non critical check fails",
+			checks: []Check{
+				{Name: "database", Critical: true, Run: ok},
+				{Name: "redis", Run: down},
+			},
+			status: StatusDegraded,
+			ready:  true,
+		},
+		{
+			name: "should be down when a critical check fails",
+			checks: []Check{
+				{Name: "database", Critical: true, Run: down},
+				{Name: "redis", Run: down},
+			},
+			status: StatusDown,
+			ready:  false,
+		},
+		{
+			name: "should fail the checks that time out",
+			checks: []Check{
+				{Name: "database", Critical: true, Timeout: 10 * time.Millisecond, Run: hang},
+			},
+			status: StatusDown,
+			ready:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := New(tt.checks...).Run(context.Background())
+
+			if report.Status != tt.status || report.Ready() != tt.ready {
+				t.Errorf("expected %s (ready %v), got %s", tt.status, tt.ready, report.Status)
+			}
+
+			if len(report.Components) != len(tt.checks) {
+				t.Fatalf("expected %d components, got %d", len(tt.checks), len(report.Components))
+			}
+
+			for _, check := range tt.checks {
+				component := report.Components[check.Name]
+				if component.Critical != check.Critical {
+					t.Errorf("expected %s to be critical %v", check.Name, check.Critical)
+				}
+				if component.Status == StatusDown && component.Error == "" {
+					t.Errorf("expected the error of %s", check.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestCachedChecker(t *testing.T) {
+	runs := 0
+	checker := NewCached(time.Hour, Check{
+		Name: "database",
+		Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		},
+	})
+
+	// A caller going away must not fail the report shared with the others
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for range 3 {
+		if report := checker.Run(ctx); report.Status != StatusOK {
+			t.Fatalf("expected the service to be ok, got %s", report.Status)
+		}
+	}
+
+	if runs != 1 {
+		t.Errorf("expected the checks to run once, ran %d times", runs)
+	}
+}
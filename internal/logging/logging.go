@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type loggerKey string
+
+const loggerCtx loggerKey = "logger"
+
+// NewContext returns a copy of ctx carrying logger, the logger of a request
+// with its IDs.
+func NewContext(ctx context.Context, logger *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, loggerCtx, logger)
+}
+
+// FromContext returns the logger carried by ctx, ok is false outside of a
+// request.
+func FromContext(ctx context.Context) (logger *zap.SugaredLogger, ok bool) {
+	logger, ok = ctx.Value(loggerCtx).(*zap.SugaredLogger)
+	return logger, ok
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are redacted from the fields whose key contains one of them.
+var sensitiveKeys = []string{"token", "password", "secret", "authorization", "cookie"}
+
+// Redact wraps core so the values of the sensitive fields never reach the
+// logs, whichever logger they were added with.
+func Redact(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core}
+}
+
+type redactingCore struct {
+	zapcore.Core
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(redact(fields))}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, redact(fields))
+}
+
+func redact(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, field := range fields {
+		if !sensitive(field.Key) {
+			continue
+		}
+
+		//? Copied so the fields of the caller aren't modified
+		if out == nil {
+			out = append([]zapcore.Field(nil), fields...)
+		}
+		out[i] = zap.String(field.Key, redacted)
+	}
+
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
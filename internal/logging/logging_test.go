@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedact(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(Redact(core)).Sugar()
+
+	logger.With("access_token", "abc").Infow("user created", "user", 1, "plainToken", "def", "Password", "secret")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	for _, key := range []string{"access_token", "plainToken", "Password"} {
+		if fields[key] != redacted {
+			t.Errorf("expected %s to be redacted, got %v", key, fields[key])
+		}
+	}
+	if fields["user"] != int64(1) {
+		t.Errorf("expected the other fields to be kept, got %v", fields["user"])
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("expected no logger outside of a request")
+	}
+
+	logger := zap.NewNop().Sugar()
+	if got, ok := FromContext(NewContext(context.Background(), logger)); !ok || got != logger {
+		t.Error("expected the logger of the request")
+	}
+}
@@ -1,16 +1,23 @@
 package mailer
 
-import "embed"
+import (
+	"embed"
+)
 
 const (
 	FromName            = "Vaibhav Patel"
-	maxRetries          = 3
-	UserWelcomeTemplate = "user_invitation.tmpl"
+	UserWelcomeTemplate = "user_invitation"
+
+	// Notification emails
+	NewFollowerTemplate = "notification_new_follower"
+	CommentTemplate     = "notification_comment"
+	MentionTemplate     = "notification_mention"
+	DigestTemplate      = "notification_digest"
 )
 
 //go:embed "templates"
 var FS embed.FS
 
 type Client interface {
-	Send(templateFile string, username, email string, data any, isSandbox bool) (int, error)
+	Send(templateName string, username, email, locale string, data any, isSandbox bool) (int, error)
 }
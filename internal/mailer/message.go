@@ -0,0 +1,76 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"time"
+)
+
+// Message is a rendered email, as handed to a backend.
+type Message struct {
+	From           mail.Address
+	To             mail.Address
+	Subject        string
+	Text           string
+	HTML           string
+	UnsubscribeURL string
+	SentAt         time.Time
+}
+
+func newMessage(fromEmail, username, email string, rendered *Rendered) Message {
+	return Message{
+		From:           mail.Address{Name: FromName, Address: fromEmail},
+		To:             mail.Address{Name: username, Address: email},
+		Subject:        rendered.Subject,
+		Text:           rendered.Text,
+		HTML:           rendered.HTML,
+		UnsubscribeURL: rendered.UnsubscribeURL,
+		SentAt:         time.Now(),
+	}
+}
+
+// Bytes encodes the message in the RFC 5322 format used by SMTP and .eml files.
+// The body is a multipart/alternative with the plain-text part first so that
+// clients prefer the HTML one.
+func (m Message) Bytes() []byte {
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+
+	header := new(bytes.Buffer)
+	fmt.Fprintf(header, "From: %s\r\n", m.From.String())
+	fmt.Fprintf(header, "To: %s\r\n", m.To.String())
+	fmt.Fprintf(header, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(header, "Date: %s\r\n", m.SentAt.Format(time.RFC1123Z))
+	if m.UnsubscribeURL != "" {
+		//? RFC 8058 one-click unsubscribe, mail clients POST to the link
+		fmt.Fprintf(header, "List-Unsubscribe: <%s>\r\n", m.UnsubscribeURL)
+		header.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	header.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(header, "Content-Type: multipart/alternative; boundary=%q\r\n", w.Boundary())
+	header.WriteString("\r\n")
+
+	writePart(w, "text/plain; charset=UTF-8", m.Text)
+	writePart(w, "text/html; charset=UTF-8", m.HTML)
+	w.Close()
+
+	return append(header.Bytes(), buf.Bytes()...)
+}
+
+// writePart adds a quoted-printable encoded part, writes to the in-memory
+// buffer can't fail.
+func writePart(w *multipart.Writer, contentType, body string) {
+	part, _ := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+
+	qp := quotedprintable.NewWriter(part)
+	qp.Write([]byte(body))
+	qp.Close()
+}
@@ -0,0 +1,194 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale is used when a template has no variant for the requested
+// locale.
+const DefaultLocale = "en"
+
+// Layouts and partials are shared by every template. The partials directory of
+// a locale holds the partials of that locale only, e.g. the signature.
+const (
+	layoutsGlob  = "templates/layouts/*"
+	partialsGlob = "templates/partials/*"
+)
+
+// UnsubscribeURLKey is the field of the template data holding the one-click
+// unsubscribe link. The link is also sent in the List-Unsubscribe headers.
+const UnsubscribeURLKey = "UnsubscribeURL"
+
+// Rendered holds the parts of an email built from a template.
+type Rendered struct {
+	Subject        string
+	Text           string
+	HTML           string
+	UnsubscribeURL string
+}
+
+// templateData is what the layouts are executed with, the templates
+// themselves only see Data.
+type templateData struct {
+	Locale string
+	Data   any
+}
+
+type localizedTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer builds emails from the embedded templates. Every template is
+// parsed once by NewTemplateRenderer, rendering is safe for concurrent use.
+type Renderer struct {
+	// templates are keyed by name then locale
+	templates map[string]map[string]*localizedTemplate
+}
+
+// NewTemplateRenderer parses the embedded templates. Each template lives in a
+// locale directory as <locale>/<name>.txt.tmpl and <locale>/<name>.html.tmpl,
+// the text variant defines "subject" and "content", the HTML variant
+// defines "content".
+func NewTemplateRenderer() (*Renderer, error) {
+	return newRenderer(FS)
+}
+
+func newRenderer(fsys fs.FS) (*Renderer, error) {
+	r := &Renderer{templates: map[string]map[string]*localizedTemplate{}}
+
+	locales, err := fs.ReadDir(fsys, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, locale := range locales {
+		if !locale.IsDir() || locale.Name() == "layouts" || locale.Name() == "partials" {
+			continue
+		}
+
+		dir := path.Join("templates", locale.Name())
+
+		names, err := fs.Glob(fsys, path.Join(dir, "*.txt.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range names {
+			name := strings.TrimSuffix(path.Base(file), ".txt.tmpl")
+
+			t, err := parseLocalized(fsys, dir, name)
+			if err != nil {
+				return nil, fmt.Errorf("mailer: parsing %s/%s: %w", locale.Name(), name, err)
+			}
+
+			if r.templates[name] == nil {
+				r.templates[name] = map[string]*localizedTemplate{}
+			}
+			r.templates[name][locale.Name()] = t
+		}
+	}
+
+	return r, nil
+}
+
+func parseLocalized(fsys fs.FS, dir, name string) (*localizedTemplate, error) {
+	text, err := texttemplate.ParseFS(fsys,
+		layoutsGlob+".txt.tmpl",
+		partialsGlob+".txt.tmpl",
+		path.Join(dir, "partials", "*.txt.tmpl"),
+		path.Join(dir, name+".txt.tmpl"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.ParseFS(fsys,
+		layoutsGlob+".html.tmpl",
+		partialsGlob+".html.tmpl",
+		path.Join(dir, "partials", "*.html.tmpl"),
+		path.Join(dir, name+".html.tmpl"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &localizedTemplate{text: text, html: html}, nil
+}
+
+// Render builds the subject, the plain-text and the HTML parts of the template
+// for the given locale. When the locale has no variant the base language is
+// tried, e.g. "fr" for "fr-CA", then DefaultLocale.
+func (r *Renderer) Render(name, locale string, data any) (*Rendered, error) {
+	//? Emails queued before the templates were split still carry the file name
+	name = strings.TrimSuffix(name, ".tmpl")
+
+	variants, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("mailer: unknown template %q", name)
+	}
+
+	locale = resolveLocale(variants, locale)
+
+	t, ok := variants[locale]
+	if !ok {
+		return nil, fmt.Errorf("mailer: template %q has no %q variant", name, locale)
+	}
+
+	td := templateData{Locale: locale, Data: data}
+	rendered := &Rendered{UnsubscribeURL: unsubscribeURL(data)}
+
+	subject := new(bytes.Buffer)
+	if err := t.text.ExecuteTemplate(subject, "subject", data); err != nil {
+		return nil, err
+	}
+	rendered.Subject = strings.TrimSpace(subject.String())
+
+	text := new(bytes.Buffer)
+	if err := t.text.ExecuteTemplate(text, "layout", td); err != nil {
+		return nil, err
+	}
+	rendered.Text = text.String()
+
+	html := new(bytes.Buffer)
+	if err := t.html.ExecuteTemplate(html, "layout", td); err != nil {
+		return nil, err
+	}
+	rendered.HTML = html.String()
+
+	return rendered, nil
+}
+
+// unsubscribeURL is the unsubscribe link of the data, the outbox hands the
+// data of the queued emails over as a map.
+func unsubscribeURL(data any) string {
+	fields, ok := data.(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	link, _ := fields[UnsubscribeURLKey].(string)
+	return link
+}
+
+func resolveLocale(variants map[string]*localizedTemplate, locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+
+	if _, ok := variants[locale]; ok {
+		return locale
+	}
+
+	if base, _, found := strings.Cut(locale, "-"); found {
+		if _, ok := variants[base]; ok {
+			return base
+		}
+	}
+
+	return DefaultLocale
+}
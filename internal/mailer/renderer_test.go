@@ -0,0 +1,119 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	r, err := NewTemplateRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]any{
+		"Username":      "<b>gopher</b>",
+		"ActivationURL": "http://localhost:3000/confirm/token",
+	}
+
+	t.Run("should escape the HTML part only", func(t *testing.T) {
+		rendered, err := r.Render(UserWelcomeTemplate, "en", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(rendered.HTML, "<b>gopher</b>") || !strings.Contains(rendered.HTML, "&lt;b&gt;gopher&lt;/b&gt;") {
+			t.Errorf("expected the username to be escaped in the HTML part, got %s", rendered.HTML)
+		}
+
+		if !strings.Contains(rendered.Text, "<b>gopher</b>") {
+			t.Errorf("expected the username to be left as is in the text part, got %s", rendered.Text)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		locale  string
+		subject string
+	}{
+		{"exact locale", "fr", "Finalisez votre inscription à GoSocial"},
+		{"base language of a regional locale", "fr-CA", "Finalisez votre inscription à GoSocial"},
+		{"unknown locale falls back to english", "de", "Finish Registration with GoSocial"},
+		{"empty locale falls back to english", "", "Finish Registration with GoSocial"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := r.Render(UserWelcomeTemplate, tt.locale, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rendered.Subject != tt.subject {
+				t.Errorf("expected subject %q, got %q", tt.subject, rendered.Subject)
+			}
+		})
+	}
+
+	t.Run("should accept the legacy template file name", func(t *testing.T) {
+		if _, err := r.Render("user_invitation.tmpl", "en", data); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should fail on an unknown template", func(t *testing.T) {
+		if _, err := r.Render("missing", "en", data); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestMessageBytes(t *testing.T) {
+	message := newMessage("noreply@gosocial.local", "gopher", "gopher@example.com", &Rendered{
+		Subject: "Hello",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	})
+
+	raw := string(message.Bytes())
+
+	for _, want := range []string{
+		"Content-Type: multipart/alternative; boundary=",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Type: text/html; charset=UTF-8",
+		"plain body",
+		"<p>html body</p>",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("expected the message to contain %q, got:\n%s", want, raw)
+		}
+	}
+}
+
+func TestUnsubscribeHeaders(t *testing.T) {
+	r, err := NewTemplateRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := r.Render(NewFollowerTemplate, "en", map[string]any{
+		"Username":        "gopher",
+		"ActorName":       "alice",
+		"URL":             "http://localhost:3000/users/2",
+		UnsubscribeURLKey: "http://localhost:8080/v1/notifications/unsubscribe?token=abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := string(newMessage("noreply@gosocial.local", "gopher", "gopher@example.com", rendered).Bytes())
+
+	for _, want := range []string{
+		"List-Unsubscribe: <http://localhost:8080/v1/notifications/unsubscribe?token=abc>\r\n",
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("expected the message to contain %q, got:\n%s", want, raw)
+		}
+	}
+}
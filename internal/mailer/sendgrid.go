@@ -1,10 +1,7 @@
 package mailer
 
 import (
-	"bytes"
 	"fmt"
-	"text/template"
-	"time"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
@@ -14,60 +11,51 @@ type SendGridMailer struct {
 	fromEmail string
 	apiKey    string
 	client    *sendgrid.Client
+	renderer  *Renderer
 }
 
-func NewSendGrid(apiKey, fromEmail string) *SendGridMailer {
+func NewSendGrid(apiKey, fromEmail string, renderer *Renderer) *SendGridMailer {
 	client := sendgrid.NewSendClient(apiKey)
 
 	return &SendGridMailer{
 		fromEmail: fromEmail,
 		apiKey:    apiKey,
 		client:    client,
+		renderer:  renderer,
 	}
 }
 
-func (m *SendGridMailer) Send(templateFile, username, email string, data any, isSandbox bool) (int, error) {
+func (m *SendGridMailer) Send(templateName, username, email, locale string, data any, isSandbox bool) (int, error) {
 	from := mail.NewEmail(FromName, m.fromEmail)
 	to := mail.NewEmail(username, email)
 
-	// template parsing and building
-	tmpl, err := template.ParseFS(FS, "templates/"+templateFile)
+	rendered, err := m.renderer.Render(templateName, locale, data)
 	if err != nil {
 		return -1, err
 	}
 
-	subject := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(subject, "subject", data)
-	if err != nil {
-		return -1, err
-	}
+	message := mail.NewSingleEmail(from, rendered.Subject, to, rendered.Text, rendered.HTML)
 
-	body := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(body, "body", data)
-	if err != nil {
-		return -1, err
+	if rendered.UnsubscribeURL != "" {
+		message.SetHeader("List-Unsubscribe", "<"+rendered.UnsubscribeURL+">")
+		message.SetHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
 	}
 
-	message := mail.NewSingleEmail(from, subject.String(), to, "", body.String())
-
 	message.SetMailSettings(&mail.MailSettings{
 		SandboxMode: &mail.Setting{
 			Enable: &isSandbox,
 		},
 	})
 
-	var retryErr error
-
-	for i := -1; i < maxRetries; i++ {
-		response, retryErr := m.client.Send(message)
-		if retryErr != nil {
-			// exponential backoff
-			time.Sleep(time.Second * time.Duration(i+1))
-			continue
-		}
+	//? Retries are handled by the outbox workers, a single attempt is made here
+	response, err := m.client.Send(message)
+	if err != nil {
+		return -1, err
+	}
 
-		return response.StatusCode, nil
+	if response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("sendgrid responded with status %d: %s", response.StatusCode, response.Body)
 	}
 
-	return -1, fmt.Errorf("failed to send email after %d attempts, error: %v", maxRetries, retryErr)
+	return response.StatusCode, nil
 }
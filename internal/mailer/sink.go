@@ -0,0 +1,87 @@
+package mailer
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileMailer writes every rendered email as an .eml file to a directory
+// instead of delivering it. Useful for offline and on-prem test environments.
+type FileMailer struct {
+	fromEmail string
+	dir       string
+	renderer  *Renderer
+}
+
+func NewFileMailer(dir, fromEmail string, renderer *Renderer) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{
+		fromEmail: fromEmail,
+		dir:       dir,
+		renderer:  renderer,
+	}, nil
+}
+
+func (m *FileMailer) Send(templateName, username, email, locale string, data any, isSandbox bool) (int, error) {
+	rendered, err := m.renderer.Render(templateName, locale, data)
+	if err != nil {
+		return -1, err
+	}
+
+	message := newMessage(m.fromEmail, username, email, rendered)
+
+	name := fmt.Sprintf("%d-%s.eml", message.SentAt.UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(email))
+	if err := os.WriteFile(filepath.Join(m.dir, name), message.Bytes(), 0o644); err != nil {
+		return -1, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// InMemoryMailer keeps the rendered emails in memory so they can be inspected,
+// mainly by tests.
+type InMemoryMailer struct {
+	sync.Mutex
+	fromEmail string
+	renderer  *Renderer
+	messages  []Message
+}
+
+func NewInMemoryMailer(fromEmail string, renderer *Renderer) *InMemoryMailer {
+	return &InMemoryMailer{fromEmail: fromEmail, renderer: renderer}
+}
+
+func (m *InMemoryMailer) Send(templateName, username, email, locale string, data any, isSandbox bool) (int, error) {
+	rendered, err := m.renderer.Render(templateName, locale, data)
+	if err != nil {
+		return -1, err
+	}
+
+	m.Lock()
+	m.messages = append(m.messages, newMessage(m.fromEmail, username, email, rendered))
+	m.Unlock()
+
+	return http.StatusOK, nil
+}
+
+// Messages returns a copy of the emails sent so far.
+func (m *InMemoryMailer) Messages() []Message {
+	m.Lock()
+	defer m.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Reset forgets the emails sent so far.
+func (m *InMemoryMailer) Reset() {
+	m.Lock()
+	m.messages = nil
+	m.Unlock()
+}
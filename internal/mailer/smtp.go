@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpOK is the SMTP "requested mail action completed" reply code
+const smtpOK = 250
+
+// smtpsPort is the port of SMTP over implicit TLS, the connection is
+// encrypted from the start instead of being upgraded with STARTTLS.
+const smtpsPort = 465
+
+// smtpTimeout bounds a whole delivery, from dialing to QUIT, so a stuck relay
+// can't hold an outbox worker.
+const smtpTimeout = 30 * time.Second
+
+type SMTPMailer struct {
+	fromEmail string
+	host      string
+	port      int
+	username  string
+	password  string
+	startTLS  bool
+	timeout   time.Duration
+	renderer  *Renderer
+}
+
+// NewSMTP creates a mailer that delivers through an SMTP relay. When startTLS
+// is set the connection must be upgraded with STARTTLS before authenticating.
+// Port 465 uses implicit TLS instead.
+func NewSMTP(host string, port int, username, password, fromEmail string, startTLS bool, renderer *Renderer) *SMTPMailer {
+	return &SMTPMailer{
+		fromEmail: fromEmail,
+		host:      host,
+		port:      port,
+		username:  username,
+		password:  password,
+		startTLS:  startTLS,
+		timeout:   smtpTimeout,
+		renderer:  renderer,
+	}
+}
+
+// Send delivers the email right away. Sandbox mode is a SendGrid feature and is
+// ignored here, point the mailer to a local SMTP catcher during development.
+func (m *SMTPMailer) Send(templateName, username, email, locale string, data any, isSandbox bool) (int, error) {
+	rendered, err := m.renderer.Render(templateName, locale, data)
+	if err != nil {
+		return -1, err
+	}
+
+	message := newMessage(m.fromEmail, username, email, rendered)
+
+	if err := m.deliver(message); err != nil {
+		return -1, err
+	}
+
+	return smtpOK, nil
+}
+
+func (m *SMTPMailer) deliver(message Message) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)), m.timeout)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(m.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	implicitTLS := m.port == smtpsPort
+	if implicitTLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: m.host})
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Hello("localhost"); err != nil {
+		return err
+	}
+
+	//? Connections over implicit TLS are encrypted already
+	if !implicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+				return err
+			}
+		} else if m.startTLS {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+	}
+
+	if m.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(message.From.Address); err != nil {
+		return err
+	}
+
+	if err := c.Rcpt(message.To.Address); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	//? The data writer takes care of the CRLF line endings and dot-stuffing
+	if _, err := w.Write(message.Bytes()); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,144 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts a single connection and answers it with serve.
+func fakeSMTP(t *testing.T, serve func(conn net.Conn)) (string, int) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		serve(conn)
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestSMTPMailer(t *testing.T) {
+	renderer, err := NewTemplateRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]any{
+		"Username":      "gopher",
+		"ActivationURL": "http://localhost:3000/confirm/token",
+	}
+
+	t.Run("should deliver the message", func(t *testing.T) {
+		received := make(chan string, 1)
+
+		host, port := fakeSMTP(t, func(conn net.Conn) {
+			r := bufio.NewReader(conn)
+			reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+			reply("220 localhost ESMTP")
+
+			var body strings.Builder
+			inData := false
+
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if inData {
+					if line == ".\r\n" {
+						inData = false
+						received <- body.String()
+						reply("250 OK")
+						continue
+					}
+					body.WriteString(line)
+					continue
+				}
+
+				switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+				case strings.HasPrefix(cmd, "EHLO"):
+					reply("250 localhost")
+				case cmd == "DATA":
+					inData = true
+					reply("354 End data with <CR><LF>.<CR><LF>")
+				case cmd == "QUIT":
+					reply("221 Bye")
+					return
+				default:
+					reply("250 OK")
+				}
+			}
+		})
+
+		m := NewSMTP(host, port, "", "", "noreply@gosocial.local", false, renderer)
+
+		status, err := m.Send(UserWelcomeTemplate, "gopher", "gopher@example.com", "en", data, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status != smtpOK {
+			t.Errorf("expected status %d, got %d", smtpOK, status)
+		}
+
+		if body := <-received; !strings.Contains(body, "Subject: Finish Registration with GoSocial") {
+			t.Errorf("unexpected message:\n%s", body)
+		}
+	})
+
+	t.Run("should give up on a relay that doesn't answer", func(t *testing.T) {
+		host, port := fakeSMTP(t, func(conn net.Conn) {
+			//? Never greets, the client would wait forever without a deadline
+			time.Sleep(time.Second)
+		})
+
+		m := NewSMTP(host, port, "", "", "noreply@gosocial.local", false, renderer)
+		m.timeout = 100 * time.Millisecond
+
+		start := time.Now()
+		if _, err := m.Send(UserWelcomeTemplate, "gopher", "gopher@example.com", "en", data, false); err == nil {
+			t.Fatal("expected a timeout")
+		}
+
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("expected the delivery to time out, it took %s", elapsed)
+		}
+	})
+
+	t.Run("should refuse a relay without STARTTLS when it is required", func(t *testing.T) {
+		host, port := fakeSMTP(t, func(conn net.Conn) {
+			r := bufio.NewReader(conn)
+			conn.Write([]byte("220 localhost ESMTP\r\n"))
+
+			for {
+				if _, err := r.ReadString('\n'); err != nil {
+					return
+				}
+				conn.Write([]byte("250 localhost\r\n"))
+			}
+		})
+
+		m := NewSMTP(host, port, "", "", "noreply@gosocial.local", true, renderer)
+
+		if _, err := m.Send(UserWelcomeTemplate, "gopher", "gopher@example.com", "en", data, false); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+			t.Errorf("expected STARTTLS to be required, got %v", err)
+		}
+	})
+}
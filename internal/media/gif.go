@@ -0,0 +1,88 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// GIF block introducers
+const (
+	gifExtension  = 0x21
+	gifImage      = 0x2C
+	gifTrailer    = 0x3B
+	gifColorTable = 0x80
+)
+
+var errMalformedGIF = errors.New("malformed gif")
+
+// gifFrames walks the blocks of a GIF without decoding them, and returns the
+// number of frames and the pixels they add up to. The header only gives the
+// size of the canvas, each frame is decoded to its own image.
+func gifFrames(data []byte) (frames int, pixels int, err error) {
+	// Header and logical screen descriptor
+	if len(data) < 13 {
+		return 0, 0, errMalformedGIF
+	}
+
+	i := 13
+	if flags := data[10]; flags&gifColorTable != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+
+	for i < len(data) {
+		switch data[i] {
+		case gifTrailer:
+			return frames, pixels, nil
+		case gifExtension:
+			// Introducer and label, then the data sub-blocks
+			if i, err = skipSubBlocks(data, i+2); err != nil {
+				return 0, 0, err
+			}
+		case gifImage:
+			if i+10 > len(data) {
+				return 0, 0, errMalformedGIF
+			}
+
+			width := int(binary.LittleEndian.Uint16(data[i+5 : i+7]))
+			height := int(binary.LittleEndian.Uint16(data[i+7 : i+9]))
+			flags := data[i+9]
+
+			frames++
+			pixels += width * height
+
+			// Descriptor, local color table and LZW minimum code size
+			i += 10
+			if flags&gifColorTable != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+
+			if i, err = skipSubBlocks(data, i+1); err != nil {
+				return 0, 0, err
+			}
+		default:
+			return 0, 0, errMalformedGIF
+		}
+	}
+
+	//? No trailer, the decoder tolerates it
+	return frames, pixels, nil
+}
+
+// skipSubBlocks returns the offset after the sub-blocks starting at i, they
+// end with an empty one.
+func skipSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, errMalformedGIF
+		}
+
+		size := int(data[i])
+		i++
+
+		if size == 0 {
+			return i, nil
+		}
+
+		i += size
+	}
+}
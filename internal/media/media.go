@@ -0,0 +1,192 @@
+// Package media validates the images uploaded by the users and prepares them
+// for storage: they are re-encoded, which drops their metadata such as the EXIF
+// location, and a thumbnail is generated.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// ThumbnailSize is the largest side of the thumbnails
+	ThumbnailSize = 320
+	// MaxPixels guards against decompression bombs, images are decoded in memory
+	MaxPixels = 40_000_000
+	// MaxFrames caps the frames of the animations, every frame is decoded
+	MaxFrames = 500
+
+	jpegQuality = 85
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type, expected jpeg, png or gif")
+	ErrTooLarge        = errors.New("image dimensions are too large")
+	ErrTooManyFrames   = errors.New("animation has too many frames")
+)
+
+// formats maps the sniffed content types to the name of their decoder and
+// their file extension.
+var formats = map[string]struct {
+	name string
+	ext  string
+}{
+	"image/jpeg": {"jpeg", ".jpg"},
+	"image/png":  {"png", ".png"},
+	"image/gif":  {"gif", ".gif"},
+}
+
+type Image struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+type Processed struct {
+	Original  Image
+	Thumbnail Image
+}
+
+// Process checks that data is a supported image, whatever the client claimed,
+// and returns it re-encoded along with its thumbnail. JPEG images are turned
+// according to their EXIF orientation first since the metadata is dropped.
+func Process(data []byte) (*Processed, error) {
+	contentType := http.DetectContentType(data)
+
+	format, ok := formats[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || name != format.name {
+		return nil, ErrUnsupportedType
+	}
+
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var img image.Image
+	var buf bytes.Buffer
+
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		img = orient(img, jpegOrientation(data))
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		err = png.Encode(&buf, img)
+	case "image/gif":
+		//* The frames are counted before decoding, together they are bound like a single image
+		frames, pixels, scanErr := gifFrames(data)
+		if scanErr != nil {
+			return nil, ErrUnsupportedType
+		}
+
+		if frames > MaxFrames {
+			return nil, ErrTooManyFrames
+		}
+
+		if pixels > MaxPixels {
+			return nil, ErrTooLarge
+		}
+
+		var g *gif.GIF
+		g, err = gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		//? Animations are kept, the thumbnail is the first frame
+		img = firstFrame(g)
+		err = gif.EncodeAll(&buf, g)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+
+	thumbnail, err := makeThumbnail(img, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Processed{
+		Original: Image{
+			Data:        buf.Bytes(),
+			ContentType: contentType,
+			Ext:         format.ext,
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+		},
+		Thumbnail: *thumbnail,
+	}, nil
+}
+
+// makeThumbnail scales the image down to fit ThumbnailSize, keeping its
+// ratio. Photos stay JPEG, everything else is a PNG to keep transparency.
+func makeThumbnail(img image.Image, contentType string) (*Image, error) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	if w > ThumbnailSize || h > ThumbnailSize {
+		if w >= h {
+			w, h = ThumbnailSize, max(1, h*ThumbnailSize/w)
+		} else {
+			w, h = max(1, w*ThumbnailSize/h), ThumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	thumbnail := &Image{Width: w, Height: h}
+
+	if contentType == "image/jpeg" {
+		thumbnail.ContentType, thumbnail.Ext = "image/jpeg", ".jpg"
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+	} else {
+		thumbnail.ContentType, thumbnail.Ext = "image/png", ".png"
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, err
+		}
+	}
+
+	thumbnail.Data = buf.Bytes()
+	return thumbnail, nil
+}
+
+// firstFrame draws the first frame of the animation on the full canvas, a
+// frame may only cover part of it.
+func firstFrame(g *gif.GIF) image.Image {
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	if len(g.Image) > 0 {
+		draw.Draw(canvas, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Over)
+	}
+
+	return canvas
+}
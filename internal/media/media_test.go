@@ -0,0 +1,215 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// withExif inserts an APP1 segment holding the orientation and a fake GPS
+// entry right after the SOI marker of a JPEG image.
+func withExif(t *testing.T, data []byte, orientation uint16) []byte {
+	t.Helper()
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(2))
+	// Orientation, SHORT, 1 value
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	// GPS IFD pointer, LONG, 1 value
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x8825, 4})
+	binary.Write(&tiff, binary.BigEndian, []uint32{1, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("GPS 48.8584N 2.2945E")
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(data[2:])
+
+	return out.Bytes()
+}
+
+func TestProcessJPEG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	// The top left corner is red, it ends up top right once turned
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data := withExif(t, buf.Bytes(), 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatal("expected the test image to carry its orientation")
+	}
+
+	p, err := Process(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Original.Width != 400 || p.Original.Height != 800 {
+		t.Errorf("expected a 400x800 image once turned, got %dx%d", p.Original.Width, p.Original.Height)
+	}
+
+	if bytes.Contains(p.Original.Data, []byte("Exif")) || bytes.Contains(p.Original.Data, []byte("GPS")) {
+		t.Error("expected the metadata to be stripped")
+	}
+
+	decoded, err := jpeg.Decode(bytes.NewReader(p.Original.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r, g, _, _ := decoded.At(350, 50).RGBA(); r>>8 < 200 || g>>8 > 60 {
+		t.Errorf("expected the top right corner to be red, got %v", decoded.At(350, 50))
+	}
+
+	if p.Thumbnail.Width != 160 || p.Thumbnail.Height != ThumbnailSize || p.Thumbnail.ContentType != "image/jpeg" {
+		t.Errorf("expected a 160x320 jpeg thumbnail, got %dx%d %s", p.Thumbnail.Width, p.Thumbnail.Height, p.Thumbnail.ContentType)
+	}
+}
+
+func TestProcessGIF(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 40, 20), palette),
+			image.NewPaletted(image.Rect(0, 0, 40, 20), palette),
+		},
+		Delay: []int{10, 10},
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := gif.DecodeAll(bytes.NewReader(p.Original.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Image) != 2 {
+		t.Errorf("expected the animation to be kept, got %d frames", len(out.Image))
+	}
+
+	// Small images aren't scaled up
+	if p.Thumbnail.Width != 40 || p.Thumbnail.Height != 20 || p.Thumbnail.ContentType != "image/png" {
+		t.Errorf("expected a 40x20 png thumbnail, got %dx%d %s", p.Thumbnail.Width, p.Thumbnail.Height, p.Thumbnail.ContentType)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	if _, err := Process([]byte("<html><body>not an image</body></html>")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected html to be rejected, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Claims to be 100000x100000 in its header, it must not be decoded
+	bomb := buf.Bytes()
+	binary.BigEndian.PutUint32(bomb[16:20], 100000)
+	binary.BigEndian.PutUint32(bomb[20:24], 100000)
+	binary.BigEndian.PutUint32(bomb[29:33], crc32.ChecksumIEEE(bomb[12:29]))
+
+	if _, err := Process(bomb); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected the image to be too large, got %v", err)
+	}
+}
+
+func TestProcessGIFLimits(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+
+	encode := func(t *testing.T, frames int, size int) []byte {
+		t.Helper()
+
+		g := &gif.GIF{}
+		for i := 0; i < frames; i++ {
+			g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, size, size), palette))
+			g.Delay = append(g.Delay, 10)
+		}
+
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, g); err != nil {
+			t.Fatal(err)
+		}
+
+		return buf.Bytes()
+	}
+
+	t.Run("should count the frames and their pixels", func(t *testing.T) {
+		frames, pixels, err := gifFrames(encode(t, 3, 10))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if frames != 3 || pixels != 300 {
+			t.Errorf("expected 3 frames of 300 pixels, got %d frames of %d pixels", frames, pixels)
+		}
+	})
+
+	t.Run("should reject animations with too many frames", func(t *testing.T) {
+		if _, err := Process(encode(t, MaxFrames+1, 1)); !errors.Is(err, ErrTooManyFrames) {
+			t.Errorf("expected too many frames, got %v", err)
+		}
+	})
+
+	t.Run("should reject frames adding up to too many pixels", func(t *testing.T) {
+		// Each frame fits, together they don't
+		data := encode(t, 1, 100)
+		frame := bytes.Index(data, []byte{0x2C, 0, 0, 0, 0, 100, 0, 100, 0})
+		if frame < 0 {
+			t.Fatal("image descriptor not found")
+		}
+
+		// Repeat the frame, with a canvas of 5000x5000
+		end := len(data) - 1
+		var bomb bytes.Buffer
+		bomb.Write(data[:frame])
+		for i := 0; i < 3; i++ {
+			descriptor := append([]byte{}, data[frame:end]...)
+			binary.LittleEndian.PutUint16(descriptor[5:7], 5000)
+			binary.LittleEndian.PutUint16(descriptor[7:9], 5000)
+			bomb.Write(descriptor)
+		}
+		bomb.WriteByte(0x3B)
+
+		raw := bomb.Bytes()
+		binary.LittleEndian.PutUint16(raw[6:8], 5000)
+		binary.LittleEndian.PutUint16(raw[8:10], 5000)
+
+		if _, err := Process(raw); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("expected the frames to be too large, got %v", err)
+		}
+	})
+}
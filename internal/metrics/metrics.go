@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "social"
+
+// Metrics are the Prometheus metrics of the API, kept in their own registry so
+// every application, and every test, starts from zero.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	cacheLookups *prometheus.CounterVec
+	rateLimited  *prometheus.CounterVec
+	mails        *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Requests served, by route pattern and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve the requests, by route pattern and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Lookups of the Redis cache, by cache and result (hit, miss or error).",
+		}, []string{"cache", "result"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_rejections_total",
+			Help:      "Requests rejected by the rate limiter, by policy.",
+		}, []string{"policy"}),
+		mails: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mails_total",
+			Help:      "Mail deliveries, by template and outcome (sent, retried or dead).",
+		}, []string{"template", "outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.cacheLookups,
+		m.rateLimited,
+		m.mails,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exports the stats of the connection pool of db.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterGauge exports the value returned by value on every scrape.
+func (m *Metrics) RegisterGauge(name, help string, value func() float64) error {
+	return m.registry.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, value))
+}
+
+// ObserveRequest records a served request, route is the pattern matched so the
+// IDs in the paths don't each get their own series.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	labels := prometheus.Labels{
+		"method": method,
+		"route":  route,
+		"status": strconv.Itoa(status),
+	}
+
+	m.requests.With(labels).Inc()
+	m.duration.With(labels).Observe(duration.Seconds())
+}
+
+func (m *Metrics) CacheLookup(cache, result string) {
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+func (m *Metrics) RateLimited(policy string) {
+	m.rateLimited.WithLabelValues(policy).Inc()
+}
+
+func (m *Metrics) Mail(template, outcome string) {
+	m.mails.WithLabelValues(template, outcome).Inc()
+}
@@ -1,20 +1,73 @@
 package models
 
 import (
+	"encoding/json"
+	"time"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Status of posts, comments and messages after going through the content
+// filter. Held content is published or rejected by the moderators.
+const (
+	StatusPublished     = "published"
+	StatusPendingReview = "pending_review"
+	StatusRejected      = "rejected"
+)
+
+// Visibility levels of a post
+const (
+	VisibilityPublic    = "public"    // listed everywhere
+	VisibilityFollowers = "followers" // only visible to the author's followers
+	VisibilityUnlisted  = "unlisted"  // reachable by ID but never listed
+)
+
 type Post struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt string    `json:"created_at"`
-	UpdatedAt string    `json:"updated_at"`
-	Version   int       `json:"version"`
-	Tags      []string  `json:"tags"`
-	Comments  []Comment `json:"comments"`
-	User      User      `json:"user"`
+	ID           int64         `json:"id"`
+	UserID       int64         `json:"user_id"`
+	Title        string        `json:"title"`
+	Content      string        `json:"content"`
+	CreatedAt    string        `json:"created_at"`
+	UpdatedAt    string        `json:"updated_at"`
+	Version      int           `json:"version"`
+	Status       string        `json:"status"`
+	Visibility   string        `json:"visibility"`
+	Tags         []string      `json:"tags"`
+	Attachments  []Attachment  `json:"attachments"`
+	LinkPreviews []LinkPreview `json:"link_previews"`
+	Comments     []Comment     `json:"comments"`
+	User         User          `json:"user"`
+}
+
+// Attachment is an image attached to a post. The blob keys stay internal,
+// clients use the public URLs.
+type Attachment struct {
+	ID           int64  `json:"id"`
+	PostID       int64  `json:"post_id"`
+	Key          string `json:"-"`
+	ThumbnailKey string `json:"-"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	AltText      string `json:"alt_text"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// LinkPreview is the card shown for a link shared in a post, as described by
+// the OpenGraph metadata of the page.
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+	SiteName    string `json:"site_name"`
+	// Failed fetches are kept too so the page isn't requested again until the
+	// preview expires
+	Failed    bool      `json:"-"`
+	FetchedAt time.Time `json:"-"`
 }
 
 type PostWithMetadata struct {
@@ -27,6 +80,7 @@ type Comment struct {
 	PostID    int64  `json:"post_id"`
 	UserID    int64  `json:"user_id"`
 	Content   string `json:"content"`
+	Status    string `json:"status"`
 	CreatedAt string `json:"created_at"`
 	User      User   `json:"user"`
 }
@@ -37,6 +91,14 @@ type Follower struct {
 	CreatedAt  string `json:"created_at"`
 }
 
+// FollowRequest is a pending follow of a private account
+type FollowRequest struct {
+	UserID    int64  `json:"user_id"`
+	TargetID  int64  `json:"target_id"`
+	CreatedAt string `json:"created_at"`
+	User      User   `json:"user"`
+}
+
 // Not returning the password in the json responses
 type User struct {
 	ID        int64    `json:"id"`
@@ -44,9 +106,15 @@ type User struct {
 	Email     string   `json:"email"`
 	Password  password `json:"-"`
 	IsActive  bool     `json:"is_active"`
+	IsPrivate bool     `json:"is_private"`
+	Language  string   `json:"language"`
 	CreatedAt string   `json:"created_at"`
 	RoleID    int64    `json:"role_id"`
 	Role      Role     `json:"role"`
+
+	// Active suspension of the user, if any. Only shown to the user and to
+	// moderators, the handlers add it to the responses.
+	Suspension *Suspension `json:"-"`
 }
 
 type Role struct {
@@ -56,6 +124,167 @@ type Role struct {
 	Description string `json:"description"`
 }
 
+type Suspension struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	ModeratorID int64      `json:"moderator_id"`
+	Reason      string     `json:"reason"`
+	ExpiresAt   *time.Time `json:"expires_at"` // nil for a permanent ban
+	CreatedAt   string     `json:"created_at"`
+}
+
+// IsActive reports whether the suspension still applies. Expired suspensions
+// lift on their own, even when the user was served from the cache.
+func (s *Suspension) IsActive() bool {
+	return s != nil && (s.ExpiresAt == nil || s.ExpiresAt.After(time.Now()))
+}
+
+// Kinds of activity users can be notified about
+const (
+	NotifyNewFollower = "new_follower"
+	NotifyComment     = "comment"
+	NotifyMention     = "mention"
+
+	// In-app only, there is no email for follow requests
+	NotifyFollowRequest = "follow_request"
+)
+
+// How a kind of activity is delivered by email
+const (
+	DeliveryInstant = "instant"
+	DeliveryDaily   = "daily" // grouped in the daily digest
+	DeliveryOff     = "off"
+)
+
+type NotificationPreferences struct {
+	UserID      int64  `json:"user_id"`
+	NewFollower string `json:"new_follower"`
+	Comment     string `json:"comment"`
+	Mention     string `json:"mention"`
+}
+
+// DefaultNotificationPreferences are used for users who never changed them.
+func DefaultNotificationPreferences(userID int64) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:      userID,
+		NewFollower: DeliveryInstant,
+		Comment:     DeliveryInstant,
+		Mention:     DeliveryInstant,
+	}
+}
+
+// Delivery returns how the given kind of activity is delivered.
+func (p *NotificationPreferences) Delivery(kind string) string {
+	switch kind {
+	case NotifyNewFollower:
+		return p.NewFollower
+	case NotifyComment:
+		return p.Comment
+	case NotifyMention:
+		return p.Mention
+	default:
+		return DeliveryOff
+	}
+}
+
+// Notification is shown in the app. Events of the same kind about the same
+// subject are grouped while unread, e.g. "gopher and 4 others commented on
+// your post".
+type Notification struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Kind       string     `json:"kind"`
+	GroupKey   string     `json:"-"`
+	Actor      User       `json:"actor"` // the latest actor
+	ActorCount int        `json:"actor_count"`
+	PostID     *int64     `json:"post_id,omitempty"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Conversation is a private discussion between two users, or a small group
+// when IsGroup is set.
+type Conversation struct {
+	ID           int64         `json:"id"`
+	CreatorID    int64         `json:"creator_id"`
+	Title        string        `json:"title"`
+	IsGroup      bool          `json:"is_group"`
+	Participants []Participant `json:"participants"`
+	LastMessage  *Message      `json:"last_message,omitempty"`
+	UnreadCount  int           `json:"unread_count"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"` // time of the last message
+}
+
+// Participant of a conversation, the last read message is its read receipt.
+type Participant struct {
+	User              User       `json:"user"`
+	LastReadMessageID *int64     `json:"last_read_message_id"`
+	LastReadAt        *time.Time `json:"last_read_at"`
+	JoinedAt          time.Time  `json:"joined_at"`
+}
+
+type Message struct {
+	ID             int64     `json:"id"`
+	ConversationID int64     `json:"conversation_id"`
+	UserID         int64     `json:"user_id"`
+	Content        string    `json:"content"`
+	Status         string    `json:"status"`
+	User           User      `json:"user"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Activity is something that happened around a user, as listed in the
+// digest emails.
+type Activity struct {
+	Kind      string `json:"kind"`
+	Actor     User   `json:"actor"`
+	PostID    int64  `json:"post_id,omitempty"`
+	PostTitle string `json:"post_title,omitempty"`
+	Content   string `json:"content,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// DigestRecipient is a user due for a digest email, along with the start of
+// the period it covers.
+type DigestRecipient struct {
+	User         User
+	Preferences  NotificationPreferences
+	Since        time.Time
+	LastDigestAt *time.Time // nil before the first digest
+}
+
+// TrendingTag is a hashtag ranked by the number of users who used it over a
+// period of time.
+type TrendingTag struct {
+	Tag   string `json:"tag"`
+	Users int    `json:"users"`
+	Uses  int    `json:"uses"`
+}
+
+// Delivery status of an email in the outbox
+const (
+	MailPending = "pending"
+	MailSending = "sending"
+	MailSent    = "sent"
+	MailDead    = "dead"
+)
+
+// OutboxMail is an email waiting to be delivered by the mail workers
+type OutboxMail struct {
+	ID        int64           `json:"id"`
+	Template  string          `json:"template"`
+	Username  string          `json:"username"`
+	Email     string          `json:"email"`
+	Locale    string          `json:"locale"`
+	Data      json.RawMessage `json:"data"`
+	Status    string          `json:"status"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	CreatedAt string          `json:"created_at"`
+}
+
 type password struct {
 	Text *string
 	Hash []byte
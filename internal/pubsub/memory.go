@@ -0,0 +1,174 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// InProcess delivers messages within a single API instance.
+type InProcess struct {
+	sync.RWMutex
+	topics map[string]map[*inProcessSubscription]struct{}
+	closed bool
+}
+
+func NewInProcess() *InProcess {
+	return &InProcess{
+		topics: map[string]map[*inProcessSubscription]struct{}{},
+	}
+}
+
+func (ps *InProcess) Publish(ctx context.Context, topic string, payload []byte) error {
+	ps.RLock()
+	defer ps.RUnlock()
+
+	if ps.closed {
+		return ErrClosed
+	}
+
+	for sub := range ps.topics[topic] {
+		sub.deliver(Message{Topic: topic, Payload: payload})
+	}
+
+	return nil
+}
+
+func (ps *InProcess) Subscribe(ctx context.Context, topics ...string) (Subscription, error) {
+	sub := &inProcessSubscription{
+		ps:       ps,
+		messages: make(chan Message, bufferSize),
+		topics:   map[string]struct{}{},
+	}
+
+	if err := sub.Subscribe(ctx, topics...); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Close ends every subscription.
+func (ps *InProcess) Close() error {
+	ps.Lock()
+	subs := map[*inProcessSubscription]struct{}{}
+	for _, topicSubs := range ps.topics {
+		for sub := range topicSubs {
+			subs[sub] = struct{}{}
+		}
+	}
+	ps.closed = true
+	ps.Unlock()
+
+	for sub := range subs {
+		sub.Close()
+	}
+
+	return nil
+}
+
+func (ps *InProcess) add(sub *inProcessSubscription, topics []string) error {
+	ps.Lock()
+	defer ps.Unlock()
+
+	if ps.closed {
+		return ErrClosed
+	}
+
+	for _, topic := range topics {
+		if ps.topics[topic] == nil {
+			ps.topics[topic] = map[*inProcessSubscription]struct{}{}
+		}
+		ps.topics[topic][sub] = struct{}{}
+	}
+
+	return nil
+}
+
+func (ps *InProcess) remove(sub *inProcessSubscription, topics []string) {
+	ps.Lock()
+	defer ps.Unlock()
+
+	for _, topic := range topics {
+		delete(ps.topics[topic], sub)
+
+		if len(ps.topics[topic]) == 0 {
+			delete(ps.topics, topic)
+		}
+	}
+}
+
+type inProcessSubscription struct {
+	sync.Mutex
+	ps       *InProcess
+	messages chan Message
+	topics   map[string]struct{}
+	closed   bool
+}
+
+func (s *inProcessSubscription) Messages() <-chan Message {
+	return s.messages
+}
+
+func (s *inProcessSubscription) Subscribe(ctx context.Context, topics ...string) error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return ErrClosed
+	}
+	for _, topic := range topics {
+		s.topics[topic] = struct{}{}
+	}
+	s.Unlock()
+
+	return s.ps.add(s, topics)
+}
+
+func (s *inProcessSubscription) Unsubscribe(ctx context.Context, topics ...string) error {
+	s.Lock()
+	for _, topic := range topics {
+		delete(s.topics, topic)
+	}
+	s.Unlock()
+
+	s.ps.remove(s, topics)
+	return nil
+}
+
+func (s *inProcessSubscription) Close() error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return nil
+	}
+
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	s.Unlock()
+
+	//? Stop the deliveries before closing the channel
+	s.ps.remove(s, topics)
+
+	s.Lock()
+	s.closed = true
+	close(s.messages)
+	s.Unlock()
+
+	return nil
+}
+
+func (s *inProcessSubscription) deliver(msg Message) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.messages <- msg:
+	default:
+		// The subscriber is too slow, drop the message
+	}
+}
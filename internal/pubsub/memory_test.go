@@ -0,0 +1,75 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInProcess(t *testing.T) {
+	ctx := context.Background()
+	ps := NewInProcess()
+
+	sub, err := ps.Subscribe(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receive := func(t *testing.T) (Message, bool) {
+		t.Helper()
+
+		select {
+		case msg, ok := <-sub.Messages():
+			return msg, ok
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a message")
+			return Message{}, false
+		}
+	}
+
+	t.Run("should deliver messages of subscribed topics only", func(t *testing.T) {
+		ps.Publish(ctx, "b", []byte("ignored"))
+		ps.Publish(ctx, "a", []byte("hello"))
+
+		msg, _ := receive(t)
+		if msg.Topic != "a" || string(msg.Payload) != "hello" {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	})
+
+	t.Run("should follow topic changes", func(t *testing.T) {
+		sub.Subscribe(ctx, "b")
+		sub.Unsubscribe(ctx, "a")
+
+		ps.Publish(ctx, "a", []byte("ignored"))
+		ps.Publish(ctx, "b", []byte("hello"))
+
+		msg, _ := receive(t)
+		if msg.Topic != "b" {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	})
+
+	t.Run("should drop messages for slow subscribers", func(t *testing.T) {
+		for i := 0; i < bufferSize*2; i++ {
+			if err := ps.Publish(ctx, "b", []byte("x")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if n := len(sub.Messages()); n != bufferSize {
+			t.Errorf("expected %d buffered messages, got %d", bufferSize, n)
+		}
+	})
+
+	t.Run("should close the channel on close", func(t *testing.T) {
+		sub.Close()
+
+		for range sub.Messages() {
+		}
+
+		if err := sub.Subscribe(ctx, "c"); err != ErrClosed {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+)
+
+// bufferSize is the number of messages kept for a slow subscriber, messages
+// are dropped once it is full so a stuck client can't block publishers.
+const bufferSize = 64
+
+var ErrClosed = errors.New("pubsub: subscription closed")
+
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+// PubSub broadcasts messages to every subscriber of a topic. Delivery is best
+// effort, subscribers only receive the messages published while subscribed.
+type PubSub interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	Subscribe(ctx context.Context, topics ...string) (Subscription, error)
+	Close() error
+}
+
+type Subscription interface {
+	// Messages is closed once the subscription is closed
+	Messages() <-chan Message
+	Subscribe(ctx context.Context, topics ...string) error
+	Unsubscribe(ctx context.Context, topics ...string) error
+	Close() error
+}
@@ -0,0 +1,244 @@
+package pubsub
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// channelPrefix namespaces the Redis channels used for the events.
+const channelPrefix = "gosocial:events:"
+
+// Redis delivers messages through Redis pub/sub so they reach the subscribers
+// of every API instance. The instance holds a single Redis subscription, to
+// the topics of all its subscribers, and fans the messages out in memory.
+type Redis struct {
+	sync.Mutex
+	rdb    *redis.Client
+	pubsub *redis.PubSub
+	local  *InProcess
+	// Subscribers of each topic, the Redis channel is left with the last one
+	refs   map[string]int
+	closed bool
+}
+
+func NewRedis(rdb *redis.Client) *Redis {
+	ps := &Redis{
+		rdb:    rdb,
+		pubsub: rdb.Subscribe(context.Background()),
+		local:  NewInProcess(),
+		refs:   map[string]int{},
+	}
+
+	go ps.forward()
+
+	return ps
+}
+
+func (ps *Redis) Publish(ctx context.Context, topic string, payload []byte) error {
+	return ps.rdb.Publish(ctx, channelPrefix+topic, payload).Err()
+}
+
+func (ps *Redis) Subscribe(ctx context.Context, topics ...string) (Subscription, error) {
+	local, err := ps.local.Subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &redisSubscription{
+		ps:     ps,
+		local:  local,
+		topics: map[string]struct{}{},
+	}
+
+	if err := sub.Subscribe(ctx, topics...); err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Close ends every subscription, the Redis client is owned by the caller and
+// is left open.
+func (ps *Redis) Close() error {
+	ps.Lock()
+	if ps.closed {
+		ps.Unlock()
+		return nil
+	}
+	ps.closed = true
+	ps.Unlock()
+
+	ps.local.Close()
+	return ps.pubsub.Close()
+}
+
+// acquire subscribes the instance to the Redis channels of the topics nobody
+// subscribed to yet.
+func (ps *Redis) acquire(ctx context.Context, topics []string) error {
+	ps.Lock()
+	defer ps.Unlock()
+
+	if ps.closed {
+		return ErrClosed
+	}
+
+	added := []string{}
+	for _, topic := range topics {
+		if ps.refs[topic] == 0 {
+			added = append(added, topic)
+		}
+		ps.refs[topic]++
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	//? Messages published before Redis confirms the subscription are missed, delivery is best effort
+	if err := ps.pubsub.Subscribe(ctx, channels(added)...); err != nil {
+		for _, topic := range topics {
+			ps.unref(topic)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// release unsubscribes the instance from the Redis channels of the topics
+// without subscribers left.
+func (ps *Redis) release(ctx context.Context, topics []string) error {
+	ps.Lock()
+	defer ps.Unlock()
+
+	removed := []string{}
+	for _, topic := range topics {
+		if ps.unref(topic) {
+			removed = append(removed, topic)
+		}
+	}
+
+	if len(removed) == 0 || ps.closed {
+		return nil
+	}
+
+	return ps.pubsub.Unsubscribe(ctx, channels(removed)...)
+}
+
+// unref drops a subscriber of the topic, it reports whether it was the last.
+func (ps *Redis) unref(topic string) bool {
+	ps.refs[topic]--
+	if ps.refs[topic] > 0 {
+		return false
+	}
+
+	delete(ps.refs, topic)
+	return true
+}
+
+// forward hands the messages of the Redis subscription to the local
+// subscribers until the subscription is closed.
+func (ps *Redis) forward() {
+	for msg := range ps.pubsub.Channel() {
+		ps.local.Publish(context.Background(), strings.TrimPrefix(msg.Channel, channelPrefix), []byte(msg.Payload))
+	}
+}
+
+type redisSubscription struct {
+	sync.Mutex
+	ps     *Redis
+	local  Subscription
+	topics map[string]struct{}
+	closed bool
+}
+
+func (s *redisSubscription) Messages() <-chan Message {
+	return s.local.Messages()
+}
+
+func (s *redisSubscription) Subscribe(ctx context.Context, topics ...string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	added := []string{}
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; !ok {
+			added = append(added, topic)
+		}
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	if err := s.local.Subscribe(ctx, added...); err != nil {
+		return err
+	}
+
+	if err := s.ps.acquire(ctx, added); err != nil {
+		s.local.Unsubscribe(ctx, added...)
+		return err
+	}
+
+	for _, topic := range added {
+		s.topics[topic] = struct{}{}
+	}
+
+	return nil
+}
+
+func (s *redisSubscription) Unsubscribe(ctx context.Context, topics ...string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	removed := []string{}
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; ok {
+			removed = append(removed, topic)
+			delete(s.topics, topic)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	s.local.Unsubscribe(ctx, removed...)
+	return s.ps.release(ctx, removed)
+}
+
+func (s *redisSubscription) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	s.topics = nil
+
+	s.local.Close()
+	return s.ps.release(context.Background(), topics)
+}
+
+func channels(topics []string) []string {
+	prefixed := make([]string, len(topics))
+	for i, topic := range topics {
+		prefixed[i] = channelPrefix + topic
+	}
+
+	return prefixed
+}
@@ -0,0 +1,115 @@
+package pubsub
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedis(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is not set")
+	}
+
+	ctx := context.Background()
+
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		t.Skipf("redis is unavailable: %v", err)
+	}
+
+	ps := NewRedis(rdb)
+	defer ps.Close()
+
+	first, err := ps.Subscribe(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := ps.Subscribe(ctx, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receive := func(t *testing.T, sub Subscription) Message {
+		t.Helper()
+
+		select {
+		case msg := <-sub.Messages():
+			return msg
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a message")
+			return Message{}
+		}
+	}
+
+	// publish waits for Redis to confirm the subscriptions, they are sent without waiting
+	publish := func(t *testing.T, topic string, payload string) {
+		t.Helper()
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			n, err := rdb.Publish(ctx, channelPrefix+topic, payload).Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n > 0 || time.Now().After(deadline) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	t.Run("should share a single Redis subscription", func(t *testing.T) {
+		publish(t, "a", "hello")
+
+		for _, sub := range []Subscription{first, second} {
+			if msg := receive(t, sub); msg.Topic != "a" || string(msg.Payload) != "hello" {
+				t.Errorf("unexpected message %+v", msg)
+			}
+		}
+
+		channels, err := rdb.PubSubNumSub(ctx, channelPrefix+"a").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if channels[channelPrefix+"a"] != 1 {
+			t.Errorf("expected a single Redis subscriber, got %d", channels[channelPrefix+"a"])
+		}
+	})
+
+	t.Run("should keep the channel while a subscriber is left", func(t *testing.T) {
+		first.Close()
+
+		publish(t, "a", "still there")
+
+		if msg := receive(t, second); string(msg.Payload) != "still there" {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	})
+
+	t.Run("should leave the channel with the last subscriber", func(t *testing.T) {
+		second.Unsubscribe(ctx, "a")
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			channels, err := rdb.PubSubNumSub(ctx, channelPrefix+"a").Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if channels[channelPrefix+"a"] == 0 {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the Redis channel to be left, got %d subscribers", channels[channelPrefix+"a"])
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
@@ -5,43 +5,66 @@ import (
 	"time"
 )
 
+type fixedWindow struct {
+	start  time.Time
+	window time.Duration
+	count  int
+}
+
 type FixedWindowRateLimiter struct {
-	sync.RWMutex
-	clients map[string]int
-	limit   int
-	window  time.Duration
+	sync.Mutex
+	*janitor
+	clients map[string]*fixedWindow
+	now     Clock
 }
 
-func NewFixedWindowLimiter(limit int, window time.Duration) *FixedWindowRateLimiter {
-	return &FixedWindowRateLimiter{
-		clients: make(map[string]int),
-		limit:   limit,
-		window:  window,
+func NewFixedWindowLimiter(opts ...Option) *FixedWindowRateLimiter {
+	o := newOptions(opts)
+
+	rl := &FixedWindowRateLimiter{
+		clients: make(map[string]*fixedWindow),
+		now:     o.clock,
 	}
+	rl.janitor = startJanitor(o.janitorInterval, rl.sweep)
+
+	return rl
 }
 
-func (rl *FixedWindowRateLimiter) Allow(ip string) (bool, time.Duration) {
-	rl.RLock()
-	count, exists := rl.clients[ip]
-	rl.RUnlock()
+func (rl *FixedWindowRateLimiter) Allow(key string, quota Quota) Result {
+	now := rl.now()
 
-	if !exists || count < rl.limit {
-		rl.Lock()
-		if !exists {
-			go rl.resetCount(ip)
-		}
+	rl.Lock()
+	defer rl.Unlock()
 
-		rl.clients[ip]++
-		rl.Unlock()
-		return true, 0
+	w, exists := rl.clients[key]
+	if !exists || now.Sub(w.start) >= w.window {
+		w = &fixedWindow{start: now, window: quota.Window}
+		rl.clients[key] = w
 	}
 
-	return false, rl.window
+	res := Result{Limit: quota.Limit, Reset: w.start.Add(w.window).Sub(now)}
+
+	if w.count < quota.Limit {
+		w.count++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = res.Reset
+	}
+	res.Remaining = max(quota.Limit-w.count, 0)
+
+	return res
 }
 
-func (rl *FixedWindowRateLimiter) resetCount(ip string) {
-	time.Sleep(rl.window)
+// sweep forgets the clients whose window is over.
+func (rl *FixedWindowRateLimiter) sweep() {
+	now := rl.now()
+
 	rl.Lock()
-	delete(rl.clients, ip)
-	rl.Unlock()
+	defer rl.Unlock()
+
+	for key, w := range rl.clients {
+		if now.Sub(w.start) >= w.window {
+			delete(rl.clients, key)
+		}
+	}
 }
@@ -1,13 +1,202 @@
 package ratelimiter
 
-import "time"
+import (
+	"fmt"
+	"sync"
+	"time"
+)
 
 type Limiter interface {
-	Allow(ip string) (bool, time.Duration)
+	// Allow counts a request of key against the quota.
+	Allow(key string, quota Quota) Result
 }
 
+// Quota is the number of requests allowed per window. A limiter is shared by
+// quotas of different sizes, each key should always be given the same one.
+type Quota struct {
+	Limit  int
+	Window time.Duration
+	// Burst is the size of the token bucket, Limit when not set
+	Burst int
+}
+
+func (q Quota) burst() int {
+	if q.Burst > 0 {
+		return q.Burst
+	}
+	return q.Limit
+}
+
+// Scale multiplies the quota, for the clients allowed more requests.
+func (q Quota) Scale(factor float64) Quota {
+	q.Limit = int(float64(q.Limit) * factor)
+	q.Burst = int(float64(q.Burst) * factor)
+	return q
+}
+
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining requests once this one is counted
+	Remaining int
+	// Reset is how long until the whole quota is available again
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed when denied
+	RetryAfter time.Duration
+}
+
+// Strategies of the limiters
+const (
+	// StrategyFixedWindow counts the requests per fixed window. Cheap, but a
+	// client can send twice the limit around the end of a window.
+	StrategyFixedWindow = "fixed_window"
+	// StrategySlidingWindowLog keeps the time of every request in the window.
+	// Exact, but memory grows with the limit.
+	StrategySlidingWindowLog = "sliding_window_log"
+	// StrategySlidingWindow weighs the count of the previous window by how
+	// much of it still overlaps the sliding window.
+	StrategySlidingWindow = "sliding_window"
+	// StrategyTokenBucket refills the bucket of each client continuously and
+	// allows bursts up to its size.
+	StrategyTokenBucket = "token_bucket"
+)
+
 type Config struct {
 	RequestsPerTimeFrame int
 	TimeFrame            time.Duration
 	Enabled              bool
+	// Strategy of the limiter, the fixed window when empty
+	Strategy string
+	// Burst is the size of the token bucket, RequestsPerTimeFrame when not set
+	Burst int
+	// Distributed shares the limit of every instance through Redis
+	Distributed bool
+	// Fallback of the distributed limiter while Redis is unavailable, allows
+	// every request when empty
+	Fallback string
+}
+
+// Quota is the default quota of the configuration.
+func (cfg Config) Quota() Quota {
+	return Quota{
+		Limit:  cfg.RequestsPerTimeFrame,
+		Window: cfg.TimeFrame,
+		Burst:  cfg.Burst,
+	}
+}
+
+// Clock returns the current time, tests provide their own to move it forward.
+type Clock func() time.Time
+
+type Option func(*options)
+
+type options struct {
+	clock           Clock
+	janitorInterval time.Duration
+	onError         func(error)
+	breaker         Breaker
+}
+
+// Breaker skips Redis while it's known to be down, it's told the outcome of
+// every call that reaches Redis.
+type Breaker interface {
+	Allow() bool
+	Success()
+	Failure()
+}
+
+// WithClock sets the clock of the local limiters. The distributed limiter
+// reads the clock of Redis, the option only applies to its fallback.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithJanitorInterval sets how often the idle clients are forgotten, once a
+// minute by default.
+func WithJanitorInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.janitorInterval = interval
+	}
+}
+
+// WithErrorHandler is called with the errors of Redis before the distributed
+// limiter falls back.
+func WithErrorHandler(onError func(error)) Option {
+	return func(o *options) {
+		o.onError = onError
+	}
+}
+
+// WithBreaker sends the requests straight to the fallback of the distributed
+// limiter while the breaker is open, so an outage of Redis adds neither a
+// timeout nor an error to every request.
+func WithBreaker(breaker Breaker) Option {
+	return func(o *options) {
+		o.breaker = breaker
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{clock: time.Now, janitorInterval: time.Minute}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.janitorInterval <= 0 {
+		o.janitorInterval = time.Minute
+	}
+
+	return o
+}
+
+// New returns the limiter of the configured strategy.
+func New(cfg Config, opts ...Option) (Limiter, error) {
+	switch cfg.Strategy {
+	case "", StrategyFixedWindow:
+		return NewFixedWindowLimiter(opts...), nil
+	case StrategySlidingWindowLog:
+		return NewSlidingWindowLogLimiter(opts...), nil
+	case StrategySlidingWindow:
+		return NewSlidingWindowLimiter(opts...), nil
+	case StrategyTokenBucket:
+		return NewTokenBucketLimiter(opts...), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limiter strategy %q", cfg.Strategy)
+	}
+}
+
+// janitor periodically forgets the clients that are idle, a single goroutine
+// per limiter instead of one per client.
+type janitor struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+func startJanitor(interval time.Duration, sweep func()) *janitor {
+	j := &janitor{stop: make(chan struct{})}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				sweep()
+			}
+		}
+	}()
+
+	return j
+}
+
+// Stop ends the janitor of the limiter.
+func (j *janitor) Stop() {
+	j.once.Do(func() {
+		close(j.stop)
+	})
 }
@@ -0,0 +1,225 @@
+package ratelimiter
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when told to.
+type fakeClock struct {
+	sync.Mutex
+	t time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.t = c.t.Add(d)
+}
+
+func allowN(t *testing.T, l Limiter, key string, quota Quota, n int) Result {
+	t.Helper()
+
+	var res Result
+	for i := 0; i < n; i++ {
+		if res = l.Allow(key, quota); !res.Allowed {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	return res
+}
+
+func expectDenied(t *testing.T, l Limiter, key string, quota Quota, retryAfter time.Duration) {
+	t.Helper()
+
+	res := l.Allow(key, quota)
+	if res.Allowed {
+		t.Fatal("expected the request to be denied")
+	}
+
+	if res.RetryAfter != retryAfter {
+		t.Errorf("expected to retry after %s, got %s", retryAfter, res.RetryAfter)
+	}
+
+	if res.Remaining != 0 {
+		t.Errorf("expected no remaining requests, got %d", res.Remaining)
+	}
+}
+
+func expectRemaining(t *testing.T, res Result, remaining int, reset time.Duration) {
+	t.Helper()
+
+	if res.Remaining != remaining || res.Reset != reset {
+		t.Errorf("expected %d remaining and a reset in %s, got %d and %s", remaining, reset, res.Remaining, res.Reset)
+	}
+}
+
+func TestFixedWindow(t *testing.T) {
+	clock := newFakeClock()
+	l := NewFixedWindowLimiter(WithClock(clock.Now))
+	defer l.Stop()
+
+	quota := Quota{Limit: 3, Window: time.Minute}
+
+	expectRemaining(t, allowN(t, l, "a", quota, 2), 1, time.Minute)
+	allowN(t, l, "a", quota, 1)
+	allowN(t, l, "b", quota, 3)
+
+	clock.Advance(20 * time.Second)
+	expectDenied(t, l, "a", quota, 40*time.Second)
+
+	clock.Advance(40 * time.Second)
+	allowN(t, l, "a", quota, 3)
+
+	l.sweep()
+	if len(l.clients) != 1 {
+		t.Errorf("expected the idle client to be forgotten, %d clients left", len(l.clients))
+	}
+}
+
+func TestSlidingWindowLog(t *testing.T) {
+	clock := newFakeClock()
+	l := NewSlidingWindowLogLimiter(WithClock(clock.Now))
+	defer l.Stop()
+
+	quota := Quota{Limit: 3, Window: time.Minute}
+
+	allowN(t, l, "a", quota, 1)
+	clock.Advance(30 * time.Second)
+	expectRemaining(t, allowN(t, l, "a", quota, 1), 1, time.Minute)
+	allowN(t, l, "a", quota, 1)
+
+	clock.Advance(10 * time.Second)
+	expectDenied(t, l, "a", quota, 20*time.Second)
+
+	// Only the first request left the window
+	clock.Advance(20 * time.Second)
+	allowN(t, l, "a", quota, 1)
+	expectDenied(t, l, "a", quota, 30*time.Second)
+
+	clock.Advance(time.Minute)
+	l.sweep()
+	if len(l.clients) != 0 {
+		t.Errorf("expected the idle client to be forgotten, %d clients left", len(l.clients))
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := newFakeClock()
+	l := NewSlidingWindowLimiter(WithClock(clock.Now))
+	defer l.Stop()
+
+	quota := Quota{Limit: 4, Window: time.Minute}
+
+	// The fixed window would allow 4 more right after the boundary
+	clock.Advance(45 * time.Second)
+	expectRemaining(t, allowN(t, l, "a", quota, 4), 0, 75*time.Second)
+
+	clock.Advance(30 * time.Second)
+	// 4 * 45/60 = 3 still count, one request fits
+	allowN(t, l, "a", quota, 1)
+	// 4 * 45/60 + 1 = 4, room is made once the previous window weighs 2, at 30s
+	expectDenied(t, l, "a", quota, 15*time.Second)
+
+	clock.Advance(15 * time.Second)
+	allowN(t, l, "a", quota, 1)
+
+	clock.Advance(2 * time.Minute)
+	l.sweep()
+	if len(l.clients) != 0 {
+		t.Errorf("expected the idle client to be forgotten, %d clients left", len(l.clients))
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := newFakeClock()
+	l := NewTokenBucketLimiter(WithClock(clock.Now))
+	defer l.Stop()
+
+	// 6 tokens per minute, one every 10s, up to 3 at once
+	quota := Quota{Limit: 6, Window: time.Minute, Burst: 3}
+
+	expectRemaining(t, allowN(t, l, "a", quota, 1), 2, 10*time.Second)
+	allowN(t, l, "a", quota, 2)
+	expectDenied(t, l, "a", quota, 10*time.Second)
+
+	clock.Advance(5 * time.Second)
+	expectDenied(t, l, "a", quota, 5*time.Second)
+
+	clock.Advance(5 * time.Second)
+	allowN(t, l, "a", quota, 1)
+
+	// The bucket never holds more than the burst
+	clock.Advance(time.Hour)
+	allowN(t, l, "a", quota, 3)
+	expectDenied(t, l, "a", quota, 10*time.Second)
+
+	// A bigger quota takes effect right away
+	clock.Advance(10 * time.Second)
+	allowN(t, l, "a", quota.Scale(2), 1)
+
+	clock.Advance(30 * time.Second)
+	l.sweep()
+	if len(l.clients) != 0 {
+		t.Errorf("expected the full bucket to be forgotten, %d clients left", len(l.clients))
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, strategy := range []string{"", StrategyFixedWindow, StrategySlidingWindowLog, StrategySlidingWindow, StrategyTokenBucket} {
+		cfg := Config{RequestsPerTimeFrame: 2, TimeFrame: time.Minute, Strategy: strategy}
+
+		l, err := New(cfg)
+		if err != nil {
+			t.Fatalf("%q: %v", strategy, err)
+		}
+
+		allowN(t, l, "a", cfg.Quota(), 2)
+		if res := l.Allow("a", cfg.Quota()); res.Allowed {
+			t.Errorf("%q: expected the third request to be denied", strategy)
+		}
+
+		l.(interface{ Stop() }).Stop()
+	}
+
+	if _, err := New(Config{Strategy: "leaky"}); err == nil {
+		t.Error("expected an unknown strategy to be rejected")
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	clock := newFakeClock()
+	l := NewFixedWindowLimiter(WithClock(clock.Now), WithJanitorInterval(time.Millisecond))
+	defer l.Stop()
+
+	allowN(t, l, "a", Quota{Limit: 1, Window: time.Minute}, 1)
+	clock.Advance(time.Minute)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		l.Lock()
+		n := len(l.clients)
+		l.Unlock()
+
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Error("expected the janitor to forget the idle client")
+}
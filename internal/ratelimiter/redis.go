@@ -0,0 +1,303 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// keyPrefix namespaces the keys of the limiter in Redis.
+const keyPrefix = "gosocial:ratelimit:"
+
+// redisTimeout bounds a check, a slow Redis shouldn't slow down every request.
+const redisTimeout = 100 * time.Millisecond
+
+// Fallbacks of the Redis limiter while Redis is unavailable
+const (
+	// FallbackAllow lets every request through.
+	FallbackAllow = "allow"
+	// FallbackLocal limits the requests per instance with the limiter of the
+	// same strategy.
+	FallbackLocal = "local"
+)
+
+// The scripts return {allowed, remaining, reset, retry after} with the
+// durations in milliseconds. They read the clock of Redis, so the instances
+// share one clock whatever the drift of their hosts.
+
+// clockScript sets now to the time of Redis in milliseconds. TIME makes the
+// scripts non deterministic, replicating their effects instead of the scripts
+// is the default since Redis 5 and is asked for on older ones.
+const clockScript = `
+if redis.replicate_commands then
+	redis.replicate_commands()
+end
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+`
+
+// KEYS[1] counter | ARGV limit, window
+var fixedWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	ttl = tonumber(ARGV[2])
+end
+
+if count <= limit then
+	return {1, limit - count, ttl, 0}
+end
+return {0, 0, ttl, ttl}
+`)
+
+// KEYS[1] sorted set of the requests | ARGV window, limit, member
+var slidingWindowLogScript = redis.NewScript(clockScript + `
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+
+local count = redis.call("ZCARD", KEYS[1])
+local allowed, retry = 0, 0
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[3])
+	redis.call("PEXPIRE", KEYS[1], window)
+	count = count + 1
+	allowed = 1
+else
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	retry = tonumber(oldest[2]) + window - now
+end
+
+local reset = 0
+if count > 0 then
+	local newest = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+	reset = tonumber(newest[2]) + window - now
+end
+
+return {allowed, math.max(limit - count, 0), reset, retry}
+`)
+
+// KEYS[1] hash of the start of the current window and the counts of the
+// current and previous windows | ARGV limit, window
+var slidingWindowScript = redis.NewScript(clockScript + `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local start = now - now % window
+local elapsed = now - start
+
+local state = redis.call("HMGET", KEYS[1], "start", "current", "previous")
+local last = tonumber(state[1])
+local current, previous = 0, 0
+if last == start then
+	current = tonumber(state[2]) or 0
+	previous = tonumber(state[3]) or 0
+elseif last == start - window then
+	previous = tonumber(state[2]) or 0
+end
+
+local overlap = 1 - elapsed / window
+local nextWindow = window - elapsed
+
+local allowed, retry = 0, 0
+if previous * overlap + current + 1 <= limit then
+	current = current + 1
+	redis.call("HSET", KEYS[1], "start", start, "current", current, "previous", previous)
+	redis.call("PEXPIRE", KEYS[1], window * 2)
+	allowed = 1
+elseif current >= limit or previous == 0 then
+	retry = nextWindow
+else
+	local needed = 1 - (limit - current - 1) / previous
+	local wait = math.floor(needed * window) - elapsed
+	retry = math.min(math.max(wait, 1), nextWindow)
+end
+
+local reset = 0
+if current > 0 then
+	reset = nextWindow + window
+elseif previous > 0 then
+	reset = nextWindow
+end
+
+local remaining = math.max(math.floor(limit - previous * overlap - current), 0)
+return {allowed, remaining, reset, retry}
+`)
+
+// KEYS[1] bucket | ARGV tokens per millisecond, burst
+var tokenBucketScript = redis.NewScript(clockScript + `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(bucket[1])
+local last = tonumber(bucket[2])
+if tokens == nil or last == nil then
+	tokens, last = burst, now
+end
+
+if now > last then
+	tokens = tokens + (now - last) * rate
+	last = now
+end
+tokens = math.min(burst, tokens)
+
+local allowed, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", tostring(last))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate))
+return {allowed, math.floor(tokens), math.ceil((burst - tokens) / rate), retry}
+`)
+
+// RedisRateLimiter keeps the counters in Redis so the limit is shared by every
+// instance of the API. Every check is a single script, so concurrent requests
+// can't both take the last slot.
+type RedisRateLimiter struct {
+	rdb      *redis.Client
+	strategy string
+	// fallback is used while Redis is unavailable, nil allows every request
+	fallback Limiter
+	breaker  Breaker
+	onError  func(error)
+	// seq makes the members of the sliding log unique
+	seq atomic.Uint64
+}
+
+// NewRedisLimiter returns a limiter of the configured strategy backed by Redis,
+// cfg.Fallback decides what happens when Redis can't be reached.
+func NewRedisLimiter(rdb *redis.Client, cfg Config, opts ...Option) (*RedisRateLimiter, error) {
+	o := newOptions(opts)
+
+	rl := &RedisRateLimiter{
+		rdb:      rdb,
+		strategy: cfg.Strategy,
+		breaker:  o.breaker,
+		onError:  o.onError,
+	}
+
+	switch rl.strategy {
+	case "":
+		rl.strategy = StrategyFixedWindow
+	case StrategyFixedWindow, StrategySlidingWindowLog, StrategySlidingWindow, StrategyTokenBucket:
+	default:
+		return nil, fmt.Errorf("unknown rate limiter strategy %q", cfg.Strategy)
+	}
+
+	switch cfg.Fallback {
+	case "", FallbackAllow:
+	case FallbackLocal:
+		fallback, err := New(cfg, opts...)
+		if err != nil {
+			return nil, err
+		}
+		rl.fallback = fallback
+	default:
+		return nil, fmt.Errorf("unknown rate limiter fallback %q", cfg.Fallback)
+	}
+
+	return rl, nil
+}
+
+func (rl *RedisRateLimiter) Allow(key string, quota Quota) Result {
+	//? While the breaker is open Redis is known to be down, the outage was already reported
+	if rl.breaker != nil && !rl.breaker.Allow() {
+		return rl.fallBack(key, quota)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	reply, err := rl.run(ctx, key, quota)
+	if err != nil {
+		if rl.breaker != nil {
+			rl.breaker.Failure()
+		}
+		if rl.onError != nil {
+			rl.onError(err)
+		}
+
+		return rl.fallBack(key, quota)
+	}
+
+	if rl.breaker != nil {
+		rl.breaker.Success()
+	}
+
+	res := Result{
+		Allowed:    reply[0] == 1,
+		Limit:      quota.Limit,
+		Remaining:  int(reply[1]),
+		Reset:      time.Duration(reply[2]) * time.Millisecond,
+		RetryAfter: time.Duration(reply[3]) * time.Millisecond,
+	}
+	if rl.strategy == StrategyTokenBucket {
+		res.Limit = quota.burst()
+	}
+
+	return res
+}
+
+// fallBack answers for Redis while it's unavailable.
+func (rl *RedisRateLimiter) fallBack(key string, quota Quota) Result {
+	if rl.fallback == nil {
+		return Result{Allowed: true, Limit: quota.Limit, Remaining: quota.Limit}
+	}
+	return rl.fallback.Allow(key, quota)
+}
+
+func (rl *RedisRateLimiter) run(ctx context.Context, key string, quota Quota) ([]int64, error) {
+	key = keyPrefix + rl.strategy + ":" + key
+	window := quota.Window.Milliseconds()
+
+	var cmd *redis.Cmd
+	switch rl.strategy {
+	case StrategySlidingWindowLog:
+		//? Only tells the requests apart, the score is the time of Redis
+		member := strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.FormatUint(rl.seq.Add(1), 10)
+		cmd = slidingWindowLogScript.Run(ctx, rl.rdb, []string{key}, window, quota.Limit, member)
+	case StrategySlidingWindow:
+		cmd = slidingWindowScript.Run(ctx, rl.rdb, []string{key}, quota.Limit, window)
+	case StrategyTokenBucket:
+		rate := strconv.FormatFloat(float64(quota.Limit)/float64(window), 'f', -1, 64)
+		cmd = tokenBucketScript.Run(ctx, rl.rdb, []string{key}, rate, quota.burst())
+	default:
+		cmd = fixedWindowScript.Run(ctx, rl.rdb, []string{key}, quota.Limit, window)
+	}
+
+	reply, err := cmd.Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) != 4 {
+		return nil, fmt.Errorf("unexpected rate limiter reply %v", reply)
+	}
+
+	return reply, nil
+}
+
+// Stop ends the janitor of the fallback limiter, the Redis client is owned by
+// the caller and is left open.
+func (rl *RedisRateLimiter) Stop() {
+	if s, ok := rl.fallback.(interface{ Stop() }); ok {
+		s.Stop()
+	}
+}
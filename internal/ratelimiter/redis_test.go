@@ -0,0 +1,130 @@
+package ratelimiter
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisFallback(t *testing.T) {
+	// Nothing listens there, every check fails
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer rdb.Close()
+
+	cfg := Config{RequestsPerTimeFrame: 2, TimeFrame: time.Minute, Strategy: StrategySlidingWindow}
+
+	t.Run("allow", func(t *testing.T) {
+		errs := 0
+		l, err := NewRedisLimiter(rdb, cfg, WithErrorHandler(func(error) { errs++ }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Stop()
+
+		allowN(t, l, "a", cfg.Quota(), 5)
+
+		if errs != 5 {
+			t.Errorf("expected the 5 errors to be reported, got %d", errs)
+		}
+	})
+
+	t.Run("local", func(t *testing.T) {
+		cfg := cfg
+		cfg.Fallback = FallbackLocal
+
+		clock := newFakeClock()
+		l, err := NewRedisLimiter(rdb, cfg, WithClock(clock.Now))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Stop()
+
+		allowN(t, l, "a", cfg.Quota(), 2)
+		expectDenied(t, l, "a", cfg.Quota(), time.Minute)
+	})
+
+	t.Run("breaker", func(t *testing.T) {
+		errs := 0
+		breaker := &fakeBreaker{open: true}
+		l, err := NewRedisLimiter(rdb, cfg, WithBreaker(breaker), WithErrorHandler(func(error) { errs++ }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Stop()
+
+		// Redis is skipped while the breaker is open
+		allowN(t, l, "a", cfg.Quota(), 5)
+		if errs != 0 || breaker.failures != 0 {
+			t.Errorf("expected redis to be skipped, got %d errors and %d failures", errs, breaker.failures)
+		}
+
+		breaker.open = false
+		allowN(t, l, "a", cfg.Quota(), 2)
+		if errs != 2 || breaker.failures != 2 {
+			t.Errorf("expected the 2 failures to be reported, got %d errors and %d failures", errs, breaker.failures)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		cfg := cfg
+		cfg.Fallback = "deny"
+
+		if _, err := NewRedisLimiter(rdb, cfg); err == nil {
+			t.Error("expected an unknown fallback to be rejected")
+		}
+	})
+}
+
+type fakeBreaker struct {
+	open     bool
+	failures int
+}
+
+func (b *fakeBreaker) Allow() bool { return !b.open }
+func (b *fakeBreaker) Success()    {}
+func (b *fakeBreaker) Failure()    { b.failures++ }
+
+// TestRedis runs the scripts against the Redis of REDIS_ADDR.
+func TestRedis(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is not set")
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis is unavailable: %v", err)
+	}
+
+	strategies := []string{StrategyFixedWindow, StrategySlidingWindowLog, StrategySlidingWindow, StrategyTokenBucket}
+	for _, strategy := range strategies {
+		t.Run(strategy, func(t *testing.T) {
+			cfg := Config{RequestsPerTimeFrame: 3, TimeFrame: time.Minute, Strategy: strategy}
+
+			// Every failure fails the test instead of falling back
+			l, err := NewRedisLimiter(rdb, cfg, WithErrorHandler(func(err error) { t.Error(err) }))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key := "test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+			if res := allowN(t, l, key, cfg.Quota(), 3); res.Remaining != 0 {
+				t.Errorf("expected no remaining requests, got %d", res.Remaining)
+			}
+
+			res := l.Allow(key, cfg.Quota())
+			if res.Allowed {
+				t.Fatal("expected the fourth request to be denied")
+			}
+			if res.RetryAfter <= 0 || res.RetryAfter > time.Minute {
+				t.Errorf("expected to retry within the window, got %s", res.RetryAfter)
+			}
+		})
+	}
+}
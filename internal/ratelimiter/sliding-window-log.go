@@ -0,0 +1,90 @@
+package ratelimiter
+
+import (
+	"sync"
+	"time"
+)
+
+type requestLog struct {
+	// times of the requests in the window, oldest first
+	times  []time.Time
+	window time.Duration
+}
+
+// prune drops the requests that are out of the window.
+func (l *requestLog) prune(now time.Time) {
+	i := 0
+	for i < len(l.times) && now.Sub(l.times[i]) >= l.window {
+		i++
+	}
+
+	l.times = l.times[i:]
+}
+
+// SlidingWindowLogRateLimiter remembers when each request of the last window
+// was made, a request is allowed when fewer than limit were.
+type SlidingWindowLogRateLimiter struct {
+	sync.Mutex
+	*janitor
+	clients map[string]*requestLog
+	now     Clock
+}
+
+func NewSlidingWindowLogLimiter(opts ...Option) *SlidingWindowLogRateLimiter {
+	o := newOptions(opts)
+
+	rl := &SlidingWindowLogRateLimiter{
+		clients: make(map[string]*requestLog),
+		now:     o.clock,
+	}
+	rl.janitor = startJanitor(o.janitorInterval, rl.sweep)
+
+	return rl
+}
+
+func (rl *SlidingWindowLogRateLimiter) Allow(key string, quota Quota) Result {
+	now := rl.now()
+
+	rl.Lock()
+	defer rl.Unlock()
+
+	l, exists := rl.clients[key]
+	if !exists {
+		l = &requestLog{window: quota.Window}
+		rl.clients[key] = l
+	}
+	l.prune(now)
+
+	res := Result{Limit: quota.Limit}
+
+	if len(l.times) < quota.Limit {
+		l.times = append(l.times, now)
+		res.Allowed = true
+	} else {
+		// A slot frees up once the oldest request leaves the window
+		res.RetryAfter = l.times[0].Add(l.window).Sub(now)
+	}
+
+	res.Remaining = max(quota.Limit-len(l.times), 0)
+	if n := len(l.times); n > 0 {
+		res.Reset = l.times[n-1].Add(l.window).Sub(now)
+	}
+
+	return res
+}
+
+func (rl *SlidingWindowLogRateLimiter) sweep() {
+	now := rl.now()
+
+	rl.Lock()
+	defer rl.Unlock()
+
+	for key, l := range rl.clients {
+		if l.prune(now); len(l.times) == 0 {
+			delete(rl.clients, key)
+		} else {
+			//? Copied so the pruned times don't stay referenced by the slice
+			l.times = append([]time.Time(nil), l.times...)
+		}
+	}
+}
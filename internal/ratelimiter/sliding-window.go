@@ -0,0 +1,114 @@
+package ratelimiter
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type slidingWindow struct {
+	start    time.Time
+	window   time.Duration
+	current  int
+	previous int
+}
+
+// advance moves the counters to the fixed window holding now.
+func (w *slidingWindow) advance(now time.Time) {
+	start := now.Truncate(w.window)
+
+	switch {
+	case start.Equal(w.start):
+	case start.Sub(w.start) == w.window:
+		w.previous, w.current = w.current, 0
+		w.start = start
+	default:
+		w.previous, w.current = 0, 0
+		w.start = start
+	}
+}
+
+// SlidingWindowRateLimiter approximates a sliding window with two counters:
+// the requests of the previous fixed window count for the part of it that the
+// sliding window still covers. It only keeps two counters per client and
+// doesn't allow the bursts of the fixed window at the window boundaries.
+type SlidingWindowRateLimiter struct {
+	sync.Mutex
+	*janitor
+	clients map[string]*slidingWindow
+	now     Clock
+}
+
+func NewSlidingWindowLimiter(opts ...Option) *SlidingWindowRateLimiter {
+	o := newOptions(opts)
+
+	rl := &SlidingWindowRateLimiter{
+		clients: make(map[string]*slidingWindow),
+		now:     o.clock,
+	}
+	rl.janitor = startJanitor(o.janitorInterval, rl.sweep)
+
+	return rl
+}
+
+func (rl *SlidingWindowRateLimiter) Allow(key string, quota Quota) Result {
+	now := rl.now()
+
+	rl.Lock()
+	defer rl.Unlock()
+
+	w, exists := rl.clients[key]
+	if !exists {
+		w = &slidingWindow{start: now.Truncate(quota.Window), window: quota.Window}
+		rl.clients[key] = w
+	}
+	w.advance(now)
+
+	elapsed := now.Sub(w.start)
+	overlap := 1 - float64(elapsed)/float64(w.window)
+	next := w.start.Add(w.window).Sub(now)
+
+	res := Result{Limit: quota.Limit}
+
+	if float64(w.previous)*overlap+float64(w.current)+1 <= float64(quota.Limit) {
+		w.current++
+		res.Allowed = true
+	} else if w.current >= quota.Limit || w.previous == 0 {
+		// Only the next window can make room
+		res.RetryAfter = next
+	} else {
+		// The previous window weighs less as time goes, find when it makes room
+		needed := 1 - float64(quota.Limit-w.current-1)/float64(w.previous)
+		wait := time.Duration(needed*float64(w.window)) - elapsed
+
+		res.RetryAfter = min(max(wait, time.Millisecond), next)
+	}
+
+	used := float64(w.previous)*overlap + float64(w.current)
+	res.Remaining = max(int(math.Floor(float64(quota.Limit)-used)), 0)
+
+	// The current requests stop counting once the next window is over too
+	switch {
+	case w.current > 0:
+		res.Reset = next + w.window
+	case w.previous > 0:
+		res.Reset = next
+	}
+
+	return res
+}
+
+// sweep forgets the clients without requests in the last two windows, they no
+// longer weigh on the sliding window.
+func (rl *SlidingWindowRateLimiter) sweep() {
+	now := rl.now()
+
+	rl.Lock()
+	defer rl.Unlock()
+
+	for key, w := range rl.clients {
+		if now.Sub(w.start) >= 2*w.window {
+			delete(rl.clients, key)
+		}
+	}
+}
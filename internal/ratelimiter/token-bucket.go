@@ -0,0 +1,103 @@
+package ratelimiter
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	burst  float64
+	// tokens per second
+	rate float64
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// until returns how long the bucket takes to hold n tokens.
+func (b *bucket) until(n float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+
+	wait := (n - b.tokens) / b.rate
+	return time.Duration(math.Ceil(wait * float64(time.Second)))
+}
+
+// TokenBucketRateLimiter gives each client a bucket of burst tokens, refilled
+// at limit tokens per window. Every request takes a token.
+type TokenBucketRateLimiter struct {
+	sync.Mutex
+	*janitor
+	clients map[string]*bucket
+	now     Clock
+}
+
+func NewTokenBucketLimiter(opts ...Option) *TokenBucketRateLimiter {
+	o := newOptions(opts)
+
+	rl := &TokenBucketRateLimiter{
+		clients: make(map[string]*bucket),
+		now:     o.clock,
+	}
+	rl.janitor = startJanitor(o.janitorInterval, rl.sweep)
+
+	return rl
+}
+
+func (rl *TokenBucketRateLimiter) Allow(key string, quota Quota) Result {
+	now := rl.now()
+
+	rl.Lock()
+	defer rl.Unlock()
+
+	burst := float64(quota.burst())
+
+	b, exists := rl.clients[key]
+	if !exists {
+		b = &bucket{tokens: burst, last: now}
+		rl.clients[key] = b
+	}
+	b.refill(now)
+
+	//? Set after the refill so the time elapsed is refilled at the previous rate
+	b.burst, b.rate = burst, float64(quota.Limit)/quota.Window.Seconds()
+	b.tokens = math.Min(b.tokens, b.burst)
+
+	res := Result{Limit: int(b.burst)}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = b.until(1)
+	}
+
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = b.until(b.burst)
+
+	return res
+}
+
+// sweep forgets the clients whose bucket is full again, a new bucket is the
+// same.
+func (rl *TokenBucketRateLimiter) sweep() {
+	now := rl.now()
+
+	rl.Lock()
+	defer rl.Unlock()
+
+	for key, b := range rl.clients {
+		b.refill(now)
+		if b.tokens >= b.burst {
+			delete(rl.clients, key)
+		}
+	}
+}
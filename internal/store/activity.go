@@ -0,0 +1,134 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// activityLimit caps the number of events returned per kind
+const activityLimit = 20
+
+// ActivityStore gathers what happened around a user over a period of time,
+// it backs the digest emails.
+type ActivityStore struct {
+	db *sql.DB
+}
+
+// GetNewFollowers returns the users who started following userID since the
+// given time.
+func (s *ActivityStore) GetNewFollowers(ctx context.Context, userID int64, since time.Time) ([]models.Activity, error) {
+	query := `
+		SELECT u.id, u.username, f.created_at
+		FROM followers f
+		JOIN users u ON u.id = f.user_id
+		WHERE f.follower_id = $1 AND f.created_at > $2
+		ORDER BY f.created_at DESC
+		LIMIT $3
+	`
+
+	return s.query(ctx, models.NotifyNewFollower, query, userID, since, activityLimit)
+}
+
+// GetComments returns the published comments written by others on the posts
+// of userID since the given time.
+func (s *ActivityStore) GetComments(ctx context.Context, userID int64, since time.Time) ([]models.Activity, error) {
+	query := `
+		SELECT u.id, u.username, c.created_at, p.id, p.title, c.content
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id
+		JOIN users u ON u.id = c.user_id
+		WHERE p.user_id = $1 AND c.user_id <> $1 AND c.status = 'published' AND c.created_at > $2
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.user_id = $1 AND b.blocked_id = c.user_id) OR (b.user_id = c.user_id AND b.blocked_id = $1)
+		)
+		ORDER BY c.created_at DESC
+		LIMIT $3
+	`
+
+	return s.query(ctx, models.NotifyComment, query, userID, since, activityLimit)
+}
+
+// GetMentions returns the published posts and comments mentioning the user
+// since the given time, limited to the ones the user is allowed to see.
+func (s *ActivityStore) GetMentions(ctx context.Context, userID int64, since time.Time) ([]models.Activity, error) {
+	query := `
+		SELECT u.id, u.username, m.created_at, p.id, p.title, m.content
+		FROM (
+			SELECT p.user_id, p.id AS post_id, p.content, pm.created_at
+			FROM post_mentions pm
+			JOIN posts p ON p.id = pm.post_id
+			WHERE pm.user_id = $1 AND pm.created_at > $2 AND p.status = 'published'
+			UNION ALL
+			SELECT c.user_id, c.post_id, c.content, cm.created_at
+			FROM comment_mentions cm
+			JOIN comments c ON c.id = cm.comment_id
+			WHERE cm.user_id = $1 AND cm.created_at > $2 AND c.status = 'published'
+		) m
+		JOIN posts p ON p.id = m.post_id
+		JOIN users u ON u.id = m.user_id
+		JOIN users a ON a.id = p.user_id
+		WHERE m.user_id <> $1
+		AND (
+			p.user_id = $1
+			OR (
+				p.status = 'published'
+				AND (
+					NOT (a.is_private OR p.visibility = 'followers')
+					OR EXISTS (SELECT 1 FROM followers f WHERE f.user_id = $1 AND f.follower_id = p.user_id)
+				)
+			)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.user_id = $1 AND b.blocked_id = m.user_id) OR (b.user_id = m.user_id AND b.blocked_id = $1)
+		)
+		ORDER BY m.created_at DESC
+		LIMIT $3
+	`
+
+	return s.query(ctx, models.NotifyMention, query, userID, since, activityLimit)
+}
+
+func (s *ActivityStore) query(ctx context.Context, kind string, query string, args ...any) ([]models.Activity, error) {
+	ctx, cancel := withQueryTimeout(ctx, "ActivityStore.query")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		args...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []models.Activity{}
+
+	for rows.Next() {
+		a := models.Activity{Kind: kind}
+
+		dest := []any{
+			&a.Actor.ID,
+			&a.Actor.Username,
+			&a.CreatedAt,
+		}
+
+		//? Follows are not attached to a post
+		if kind != models.NotifyNewFollower {
+			dest = append(dest, &a.PostID, &a.PostTitle, &a.Content)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		activities = append(activities, a)
+	}
+
+	return activities, rows.Err()
+}
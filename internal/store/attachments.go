@@ -0,0 +1,233 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// AttachmentStore keeps the metadata of the images attached to posts, the
+// images themselves live in the blob store.
+type AttachmentStore struct {
+	db *sql.DB
+}
+
+// Create attaches the image to the post unless it already has max of them,
+// ErrConflict is returned then. The post row is locked while counting so
+// concurrent uploads can't get past the limit.
+func (s *AttachmentStore) Create(ctx context.Context, a *models.Attachment, max int) error {
+	ctx, cancel := withQueryTimeout(ctx, "AttachmentStore.Create")
+	defer cancel()
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		var count int
+
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT id FROM posts WHERE id = $1 FOR UPDATE`,
+			a.PostID,
+		).Scan(new(int64))
+
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		err = tx.QueryRowContext(
+			ctx,
+			`SELECT COUNT(*) FROM post_attachments WHERE post_id = $1`,
+			a.PostID,
+		).Scan(&count)
+
+		if err != nil {
+			return err
+		}
+
+		if count >= max {
+			return ErrConflict
+		}
+
+		query := `
+			INSERT INTO post_attachments (post_id, key, thumbnail_key, url, thumbnail_url, content_type, size, width, height, alt_text)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at
+		`
+
+		return tx.QueryRowContext(
+			ctx,
+			query,
+			a.PostID,
+			a.Key,
+			a.ThumbnailKey,
+			a.URL,
+			a.ThumbnailURL,
+			a.ContentType,
+			a.Size,
+			a.Width,
+			a.Height,
+			a.AltText,
+		).Scan(
+			&a.ID,
+			&a.CreatedAt,
+		)
+	})
+}
+
+// Delete removes the attachment of the post and returns it so its blobs can
+// be deleted as well.
+func (s *AttachmentStore) Delete(ctx context.Context, postID int64, attachmentID int64) (*models.Attachment, error) {
+	query := `
+		DELETE FROM post_attachments
+		WHERE id = $1 AND post_id = $2
+		RETURNING id, post_id, key, thumbnail_key, url, thumbnail_url, content_type, size, width, height, alt_text, created_at
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "AttachmentStore.Delete")
+	defer cancel()
+
+	var a models.Attachment
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		attachmentID,
+		postID,
+	).Scan(
+		&a.ID,
+		&a.PostID,
+		&a.Key,
+		&a.ThumbnailKey,
+		&a.URL,
+		&a.ThumbnailURL,
+		&a.ContentType,
+		&a.Size,
+		&a.Width,
+		&a.Height,
+		&a.AltText,
+		&a.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &a, nil
+}
+
+// GetPostIDByKey returns the post of the attachment stored under the key, the
+// key of the image or of its thumbnail.
+func (s *AttachmentStore) GetPostIDByKey(ctx context.Context, key string) (int64, error) {
+	query := `
+		SELECT post_id FROM post_attachments
+		WHERE key = $1 OR thumbnail_key = $1
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "AttachmentStore.GetPostIDByKey")
+	defer cancel()
+
+	var postID int64
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		key,
+	).Scan(&postID)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, ErrNotFound
+		default:
+			return 0, err
+		}
+	}
+
+	return postID, nil
+}
+
+// getAttachments loads the attachments of several posts in a single query,
+// grouped by post in upload order.
+func getAttachments(ctx context.Context, db *sql.DB, postIDs []int64) (map[int64][]models.Attachment, error) {
+	query := `
+		SELECT id, post_id, key, thumbnail_key, url, thumbnail_url, content_type, size, width, height, alt_text, created_at
+		FROM post_attachments
+		WHERE post_id = ANY($1)
+		ORDER BY post_id, id
+	`
+
+	attachments := map[int64][]models.Attachment{}
+	if len(postIDs) == 0 {
+		return attachments, nil
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		query,
+		pq.Array(postIDs),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Attachment
+
+		err := rows.Scan(
+			&a.ID,
+			&a.PostID,
+			&a.Key,
+			&a.ThumbnailKey,
+			&a.URL,
+			&a.ThumbnailURL,
+			&a.ContentType,
+			&a.Size,
+			&a.Width,
+			&a.Height,
+			&a.AltText,
+			&a.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attachments[a.PostID] = append(attachments[a.PostID], a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// attachToPosts fills the attachments of the listed posts.
+func attachToPosts(ctx context.Context, db *sql.DB, posts []models.PostWithMetadata) error {
+	ids := make([]int64, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	attachments, err := getAttachments(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Attachments = attachments[posts[i].ID]
+		if posts[i].Attachments == nil {
+			posts[i].Attachments = []models.Attachment{}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+type BlockStore struct {
+	db *sql.DB
+}
+
+func (s *BlockStore) Block(ctx context.Context, userID int64, blockedID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.create(ctx, tx, userID, blockedID); err != nil {
+			return err
+		}
+
+		//? Blocking removes the follow relationship and pending requests in both directions
+		if err := s.deleteFollows(ctx, tx, userID, blockedID); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (s *BlockStore) Unblock(ctx context.Context, userID int64, blockedID int64) error {
+	query := `
+		DELETE FROM user_blocks
+		WHERE user_id = $1 AND blocked_id = $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "BlockStore.Unblock")
+	defer cancel()
+
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		userID,
+		blockedID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// IsBlocked reports whether either of the two users has blocked the other.
+func (s *BlockStore) IsBlocked(ctx context.Context, userID int64, otherID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (user_id = $1 AND blocked_id = $2) OR (user_id = $2 AND blocked_id = $1)
+		)
+	`
+	ctx, cancel := withQueryTimeout(ctx, "BlockStore.IsBlocked")
+	defer cancel()
+
+	var blocked bool
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+		otherID,
+	).Scan(&blocked)
+
+	if err != nil {
+		return false, err
+	}
+
+	return blocked, nil
+}
+
+// GetBlockedIDs returns the users blocked by the user or who blocked them.
+func (s *BlockStore) GetBlockedIDs(ctx context.Context, userID int64) ([]int64, error) {
+	query := `
+		SELECT blocked_id FROM user_blocks WHERE user_id = $1
+		UNION
+		SELECT user_id FROM user_blocks WHERE blocked_id = $1
+	`
+	ctx, cancel := withQueryTimeout(ctx, "BlockStore.GetBlockedIDs")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (s *BlockStore) create(ctx context.Context, tx *sql.Tx, userID int64, blockedID int64) error {
+	query := `
+		INSERT INTO user_blocks (user_id, blocked_id)
+		VALUES ($1, $2)
+	`
+	ctx, cancel := withQueryTimeout(ctx, "BlockStore.create")
+	defer cancel()
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		userID,
+		blockedID,
+	)
+
+	if err != nil {
+		switch {
+		case IsDuplicateKeyError(err):
+			return ErrConflict
+		case IsForeignKeyError(err):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *BlockStore) deleteFollows(ctx context.Context, tx *sql.Tx, userID int64, blockedID int64) error {
+	query := `
+		WITH unfollowed AS (
+			DELETE FROM followers
+			WHERE (user_id = $1 AND follower_id = $2) OR (user_id = $2 AND follower_id = $1)
+		)
+		DELETE FROM follow_requests
+		WHERE (user_id = $1 AND target_id = $2) OR (user_id = $2 AND target_id = $1)
+	`
+	ctx, cancel := withQueryTimeout(ctx, "BlockStore.deleteFollows")
+	defer cancel()
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		userID,
+		blockedID,
+	)
+
+	return err
+}
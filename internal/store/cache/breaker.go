@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	// BreakerClosed lets the calls reach Redis
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen skips Redis until it answers a ping again
+	BreakerOpen BreakerState = "open"
+)
+
+// pingTimeout bounds the pings made while the breaker is open.
+const pingTimeout = time.Second
+
+// purgeTimeout bounds the deletes of the stale keys once Redis is back.
+const purgeTimeout = 10 * time.Second
+
+// maxStaleKeys bounds the stale keys remembered while Redis is down, past it
+// the cached values are flushed altogether once it's back.
+const maxStaleKeys = 10000
+
+// Purge deletes the stale keys, or every cached value when flush is set.
+type Purge func(ctx context.Context, keys []string, flush bool) error
+
+// Breaker stops the calls to Redis after consecutive failures, so an outage
+// doesn't add a timeout to every request. While open, Redis is pinged in the
+// background and the breaker closes once it answers. A nil Breaker is always
+// closed.
+//
+// The keys that couldn't be deleted from Redis are remembered as stale and
+// purged before the breaker closes, so Redis doesn't serve the values they
+// held once it's back.
+type Breaker struct {
+	sync.Mutex
+	state     BreakerState
+	failures  int
+	threshold int
+	ping      func(context.Context) error
+	onChange  func(BreakerState)
+	purge     Purge
+	stale     map[string]struct{}
+	flush     bool
+}
+
+// NewBreaker opens after threshold consecutive failures, onChange is called on
+// every change of state.
+func NewBreaker(threshold int, ping func(context.Context) error, onChange func(BreakerState)) *Breaker {
+	return &Breaker{
+		state:     BreakerClosed,
+		threshold: threshold,
+		ping:      ping,
+		onChange:  onChange,
+	}
+}
+
+// Allow tells whether Redis should be called.
+func (b *Breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	return b.state == BreakerClosed
+}
+
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.failures = 0
+}
+
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	b.failures++
+	trip := b.state == BreakerClosed && b.failures >= b.threshold
+	b.Unlock()
+
+	if trip {
+		b.Trip()
+	}
+}
+
+// Trip opens the breaker right away, when Redis is known to be down.
+func (b *Breaker) Trip() {
+	b.set(BreakerOpen)
+}
+
+func (b *Breaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	return b.state
+}
+
+// OnPurge sets how the stale keys are deleted once Redis answers again.
+func (b *Breaker) OnPurge(purge Purge) {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.purge = purge
+}
+
+// Stale remembers keys that couldn't be deleted from Redis.
+func (b *Breaker) Stale(keys ...string) {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if b.flush {
+		return
+	}
+
+	if b.stale == nil {
+		b.stale = map[string]struct{}{}
+	}
+	for _, key := range keys {
+		b.stale[key] = struct{}{}
+	}
+
+	if len(b.stale) > maxStaleKeys {
+		b.stale = nil
+		b.flush = true
+	}
+}
+
+// Run pings Redis every interval while the breaker is open, until ctx is done.
+func (b *Breaker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if b.Allow() {
+			continue
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := b.ping(pingCtx)
+		cancel()
+
+		if err != nil {
+			continue
+		}
+
+		purgeCtx, cancel := context.WithTimeout(ctx, purgeTimeout)
+		err = b.purgeStale(purgeCtx)
+		cancel()
+
+		if err == nil {
+			b.set(BreakerClosed)
+		}
+	}
+}
+
+// purgeStale purges the stale keys, including the ones that went stale during
+// the purge. The keys are remembered again when the purge fails.
+func (b *Breaker) purgeStale(ctx context.Context) error {
+	for {
+		b.Lock()
+		keys := make([]string, 0, len(b.stale))
+		for key := range b.stale {
+			keys = append(keys, key)
+		}
+		flush := b.flush
+		purge := b.purge
+
+		b.stale = nil
+		b.flush = false
+		b.Unlock()
+
+		if len(keys) == 0 && !flush {
+			return nil
+		}
+
+		if purge == nil {
+			continue
+		}
+
+		if err := purge(ctx, keys, flush); err != nil {
+			b.Stale(keys...)
+			if flush {
+				b.Lock()
+				b.stale = nil
+				b.flush = true
+				b.Unlock()
+			}
+			return err
+		}
+	}
+}
+
+func (b *Breaker) set(state BreakerState) {
+	b.Lock()
+	changed := b.state != state
+	b.state = state
+	b.failures = 0
+	b.Unlock()
+
+	if changed && b.onChange != nil {
+		b.onChange(state)
+	}
+}
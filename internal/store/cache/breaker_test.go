@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	var reachable atomic.Bool
+	changes := make(chan BreakerState, 2)
+
+	b := NewBreaker(
+		2,
+		func(ctx context.Context) error {
+			if reachable.Load() {
+				return nil
+			}
+			return errors.New("connection refused")
+		},
+		func(state BreakerState) { changes <- state },
+	)
+
+	b.Failure()
+	b.Success()
+	b.Failure()
+	if !b.Allow() {
+		t.Fatal("expected a success to reset the failures")
+	}
+
+	b.Failure()
+	if b.Allow() || b.State() != BreakerOpen {
+		t.Fatal("expected the breaker to open after 2 failures in a row")
+	}
+	if state := <-changes; state != BreakerOpen {
+		t.Errorf("expected to be told the breaker opened, got %s", state)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx, time.Millisecond)
+
+	// Still down, the breaker stays open
+	time.Sleep(10 * time.Millisecond)
+	if b.Allow() {
+		t.Fatal("expected the breaker to stay open while the ping fails")
+	}
+
+	reachable.Store(true)
+	select {
+	case state := <-changes:
+		if state != BreakerClosed {
+			t.Errorf("expected the breaker to close, got %s", state)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the breaker to close once the ping succeeds")
+	}
+
+	var nilBreaker *Breaker
+	nilBreaker.Failure()
+	if !nilBreaker.Allow() {
+		t.Error("expected a nil breaker to always allow")
+	}
+}
+
+func TestResilientUserStore(t *testing.T) {
+	ctx := context.Background()
+
+	failing := &failingUserStore{err: errors.New("connection refused")}
+
+	lookups := map[Lookup]int{}
+	b := NewBreaker(2, func(context.Context) error { return nil }, nil)
+	s := NewResilientUserStore(failing, b, nil, func(result Lookup) { lookups[result]++ })
+
+	for i := 0; i < 3; i++ {
+		user, err := s.Get(ctx, 1)
+		if err != nil || user != nil {
+			t.Fatalf("expected a miss, got %v, %v", user, err)
+		}
+	}
+
+	if err := s.Set(ctx, &models.User{ID: 1}); err != nil {
+		t.Errorf("expected the failed set to be ignored, got %v", err)
+	}
+
+	if b.State() != BreakerOpen {
+		t.Error("expected the failures to open the breaker")
+	}
+	if lookups[LookupError] != 2 {
+		t.Errorf("expected the 2 failed lookups to be reported, got %v", lookups)
+	}
+
+	// The cache is skipped altogether once open
+	calls := failing.calls
+	s.Get(ctx, 1)
+	if failing.calls != calls {
+		t.Error("expected the open breaker to skip the cache")
+	}
+
+	// A failed delete leaves a stale user, it isn't hidden like a failed lookup
+	reported := 0
+	s = NewResilientUserStore(failing, NewBreaker(2, func(context.Context) error { return nil }, nil), func(error) { reported++ }, nil)
+	if err := s.Delete(ctx, 1); err == nil || reported != 1 {
+		t.Errorf("expected the failed delete to be returned and reported, got %v and %d reports", err, reported)
+	}
+}
+
+func TestCacheBreaker(t *testing.T) {
+	rdb := newFakeRedis()
+	rdb.err = errors.New("connection refused")
+
+	b := NewBreaker(1, func(context.Context) error { return nil }, nil)
+	c := newCache[*models.Role](rdb, Options{Breaker: b})
+
+	var loads int
+	load := func(ctx context.Context) (*models.Role, error) {
+		loads++
+		return &models.Role{Name: "admin"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetOrLoad(context.Background(), "admin", load); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if loads != 2 || b.State() != BreakerOpen {
+		t.Errorf("expected the roles to be loaded with the breaker open, got %d loads and %s", loads, b.State())
+	}
+}
+
+func TestBreakerPurge(t *testing.T) {
+	ctx := context.Background()
+
+	rdb := newFakeRedis()
+	rdb.values["post-1"] = "stale"
+	rdb.values["post-2"] = "stale"
+
+	b := NewBreaker(1, func(context.Context) error { return nil }, nil)
+	c := newCache[*models.Post](rdb, Options{Prefix: "post-", Breaker: b})
+
+	purged := make(chan []string, 1)
+	b.OnPurge(func(ctx context.Context, keys []string, flush bool) error {
+		if flush {
+			t.Error("expected the stale keys to be deleted, not flushed")
+		}
+
+		if err := rdb.Del(ctx, keys...).Err(); err != nil {
+			return err
+		}
+
+		purged <- keys
+		return nil
+	})
+
+	b.Trip()
+
+	// Deleted even though the breaker is open
+	if err := c.Delete(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rdb.values["post-1"]; ok {
+		t.Error("expected the delete to be tried with the breaker open")
+	}
+
+	rdb.err = errors.New("connection refused")
+	if err := c.Delete(ctx, "2"); err == nil {
+		t.Fatal("expected the delete to fail")
+	}
+	rdb.err = nil
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go b.Run(runCtx, time.Millisecond)
+
+	select {
+	case keys := <-purged:
+		if len(keys) != 1 || keys[0] != "post-2" {
+			t.Errorf("expected the stale key to be purged, got %v", keys)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the stale keys to be purged once Redis answers")
+	}
+
+	if _, ok := rdb.values["post-2"]; ok {
+		t.Error("expected the stale value to be deleted")
+	}
+
+	for !b.Allow() {
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Run("should flush once too many keys are stale", func(t *testing.T) {
+		b := NewBreaker(1, func(context.Context) error { return nil }, nil)
+
+		var flushed atomic.Bool
+		b.OnPurge(func(ctx context.Context, keys []string, flush bool) error {
+			flushed.Store(flush)
+			return nil
+		})
+
+		b.Trip()
+		for i := 0; i <= maxStaleKeys; i++ {
+			b.Stale(fmt.Sprintf("post-%d", i))
+		}
+
+		if err := b.purgeStale(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if !flushed.Load() {
+			t.Error("expected the cache to be flushed")
+		}
+	})
+
+	t.Run("should stay open while the purge fails", func(t *testing.T) {
+		b := NewBreaker(1, func(context.Context) error { return nil }, nil)
+		b.OnPurge(func(ctx context.Context, keys []string, flush bool) error {
+			return errors.New("connection refused")
+		})
+
+		b.Trip()
+		b.Stale("post-1")
+
+		if err := b.purgeStale(ctx); err == nil {
+			t.Fatal("expected the purge to fail")
+		}
+
+		if len(b.stale) != 1 {
+			t.Errorf("expected the stale key to be kept, got %v", b.stale)
+		}
+	})
+}
+
+type failingUserStore struct {
+	err   error
+	calls int
+}
+
+func (s *failingUserStore) Get(context.Context, int64) (*models.User, error) {
+	s.calls++
+	return nil, s.err
+}
+
+func (s *failingUserStore) Set(context.Context, *models.User) error {
+	s.calls++
+	return s.err
+}
+
+func (s *failingUserStore) Delete(context.Context, int64) error {
+	s.calls++
+	return s.err
+}
@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/sync/singleflight"
+)
+
+type Options struct {
+	// Prefix namespaces the keys of the cache
+	Prefix string
+	TTL    time.Duration
+	// NotFound is the error of the loader for the values that don't exist. It's
+	// cached for NegativeTTL so looking them up again doesn't reach the database.
+	NotFound    error
+	NegativeTTL time.Duration
+	// Codec of the values, JSON when not set
+	Codec Codec
+	// OnError is called with the errors of Redis, they don't fail the lookups
+	OnError func(error)
+	// Breaker skips Redis while it's down, the lookups are all loaded
+	Breaker *Breaker
+	// OnLookup is called with the result of the lookups made in Redis
+	OnLookup func(Lookup)
+}
+
+// Lookup is the result of a lookup in Redis.
+type Lookup string
+
+const (
+	LookupHit   Lookup = "hit"
+	LookupMiss  Lookup = "miss"
+	LookupError Lookup = "error"
+)
+
+// missing is cached in place of the values that don't exist, no codec encodes a
+// value to nothing.
+const missing = ""
+
+// redisClient is the part of the Redis client used by the cache.
+type redisClient interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// Cache is a read-through cache of values of type T kept in Redis.
+type Cache[T any] struct {
+	rdb   redisClient
+	opts  Options
+	group singleflight.Group
+}
+
+// New returns a cache of T. Without a Redis client every lookup is loaded.
+func New[T any](rdb *redis.Client, opts Options) *Cache[T] {
+	//? A nil client in the interface wouldn't compare to nil
+	if rdb == nil {
+		return newCache[T](nil, opts)
+	}
+
+	return newCache[T](rdb, opts)
+}
+
+func newCache[T any](rdb redisClient, opts Options) *Cache[T] {
+	if opts.Codec == nil {
+		opts.Codec = JSON
+	}
+
+	return &Cache[T]{rdb: rdb, opts: opts}
+}
+
+// GetOrLoad returns the cached value of key, or loads and caches it. Concurrent
+// lookups of a key that isn't cached share a single load.
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, load func(context.Context) (T, error)) (T, error) {
+	if !c.available() {
+		return load(ctx)
+	}
+
+	key = c.opts.Prefix + key
+
+	data, err := c.rdb.Get(ctx, key).Bytes()
+	switch {
+	case err == nil:
+		c.opts.Breaker.Success()
+
+		value, err := c.decode(data)
+		if err == nil || errors.Is(err, c.opts.NotFound) {
+			c.lookup(LookupHit)
+			return value, err
+		}
+		// Cached by an older version of the value, loaded again
+		c.lookup(LookupMiss)
+		c.report(err)
+	case errors.Is(err, redis.Nil):
+		c.opts.Breaker.Success()
+		c.lookup(LookupMiss)
+	default:
+		c.opts.Breaker.Failure()
+		c.lookup(LookupError)
+		c.report(err)
+	}
+
+	//? The load is shared, it shouldn't be canceled when the first caller gives up
+	shared := context.WithoutCancel(ctx)
+
+	loaded := c.group.DoChan(key, func() (any, error) {
+		return c.load(shared, key, load)
+	})
+
+	var zero T
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-loaded:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+
+		//? Decoded by every caller so they don't share the value they may modify
+		return c.decode(res.Val.([]byte))
+	}
+}
+
+func (c *Cache[T]) load(ctx context.Context, key string, load func(context.Context) (T, error)) ([]byte, error) {
+	value, err := load(ctx)
+	if err != nil {
+		if c.opts.NotFound == nil || !errors.Is(err, c.opts.NotFound) {
+			return nil, err
+		}
+
+		if c.opts.NegativeTTL > 0 {
+			c.set(ctx, key, []byte(missing), c.opts.NegativeTTL)
+		}
+		return []byte(missing), nil
+	}
+
+	data, err := c.opts.Codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	c.set(ctx, key, data, c.opts.TTL)
+
+	return data, nil
+}
+
+// Set caches the value of key.
+func (c *Cache[T]) Set(ctx context.Context, key string, value T) error {
+	if !c.available() {
+		return nil
+	}
+
+	data, err := c.opts.Codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.record(c.rdb.Set(ctx, c.opts.Prefix+key, data, c.opts.TTL).Err())
+}
+
+// Delete removes the keys, the next lookups load them again. The delete is
+// tried even while the breaker is open, the keys that couldn't be deleted are
+// purged by the breaker before it closes.
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	if c.rdb == nil || len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.opts.Prefix + key
+	}
+
+	err := c.rdb.Del(ctx, prefixed...).Err()
+	if err != nil {
+		c.opts.Breaker.Stale(prefixed...)
+	}
+
+	return c.record(err)
+}
+
+func (c *Cache[T]) available() bool {
+	return c.rdb != nil && c.opts.Breaker.Allow()
+}
+
+// record tells the breaker how the call to Redis went.
+func (c *Cache[T]) record(err error) error {
+	if err != nil {
+		c.opts.Breaker.Failure()
+	} else {
+		c.opts.Breaker.Success()
+	}
+
+	return err
+}
+
+func (c *Cache[T]) decode(data []byte) (T, error) {
+	var value T
+
+	if string(data) == missing {
+		return value, c.opts.NotFound
+	}
+
+	if err := c.opts.Codec.Unmarshal(data, &value); err != nil {
+		return value, err
+	}
+
+	return value, nil
+}
+
+func (c *Cache[T]) set(ctx context.Context, key string, data []byte, ttl time.Duration) {
+	if err := c.record(c.rdb.Set(ctx, key, data, ttl).Err()); err != nil {
+		c.report(err)
+	}
+}
+
+func (c *Cache[T]) report(err error) {
+	if c.opts.OnError != nil {
+		c.opts.OnError(err)
+	}
+}
+
+func (c *Cache[T]) lookup(result Lookup) {
+	if c.opts.OnLookup != nil {
+		c.opts.OnLookup(result)
+	}
+}
@@ -0,0 +1,276 @@
+package cache
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// fakeRedis keeps the values in memory, expirations are only recorded.
+type fakeRedis struct {
+	sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.err != nil {
+		return redis.NewStringResult("", f.err)
+	}
+
+	value, ok := f.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.err != nil {
+		return redis.NewStatusResult("", f.err)
+	}
+
+	f.values[key] = string(value.([]byte))
+	f.ttls[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.err != nil {
+		return redis.NewIntResult(0, f.err)
+	}
+
+	for _, key := range keys {
+		delete(f.values, key)
+	}
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+
+var errNotFound = errors.New("not found")
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	rdb := newFakeRedis()
+
+	c := newCache[*models.Post](rdb, Options{
+		Prefix:      "post-",
+		TTL:         time.Minute,
+		NotFound:    errNotFound,
+		NegativeTTL: time.Second,
+		Codec:       MsgPack,
+	})
+
+	var loads atomic.Int32
+	load := func(ctx context.Context) (*models.Post, error) {
+		loads.Add(1)
+		return &models.Post{
+			ID:          1,
+			Title:       "hello",
+			Attachments: []models.Attachment{{ID: 2, Key: "posts/1/a"}},
+		}, nil
+	}
+
+	t.Run("should load once", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			post, err := c.GetOrLoad(ctx, "1", load)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if post.Title != "hello" || post.Attachments[0].Key != "posts/1/a" {
+				t.Errorf("unexpected post %+v", post)
+			}
+		}
+
+		if loads.Load() != 1 {
+			t.Errorf("expected a single load, got %d", loads.Load())
+		}
+
+		if rdb.ttls["post-1"] != time.Minute {
+			t.Errorf("expected the post to be cached for a minute, got %s", rdb.ttls["post-1"])
+		}
+	})
+
+	t.Run("should return a copy to every caller", func(t *testing.T) {
+		post, _ := c.GetOrLoad(ctx, "1", load)
+		post.Title = "changed"
+
+		post, _ = c.GetOrLoad(ctx, "1", load)
+		if post.Title != "hello" {
+			t.Errorf("expected the cached post to be unchanged, got %q", post.Title)
+		}
+	})
+
+	t.Run("should load again once deleted", func(t *testing.T) {
+		if err := c.Delete(ctx, "1"); err != nil {
+			t.Fatal(err)
+		}
+
+		before := loads.Load()
+		if _, err := c.GetOrLoad(ctx, "1", load); err != nil {
+			t.Fatal(err)
+		}
+
+		if loads.Load() != before+1 {
+			t.Error("expected the deleted post to be loaded")
+		}
+	})
+
+	t.Run("should cache missing values", func(t *testing.T) {
+		var misses int
+		missing := func(ctx context.Context) (*models.Post, error) {
+			misses++
+			return nil, errNotFound
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := c.GetOrLoad(ctx, "404", missing); !errors.Is(err, errNotFound) {
+				t.Fatalf("expected not found, got %v", err)
+			}
+		}
+
+		if misses != 1 {
+			t.Errorf("expected a single load, got %d", misses)
+		}
+
+		if rdb.ttls["post-404"] != time.Second {
+			t.Errorf("expected the miss to be cached for a second, got %s", rdb.ttls["post-404"])
+		}
+	})
+
+	t.Run("should not cache errors", func(t *testing.T) {
+		var calls int
+		failing := func(ctx context.Context) (*models.Post, error) {
+			calls++
+			return nil, errors.New("connection refused")
+		}
+
+		c.GetOrLoad(ctx, "500", failing)
+		c.GetOrLoad(ctx, "500", failing)
+
+		if calls != 2 {
+			t.Errorf("expected every lookup to load, got %d loads", calls)
+		}
+	})
+
+	t.Run("should load when redis fails", func(t *testing.T) {
+		var reported int
+		failing := newFakeRedis()
+		failing.err = errors.New("redis is down")
+
+		c := newCache[*models.Post](failing, Options{OnError: func(error) { reported++ }})
+
+		post, err := c.GetOrLoad(ctx, "1", load)
+		if err != nil || post.ID != 1 {
+			t.Fatalf("expected the post to be loaded, got %v, %v", post, err)
+		}
+
+		if reported != 2 {
+			t.Errorf("expected the failed get and set to be reported, got %d", reported)
+		}
+	})
+}
+
+func TestGetOrLoadSingleflight(t *testing.T) {
+	c := newCache[*models.Role](newFakeRedis(), Options{TTL: time.Minute})
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (*models.Role, error) {
+		loads.Add(1)
+		<-release
+		return &models.Role{Name: "admin", Level: 3}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			role, err := c.GetOrLoad(context.Background(), "admin", load)
+			if err != nil || role.Level != 3 {
+				t.Errorf("unexpected role %v, %v", role, err)
+			}
+		}()
+	}
+
+	// Lets the lookups reach the load before it's released
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if loads.Load() != 1 {
+		t.Errorf("expected the concurrent lookups to share a load, got %d", loads.Load())
+	}
+}
+
+func TestWithoutRedis(t *testing.T) {
+	c := New[*models.Role](nil, Options{})
+
+	var loads int
+	load := func(ctx context.Context) (*models.Role, error) {
+		loads++
+		return &models.Role{Name: "user"}, nil
+	}
+
+	c.GetOrLoad(context.Background(), "user", load)
+	c.GetOrLoad(context.Background(), "user", load)
+
+	if loads != 2 {
+		t.Errorf("expected every lookup to load, got %d loads", loads)
+	}
+
+	if err := c.Delete(context.Background(), "user"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	post := &models.Post{
+		ID:          1,
+		Tags:        []string{"go"},
+		Attachments: []models.Attachment{},
+	}
+
+	for name, codec := range map[string]Codec{"json": JSON, "msgpack": MsgPack} {
+		data, err := codec.Marshal(post)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded *models.Post
+		if err := codec.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.ID != 1 || decoded.Tags[0] != "go" {
+			t.Errorf("%s: unexpected post %+v", name, decoded)
+		}
+
+		//? The API returns an empty list, not null
+		if decoded.Attachments == nil {
+			t.Errorf("%s: expected the empty attachments to stay empty", name)
+		}
+	}
+}
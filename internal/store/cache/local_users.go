@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/pubsub"
+	"context"
+	"strconv"
+	"time"
+)
+
+// usersInvalidationTopic carries the IDs of the users deleted from the cache.
+const usersInvalidationTopic = "cache:users"
+
+type userStore interface {
+	Get(context.Context, int64) (*models.User, error)
+	Set(context.Context, *models.User) error
+	Delete(context.Context, int64) error
+}
+
+// LocalUserStore keeps the users of the next store in memory for a short
+// time, sparing a round trip on every authenticated request. Deletes are
+// broadcast so every instance forgets the user, a missed message is only
+// stale for the TTL.
+type LocalUserStore struct {
+	next    userStore
+	local   *LRU[int64, models.User]
+	ps      pubsub.PubSub
+	sub     pubsub.Subscription
+	onError func(error)
+}
+
+// NewLocalUserStore wraps next with an in-memory tier of up to size users. The
+// invalidations are received until the pubsub is closed.
+func NewLocalUserStore(ctx context.Context, next userStore, ps pubsub.PubSub, size int, ttl time.Duration, onError func(error)) (*LocalUserStore, error) {
+	sub, err := ps.Subscribe(ctx, usersInvalidationTopic)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &LocalUserStore{
+		next:    next,
+		local:   NewLRU[int64, models.User](size, ttl),
+		ps:      ps,
+		sub:     sub,
+		onError: onError,
+	}
+
+	go s.listen()
+
+	return s, nil
+}
+
+func (s *LocalUserStore) Get(ctx context.Context, userID int64) (*models.User, error) {
+	//? Kept by value so every request gets its own copy to modify
+	if user, ok := s.local.Get(userID); ok {
+		return &user, nil
+	}
+
+	user, err := s.next.Get(ctx, userID)
+	if err != nil || user == nil {
+		return user, err
+	}
+
+	s.local.Set(userID, *user)
+
+	return user, nil
+}
+
+func (s *LocalUserStore) Set(ctx context.Context, user *models.User) error {
+	if err := s.next.Set(ctx, user); err != nil {
+		return err
+	}
+
+	s.local.Set(user.ID, *user)
+
+	return nil
+}
+
+// Delete forgets the user on every instance, even when the next store fails
+// to delete it.
+func (s *LocalUserStore) Delete(ctx context.Context, userID int64) error {
+	s.local.Delete(userID)
+	err := s.next.Delete(ctx, userID)
+
+	payload := []byte(strconv.FormatInt(userID, 10))
+	if err := s.ps.Publish(ctx, usersInvalidationTopic, payload); err != nil && s.onError != nil {
+		s.onError(err)
+	}
+
+	return err
+}
+
+// listen forgets the users deleted by the other instances, and by this one.
+func (s *LocalUserStore) listen() {
+	for msg := range s.sub.Messages() {
+		userID, err := strconv.ParseInt(string(msg.Payload), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		s.local.Delete(userID)
+	}
+}
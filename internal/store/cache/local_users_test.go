@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"SocialMedia/internal/models"
+	"SocialMedia/internal/pubsub"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryUserStore stands in for the Redis tier shared by the instances.
+type memoryUserStore struct {
+	sync.Mutex
+	users map[int64]models.User
+	gets  int
+}
+
+func (s *memoryUserStore) Get(ctx context.Context, userID int64) (*models.User, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.gets++
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (s *memoryUserStore) Set(ctx context.Context, user *models.User) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (s *memoryUserStore) Delete(ctx context.Context, userID int64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.users, userID)
+	return nil
+}
+
+func TestLocalUserStore(t *testing.T) {
+	ctx := context.Background()
+
+	ps := pubsub.NewInProcess()
+	defer ps.Close()
+
+	shared := &memoryUserStore{users: map[int64]models.User{}}
+
+	// Two instances of the API
+	a, err := NewLocalUserStore(ctx, shared, ps, 10, time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewLocalUserStore(ctx, shared, ps, 10, time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Set(ctx, &models.User{ID: 1, Username: "gopher"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should keep the users in memory", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			user, err := b.Get(ctx, 1)
+			if err != nil || user == nil || user.Username != "gopher" {
+				t.Fatalf("unexpected user %v, %v", user, err)
+			}
+
+			user.Username = "changed"
+		}
+
+		if shared.gets != 1 {
+			t.Errorf("expected the shared store to be read once, got %d", shared.gets)
+		}
+	})
+
+	t.Run("should forget the deleted users on every instance", func(t *testing.T) {
+		a.Delete(ctx, 1)
+
+		deadline := time.Now().Add(time.Second)
+		for b.local.Len() != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("expected the other instance to forget the user")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		user, err := b.Get(ctx, 1)
+		if err != nil || user != nil {
+			t.Errorf("expected the user to be gone, got %v, %v", user, err)
+		}
+	})
+}
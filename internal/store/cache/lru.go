@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// LRU keeps up to size values in memory for ttl, the least recently used are
+// evicted first.
+type LRU[K comparable, V any] struct {
+	sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[K]*list.Element
+	// most recently used first
+	order *list.List
+	now   func() time.Time
+}
+
+func NewLRU[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[K]*list.Element, size),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	var zero V
+
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := el.Value.(*lruEntry[K, V])
+	if !c.now().Before(entry.expires) {
+		c.remove(el)
+		return zero, false
+	}
+
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.Lock()
+	defer c.Unlock()
+
+	expires := c.now().Add(c.ttl)
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry[K, V])
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expires: expires})
+
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *LRU[K, V]) Delete(key K) {
+	c.Lock()
+	defer c.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*lruEntry[K, V]).key)
+}
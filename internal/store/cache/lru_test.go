@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	c := NewLRU[int, string](2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set(1, "one")
+	c.Set(2, "two")
+
+	// 1 is now the most recently used, 2 is evicted first
+	if v, ok := c.Get(1); !ok || v != "one" {
+		t.Fatalf("expected one, got %q", v)
+	}
+
+	c.Set(3, "three")
+	if _, ok := c.Get(2); ok {
+		t.Error("expected the least recently used value to be evicted")
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 values, got %d", c.Len())
+	}
+
+	c.Delete(1)
+	if _, ok := c.Get(1); ok {
+		t.Error("expected the deleted value to be gone")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get(3); ok {
+		t.Error("expected the value to expire")
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected the expired value to be removed, %d left", c.Len())
+	}
+}
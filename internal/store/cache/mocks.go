@@ -7,9 +7,14 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// NewMockStore caches nothing but the users, every post, feed and role is
+// loaded.
 func NewMockStore() Storage {
 	return Storage{
 		Users: &MockUserStore{},
+		Posts: New[*models.Post](nil, Options{}),
+		Feeds: New[*[]models.PostWithMetadata](nil, Options{}),
+		Roles: New[*models.Role](nil, Options{}),
 	}
 }
 
@@ -27,6 +32,7 @@ func (m *MockUserStore) Set(ctx context.Context, user *models.User) error {
 	return args.Error(0)
 }
 
-func (m *MockUserStore) Delete(ctx context.Context, id int64) {
-	m.Called(id)
+func (m *MockUserStore) Delete(ctx context.Context, id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
 }
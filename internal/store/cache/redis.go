@@ -1,13 +1,63 @@
 package cache
 
-import "github.com/go-redis/redis/v8"
+import (
+	"context"
 
+	"github.com/go-redis/redis/v8"
+)
+
+// NewRedisClient returns a client tracing every command it sends.
 func NewRedisClient(addr, pw string, db int) *redis.Client {
-	return redis.NewClient(
+	rdb := redis.NewClient(
 		&redis.Options{
 			Addr:     addr,
 			Password: pw,
 			DB:       db,
 		},
 	)
+	rdb.AddHook(tracingHook{})
+
+	return rdb
+}
+
+// purgeBatch is the number of keys scanned and deleted at once when flushing.
+const purgeBatch = 1000
+
+// purgeKeys deletes the stale keys, or every key of the prefixes when they
+// must all be flushed.
+func purgeKeys(rdb *redis.Client, prefixes ...string) Purge {
+	return func(ctx context.Context, keys []string, flush bool) error {
+		if !flush {
+			return rdb.Del(ctx, keys...).Err()
+		}
+
+		for _, prefix := range prefixes {
+			batch := make([]string, 0, purgeBatch)
+
+			iter := rdb.Scan(ctx, 0, prefix+"*", purgeBatch).Iterator()
+			for iter.Next(ctx) {
+				batch = append(batch, iter.Val())
+				if len(batch) < purgeBatch {
+					continue
+				}
+
+				if err := rdb.Del(ctx, batch...).Err(); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+
+			if err := iter.Err(); err != nil {
+				return err
+			}
+
+			if len(batch) > 0 {
+				if err := rdb.Del(ctx, batch...).Err(); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
 }
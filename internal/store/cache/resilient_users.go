@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+)
+
+// ResilientUserStore turns the errors of the next store into cache misses, so
+// the users are loaded from the database while Redis is down instead of the
+// requests failing.
+type ResilientUserStore struct {
+	next     userStore
+	breaker  *Breaker
+	onError  func(error)
+	onLookup func(Lookup)
+}
+
+func NewResilientUserStore(next userStore, breaker *Breaker, onError func(error), onLookup func(Lookup)) *ResilientUserStore {
+	return &ResilientUserStore{
+		next:     next,
+		breaker:  breaker,
+		onError:  onError,
+		onLookup: onLookup,
+	}
+}
+
+func (s *ResilientUserStore) Get(ctx context.Context, userID int64) (*models.User, error) {
+	if !s.breaker.Allow() {
+		return nil, nil
+	}
+
+	user, err := s.next.Get(ctx, userID)
+	if err != nil {
+		s.lookup(LookupError)
+		s.fail(err)
+		return nil, nil
+	}
+
+	s.breaker.Success()
+
+	if user == nil {
+		s.lookup(LookupMiss)
+	} else {
+		s.lookup(LookupHit)
+	}
+
+	return user, nil
+}
+
+func (s *ResilientUserStore) Set(ctx context.Context, user *models.User) error {
+	if !s.breaker.Allow() {
+		return nil
+	}
+
+	if err := s.next.Set(ctx, user); err != nil {
+		s.fail(err)
+		return nil
+	}
+
+	s.breaker.Success()
+
+	return nil
+}
+
+// Delete returns the errors of the next store, unlike the lookups a failed
+// delete leaves a stale user behind. It is tried even while the breaker is
+// open, the users that couldn't be deleted are purged before it closes.
+func (s *ResilientUserStore) Delete(ctx context.Context, userID int64) error {
+	if err := s.next.Delete(ctx, userID); err != nil {
+		s.breaker.Stale(userKey(userID))
+		s.fail(err)
+		return err
+	}
+
+	s.breaker.Success()
+
+	return nil
+}
+
+func (s *ResilientUserStore) fail(err error) {
+	s.breaker.Failure()
+
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func (s *ResilientUserStore) lookup(result Lookup) {
+	if s.onLookup != nil {
+		s.onLookup(result)
+	}
+}
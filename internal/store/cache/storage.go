@@ -2,7 +2,9 @@ package cache
 
 import (
 	"SocialMedia/internal/models"
+	"SocialMedia/internal/store"
 	"context"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 )
@@ -11,12 +13,82 @@ type Storage struct {
 	Users interface {
 		Get(context.Context, int64) (*models.User, error)
 		Set(context.Context, *models.User) error
-		Delete(context.Context, int64)
+		Delete(context.Context, int64) error
 	}
+	// Posts by ID, kept with their attachments
+	Posts *Cache[*models.Post]
+	// Feeds holds the first page of the feeds by user ID
+	Feeds *Cache[*[]models.PostWithMetadata]
+	// Roles by name
+	Roles *Cache[*models.Role]
+	// Breaker tracks the state of Redis, nil when the cache is disabled
+	Breaker *Breaker
 }
 
-func NewRedisStorage(rbd *redis.Client) Storage {
+// Prefixes of the keys of the caches that are invalidated
+const (
+	postPrefix = "post-"
+	feedPrefix = "feed-"
+)
+
+// Hooks observe the caches of the storage, either may be nil.
+type Hooks struct {
+	// OnError is called with the errors of Redis, the values are loaded from
+	// the database instead
+	OnError func(error)
+	// OnLookup is called with the name of the cache and the result of every
+	// lookup made in Redis
+	OnLookup func(cache string, result Lookup)
+}
+
+func (h Hooks) lookup(cache string) func(Lookup) {
+	if h.OnLookup == nil {
+		return nil
+	}
+
+	return func(result Lookup) {
+		h.OnLookup(cache, result)
+	}
+}
+
+// NewRedisStorage caches in Redis through the breaker. The invalidations that
+// fail while Redis is down are purged by the breaker once it's back.
+func NewRedisStorage(rbd *redis.Client, breaker *Breaker, hooks Hooks) Storage {
+	if rbd != nil {
+		breaker.OnPurge(purgeKeys(rbd, userPrefix, postPrefix, feedPrefix))
+	}
+
 	return Storage{
-		Users: &UserStore{rdb: rbd},
+		Users: NewResilientUserStore(&UserStore{rdb: rbd}, breaker, hooks.OnError, hooks.lookup("users")),
+		Posts: New[*models.Post](rbd, Options{
+			Prefix:      postPrefix,
+			TTL:         time.Minute * 5,
+			NotFound:    store.ErrNotFound,
+			NegativeTTL: time.Second * 30,
+			//? The attachment keys are needed to delete the blobs but not in the JSON
+			Codec:    MsgPack,
+			OnError:  hooks.OnError,
+			Breaker:  breaker,
+			OnLookup: hooks.lookup("posts"),
+		}),
+		Feeds: New[*[]models.PostWithMetadata](rbd, Options{
+			Prefix:   feedPrefix,
+			TTL:      time.Second * 30, // New posts of the followed users show up late
+			Codec:    MsgPack,
+			OnError:  hooks.OnError,
+			Breaker:  breaker,
+			OnLookup: hooks.lookup("feeds"),
+		}),
+		Roles: New[*models.Role](rbd, Options{
+			Prefix:      "role-",
+			TTL:         time.Hour,
+			NotFound:    store.ErrNotFound,
+			NegativeTTL: time.Minute,
+			Codec:       JSON,
+			OnError:     hooks.OnError,
+			Breaker:     breaker,
+			OnLookup:    hooks.lookup("roles"),
+		}),
+		Breaker: breaker,
 	}
 }
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("SocialMedia/internal/store/cache")
+
+// tracingHook traces the commands sent to Redis, a span per command or
+// pipeline. The keys and values aren't recorded, only the command name.
+type tracingHook struct{}
+
+func (tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = tracer.Start(
+		ctx,
+		"redis."+cmd.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis),
+	)
+
+	return ctx, nil
+}
+
+func (tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	end(trace.SpanFromContext(ctx), cmd.Err())
+	return nil
+}
+
+func (tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name()
+	}
+
+	ctx, _ = tracer.Start(
+		ctx,
+		"redis.pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(strings.Join(names, " "))),
+	)
+
+	return ctx, nil
+}
+
+func (tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			err = cmd.Err()
+			break
+		}
+	}
+
+	end(trace.SpanFromContext(ctx), err)
+	return nil
+}
+
+func end(span trace.Span, err error) {
+	//? A missing key is a cache miss, not a failure
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
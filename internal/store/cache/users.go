@@ -16,8 +16,18 @@ type UserStore struct {
 
 const UserExpTime = time.Minute
 
+// userPrefix namespaces the keys of the cached users.
+const userPrefix = "user-"
+
+// cachedUser keeps the suspension of the user in the cache, it is left out of
+// the JSON of models.User.
+type cachedUser struct {
+	models.User
+	Suspension *models.Suspension `json:"suspension,omitempty"`
+}
+
 func (s *UserStore) Get(ctx context.Context, userID int64) (*models.User, error) {
-	cacheKey := fmt.Sprintf("user-%v", userID)
+	cacheKey := userKey(userID)
 
 	data, err := s.rdb.Get(ctx, cacheKey).Result()
 	if err == redis.Nil {
@@ -26,16 +36,19 @@ func (s *UserStore) Get(ctx context.Context, userID int64) (*models.User, error)
 		return nil, err
 	}
 
-	var user models.User
+	var cached cachedUser
 
 	if data != "" {
-		err := json.Unmarshal([]byte(data), &user)
+		err := json.Unmarshal([]byte(data), &cached)
 
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	user := cached.User
+	user.Suspension = cached.Suspension
+
 	return &user, nil
 }
 
@@ -44,9 +57,9 @@ func (s *UserStore) Set(ctx context.Context, user *models.User) error {
 		return fmt.Errorf("invalid user id")
 	}
 
-	cacheKey := fmt.Sprintf("user-%v", user.ID)
+	cacheKey := userKey(user.ID)
 
-	json, err := json.Marshal(user)
+	json, err := json.Marshal(cachedUser{User: *user, Suspension: user.Suspension})
 	if err != nil {
 		return err
 	}
@@ -54,7 +67,12 @@ func (s *UserStore) Set(ctx context.Context, user *models.User) error {
 	return s.rdb.SetEX(ctx, cacheKey, json, UserExpTime).Err()
 }
 
-func (s *UserStore) Delete(ctx context.Context, userID int64) {
-	cacheKey := fmt.Sprintf("user-%d", userID)
-	s.rdb.Del(ctx, cacheKey)
+func (s *UserStore) Delete(ctx context.Context, userID int64) error {
+	cacheKey := userKey(userID)
+	return s.rdb.Del(ctx, cacheKey).Err()
+}
+
+// userKey is the Redis key of the cached user.
+func userKey(userID int64) string {
+	return fmt.Sprintf("%s%d", userPrefix, userID)
 }
@@ -4,6 +4,7 @@ import (
 	"SocialMedia/internal/models"
 	"context"
 	"database/sql"
+	"errors"
 )
 
 type CommentStore struct {
@@ -12,19 +13,24 @@ type CommentStore struct {
 
 func (s *CommentStore) Create(ctx context.Context, comment *models.Comment) error {
 	query := `
-		INSERT INTO comments (post_id, user_id, content)
-		VALUES ($1, $2, $3)
+		INSERT INTO comments (post_id, user_id, content, status)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "CommentStore.Create")
 	defer cancel()
 
+	if comment.Status == "" {
+		comment.Status = models.StatusPublished
+	}
+
 	err := s.db.QueryRowContext(
 		ctx,
 		query,
 		comment.PostID,
 		comment.UserID,
 		comment.Content,
+		comment.Status,
 	).Scan(
 		&comment.ID,
 		&comment.CreatedAt,
@@ -37,20 +43,29 @@ func (s *CommentStore) Create(ctx context.Context, comment *models.Comment) erro
 	return nil
 }
 
-func (s *CommentStore) GetByPostID(ctx context.Context, postID int64) (*[]models.Comment, error) {
+// GetByPostID returns the published comments of a post, hiding the ones written
+// by users that are in a block relationship with the viewer. The viewer's own
+// comments are returned even while they are pending review.
+func (s *CommentStore) GetByPostID(ctx context.Context, postID int64, viewerID int64) (*[]models.Comment, error) {
 	query := `
-		SELECT c.id, c.post_id, c.user_id, c.content, c.created_at, u.username, u.id FROM comments c 
+		SELECT c.id, c.post_id, c.user_id, c.content, c.status, c.created_at, u.username, u.id FROM comments c 
 		JOIN users u on u.id = c.user_id
 		where c.post_id = $1
+		AND (c.status = 'published' OR c.user_id = $2)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.user_id = $2 AND b.blocked_id = c.user_id) OR (b.user_id = c.user_id AND b.blocked_id = $2)
+		)
 		ORDER BY c.created_at DESC;
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "CommentStore.GetByPostID")
 	defer cancel()
 
 	rows, err := s.db.QueryContext(
 		ctx,
 		query,
 		postID,
+		viewerID,
 	)
 
 	if err != nil {
@@ -68,6 +83,7 @@ func (s *CommentStore) GetByPostID(ctx context.Context, postID int64) (*[]models
 			&c.PostID,
 			&c.UserID,
 			&c.Content,
+			&c.Status,
 			&c.CreatedAt,
 			&c.User.Username,
 			&c.User.ID,
@@ -82,3 +98,97 @@ func (s *CommentStore) GetByPostID(ctx context.Context, postID int64) (*[]models
 
 	return &comments, nil
 }
+
+// GetPending returns a page of the comments held for review, the oldest first
+// unless sorted otherwise.
+func (s *CommentStore) GetPending(ctx context.Context, fq PaginatedFeedQuery) ([]models.Comment, error) {
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.content, c.status, c.created_at, u.username
+		FROM comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.status = 'pending_review'
+		ORDER BY c.created_at ` + fq.Sort + `, c.id ` + fq.Sort + `
+		LIMIT $1 OFFSET $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "CommentStore.GetPending")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+
+	for rows.Next() {
+		var c models.Comment
+
+		err := rows.Scan(
+			&c.ID,
+			&c.PostID,
+			&c.UserID,
+			&c.Content,
+			&c.Status,
+			&c.CreatedAt,
+			&c.User.Username,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.User.ID = c.UserID
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
+
+// SetStatus stores the status of a comment held for review and returns it.
+// Comments can't be edited, the ones that aren't pending anymore were already
+// reviewed and are not found.
+func (s *CommentStore) SetStatus(ctx context.Context, commentID int64, status string) (*models.Comment, error) {
+	query := `
+		WITH c AS (
+			UPDATE comments
+			SET status = $2
+			WHERE id = $1 AND status = 'pending_review'
+			RETURNING id, post_id, user_id, content, status, created_at
+		)
+		SELECT c.id, c.post_id, c.user_id, c.content, c.status, c.created_at, u.username
+		FROM c
+		JOIN users u ON u.id = c.user_id
+	`
+	ctx, cancel := withQueryTimeout(ctx, "CommentStore.SetStatus")
+	defer cancel()
+
+	var c models.Comment
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		commentID,
+		status,
+	).Scan(
+		&c.ID,
+		&c.PostID,
+		&c.UserID,
+		&c.Content,
+		&c.Status,
+		&c.CreatedAt,
+		&c.User.Username,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	c.User.ID = c.UserID
+	return &c, nil
+}
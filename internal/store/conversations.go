@@ -0,0 +1,713 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// visibleMessages filters the messages m the viewer ($1) can read: the
+// published ones and their own, hiding the ones written by users in a block
+// relationship with the viewer.
+const visibleMessages = `
+	(m.status = 'published' OR m.user_id = $1)
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.user_id = $1 AND b.blocked_id = m.user_id) OR (b.user_id = m.user_id AND b.blocked_id = $1)
+	)
+`
+
+// conversationSelect lists the conversations of the viewer ($1) along with
+// their last message and the number of unread messages.
+const conversationSelect = `
+	SELECT c.id, COALESCE(c.creator_id, 0), c.title, c.is_group, c.created_at, c.updated_at,
+		(
+			SELECT COUNT(*) FROM messages m
+			WHERE m.conversation_id = c.id AND m.user_id <> $1 AND m.id > COALESCE(cp.last_read_message_id, 0)
+			AND ` + visibleMessages + `
+		),
+		lm.id, lm.user_id, lm.username, lm.content, lm.status, lm.created_at
+	FROM conversations c
+	JOIN conversation_participants cp ON cp.conversation_id = c.id AND cp.user_id = $1
+	LEFT JOIN LATERAL (
+		SELECT m.id, m.user_id, u.username, m.content, m.status, m.created_at
+		FROM messages m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.conversation_id = c.id
+		AND ` + visibleMessages + `
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT 1
+	) lm ON true
+`
+
+// ConversationStore keeps the conversations, their participants and messages.
+type ConversationStore struct {
+	db *sql.DB
+}
+
+// Create starts the conversation between the creator and the participants.
+// ErrNotFound is returned when a participant doesn't exist, ErrBlocked when
+// one of them is in a block relationship with the creator and ErrConflict when
+// the two users of a one-to-one conversation already have one.
+func (s *ConversationStore) Create(ctx context.Context, conv *models.Conversation, participantIDs []int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.checkParticipants(ctx, tx, conv.CreatorID, participantIDs); err != nil {
+			return err
+		}
+
+		var key sql.NullString
+		if !conv.IsGroup {
+			key = sql.NullString{String: directKey(conv.CreatorID, participantIDs[0]), Valid: true}
+		}
+
+		if err := s.create(ctx, tx, conv, key); err != nil {
+			return err
+		}
+
+		return s.addParticipants(ctx, tx, conv.ID, append([]int64{conv.CreatorID}, participantIDs...))
+	})
+}
+
+// GetDirect returns the one-to-one conversation between the two users.
+func (s *ConversationStore) GetDirect(ctx context.Context, userID int64, otherID int64) (*models.Conversation, error) {
+	query := conversationSelect + `
+		WHERE c.direct_key = $2
+	`
+
+	conversations, err := s.query(ctx, query, userID, directKey(userID, otherID))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conversations) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &conversations[0], nil
+}
+
+// GetByID returns the conversation if userID takes part in it.
+func (s *ConversationStore) GetByID(ctx context.Context, conversationID int64, userID int64) (*models.Conversation, error) {
+	query := conversationSelect + `
+		WHERE c.id = $2
+	`
+
+	conversations, err := s.query(ctx, query, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conversations) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &conversations[0], nil
+}
+
+// GetByUserID returns a page of the conversations of the user, the ones with
+// the most recent messages first. The cursor is on updated_at, which moves with
+// every message: a conversation active while paging jumps above the cursor and
+// is only seen again from the first page.
+func (s *ConversationStore) GetByUserID(ctx context.Context, userID int64, cq CursorQuery) ([]models.Conversation, error) {
+	query := conversationSelect + `
+		WHERE ($2::TIMESTAMPTZ IS NULL OR (c.updated_at, c.id) < ($2, $3))
+		ORDER BY c.updated_at DESC, c.id DESC
+		LIMIT $4
+	`
+
+	var after sql.NullTime
+	var afterID int64
+
+	if cq.Cursor != "" {
+		cursor, err := DecodeCursor(cq.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		after = sql.NullTime{Time: cursor.Time, Valid: true}
+		afterID = cursor.ID
+	}
+
+	return s.query(ctx, query, userID, after, afterID, cq.Limit)
+}
+
+// CreateMessage sends the message to the conversation, it is read by its
+// sender. ErrBlocked is returned when the two users of a one-to-one
+// conversation are in a block relationship.
+func (s *ConversationStore) CreateMessage(ctx context.Context, msg *models.Message) error {
+	if msg.Status == "" {
+		msg.Status = models.StatusPublished
+	}
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		isGroup, blocked, err := s.getSendTarget(ctx, tx, msg.ConversationID, msg.UserID)
+		if err != nil {
+			return err
+		}
+
+		//? Groups stay usable, the messages are hidden from the blocked users instead
+		if !isGroup && blocked {
+			return ErrBlocked
+		}
+
+		if err := s.createMessage(ctx, tx, msg); err != nil {
+			return err
+		}
+
+		return s.markRead(ctx, tx, msg.ConversationID, msg.UserID, msg.ID)
+	})
+}
+
+// GetMessages returns a page of the messages of the conversation the viewer
+// can read, the most recent first.
+func (s *ConversationStore) GetMessages(ctx context.Context, conversationID int64, viewerID int64, cq CursorQuery) ([]models.Message, error) {
+	query := `
+		SELECT m.id, m.conversation_id, m.user_id, u.username, m.content, m.status, m.created_at
+		FROM messages m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.conversation_id = $2
+		AND ` + visibleMessages + `
+		AND ($3::TIMESTAMPTZ IS NULL OR (m.created_at, m.id) < ($3, $4))
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $5
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.GetMessages")
+	defer cancel()
+
+	var after sql.NullTime
+	var afterID int64
+
+	if cq.Cursor != "" {
+		cursor, err := DecodeCursor(cq.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		after = sql.NullTime{Time: cursor.Time, Valid: true}
+		afterID = cursor.ID
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		viewerID,
+		conversationID,
+		after,
+		afterID,
+		cq.Limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+
+	for rows.Next() {
+		var m models.Message
+
+		err := rows.Scan(
+			&m.ID,
+			&m.ConversationID,
+			&m.UserID,
+			&m.User.Username,
+			&m.Content,
+			&m.Status,
+			&m.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.User.ID = m.UserID
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+// MarkRead moves the read receipt of the user to the last message of the
+// conversation.
+func (s *ConversationStore) MarkRead(ctx context.Context, conversationID int64, userID int64) error {
+	query := `
+		UPDATE conversation_participants
+		SET last_read_message_id = GREATEST(
+				COALESCE(last_read_message_id, 0),
+				(SELECT COALESCE(MAX(id), 0) FROM messages WHERE conversation_id = $1 AND status = 'published')
+			),
+			last_read_at = NOW()
+		WHERE conversation_id = $1 AND user_id = $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.MarkRead")
+	defer cancel()
+
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		conversationID,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetPendingMessages returns a page of the messages held for review, the
+// oldest first unless sorted otherwise.
+func (s *ConversationStore) GetPendingMessages(ctx context.Context, fq PaginatedFeedQuery) ([]models.Message, error) {
+	query := `
+		SELECT m.id, m.conversation_id, m.user_id, u.username, m.content, m.status, m.created_at
+		FROM messages m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.status = 'pending_review'
+		ORDER BY m.created_at ` + fq.Sort + `, m.id ` + fq.Sort + `
+		LIMIT $1 OFFSET $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.GetPendingMessages")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+
+	for rows.Next() {
+		var m models.Message
+
+		err := rows.Scan(
+			&m.ID,
+			&m.ConversationID,
+			&m.UserID,
+			&m.User.Username,
+			&m.Content,
+			&m.Status,
+			&m.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.User.ID = m.UserID
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
+
+// SetMessageStatus stores the status of a message held for review and returns
+// it. Messages can't be edited, the ones that aren't pending anymore were
+// already reviewed and are not found. An approved message moves its
+// conversation up, as a new message would.
+func (s *ConversationStore) SetMessageStatus(ctx context.Context, messageID int64, status string) (*models.Message, error) {
+	query := `
+		WITH m AS (
+			UPDATE messages
+			SET status = $2
+			WHERE id = $1 AND status = 'pending_review'
+			RETURNING id, conversation_id, user_id, content, status, created_at
+		), bumped AS (
+			UPDATE conversations
+			SET updated_at = NOW()
+			WHERE id = (SELECT conversation_id FROM m) AND $2 = 'published'
+		)
+		SELECT m.id, m.conversation_id, m.user_id, u.username, m.content, m.status, m.created_at
+		FROM m
+		JOIN users u ON u.id = m.user_id
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.SetMessageStatus")
+	defer cancel()
+
+	var m models.Message
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		messageID,
+		status,
+	).Scan(
+		&m.ID,
+		&m.ConversationID,
+		&m.UserID,
+		&m.User.Username,
+		&m.Content,
+		&m.Status,
+		&m.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	m.User.ID = m.UserID
+	return &m, nil
+}
+
+// checkParticipants makes sure the participants exist and none of them is in
+// a block relationship with the creator.
+func (s *ConversationStore) checkParticipants(ctx context.Context, tx *sql.Tx, creatorID int64, participantIDs []int64) error {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE id = ANY($2) AND is_active = true),
+			EXISTS (
+				SELECT 1 FROM user_blocks b
+				WHERE (b.user_id = $1 AND b.blocked_id = ANY($2)) OR (b.user_id = ANY($2) AND b.blocked_id = $1)
+			)
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.checkParticipants")
+	defer cancel()
+
+	var found int
+	var blocked bool
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		creatorID,
+		pq.Array(participantIDs),
+	).Scan(
+		&found,
+		&blocked,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if found != len(participantIDs) {
+		return ErrNotFound
+	}
+
+	if blocked {
+		return ErrBlocked
+	}
+
+	return nil
+}
+
+func (s *ConversationStore) create(ctx context.Context, tx *sql.Tx, conv *models.Conversation, key sql.NullString) error {
+	query := `
+		INSERT INTO conversations (creator_id, title, is_group, direct_key)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.create")
+	defer cancel()
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		conv.CreatorID,
+		conv.Title,
+		conv.IsGroup,
+		key,
+	).Scan(
+		&conv.ID,
+		&conv.CreatedAt,
+		&conv.UpdatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case IsDuplicateKeyError(err):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ConversationStore) addParticipants(ctx context.Context, tx *sql.Tx, conversationID int64, userIDs []int64) error {
+	query := `
+		INSERT INTO conversation_participants (conversation_id, user_id)
+		SELECT $1, unnest($2::BIGINT[])
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.addParticipants")
+	defer cancel()
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		conversationID,
+		pq.Array(userIDs),
+	)
+
+	return err
+}
+
+// getSendTarget returns whether the conversation is a group and whether the
+// sender is in a block relationship with another participant. ErrNotFound is
+// returned when the sender doesn't take part in the conversation.
+func (s *ConversationStore) getSendTarget(ctx context.Context, tx *sql.Tx, conversationID int64, senderID int64) (bool, bool, error) {
+	query := `
+		SELECT c.is_group, EXISTS (
+			SELECT 1 FROM conversation_participants p
+			JOIN user_blocks b ON (b.user_id = $2 AND b.blocked_id = p.user_id) OR (b.user_id = p.user_id AND b.blocked_id = $2)
+			WHERE p.conversation_id = c.id AND p.user_id <> $2
+		)
+		FROM conversations c
+		JOIN conversation_participants cp ON cp.conversation_id = c.id AND cp.user_id = $2
+		WHERE c.id = $1
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.getSendTarget")
+	defer cancel()
+
+	var isGroup, blocked bool
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		conversationID,
+		senderID,
+	).Scan(
+		&isGroup,
+		&blocked,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, false, ErrNotFound
+		default:
+			return false, false, err
+		}
+	}
+
+	return isGroup, blocked, nil
+}
+
+func (s *ConversationStore) createMessage(ctx context.Context, tx *sql.Tx, msg *models.Message) error {
+	query := `
+		WITH message AS (
+			INSERT INTO messages (conversation_id, user_id, content, status)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at
+		), bumped AS (
+			UPDATE conversations
+			SET updated_at = (SELECT created_at FROM message)
+			WHERE id = $1 AND $4 = 'published'
+		)
+		SELECT id, created_at FROM message
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.createMessage")
+	defer cancel()
+
+	return tx.QueryRowContext(
+		ctx,
+		query,
+		msg.ConversationID,
+		msg.UserID,
+		msg.Content,
+		msg.Status,
+	).Scan(
+		&msg.ID,
+		&msg.CreatedAt,
+	)
+}
+
+// markRead moves the read receipt of the user to the message, never backwards.
+func (s *ConversationStore) markRead(ctx context.Context, tx *sql.Tx, conversationID int64, userID int64, messageID int64) error {
+	query := `
+		UPDATE conversation_participants
+		SET last_read_message_id = GREATEST(COALESCE(last_read_message_id, 0), $3), last_read_at = NOW()
+		WHERE conversation_id = $1 AND user_id = $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.markRead")
+	defer cancel()
+
+	res, err := tx.ExecContext(
+		ctx,
+		query,
+		conversationID,
+		userID,
+		messageID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// query runs a conversationSelect query and loads the participants of the
+// conversations found.
+func (s *ConversationStore) query(ctx context.Context, query string, args ...any) ([]models.Conversation, error) {
+	ctx, cancel := withQueryTimeout(ctx, "ConversationStore.query")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		args...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []models.Conversation{}
+
+	for rows.Next() {
+		var c models.Conversation
+		var last lastMessageScanner
+
+		err := rows.Scan(
+			&c.ID,
+			&c.CreatorID,
+			&c.Title,
+			&c.IsGroup,
+			&c.CreatedAt,
+			&c.UpdatedAt,
+			&c.UnreadCount,
+			&last.id,
+			&last.userID,
+			&last.username,
+			&last.content,
+			&last.status,
+			&last.createdAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.LastMessage = last.message(c.ID)
+		conversations = append(conversations, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadParticipants(ctx, conversations); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+func (s *ConversationStore) loadParticipants(ctx context.Context, conversations []models.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT cp.conversation_id, u.id, u.username, cp.last_read_message_id, cp.last_read_at, cp.joined_at
+		FROM conversation_participants cp
+		JOIN users u ON u.id = cp.user_id
+		WHERE cp.conversation_id = ANY($1)
+		ORDER BY cp.joined_at, u.id
+	`
+
+	index := make(map[int64]int, len(conversations))
+	ids := make([]int64, 0, len(conversations))
+
+	for i, c := range conversations {
+		index[c.ID] = i
+		ids = append(ids, c.ID)
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		pq.Array(ids),
+	)
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var conversationID int64
+		var p models.Participant
+
+		err := rows.Scan(
+			&conversationID,
+			&p.User.ID,
+			&p.User.Username,
+			&p.LastReadMessageID,
+			&p.LastReadAt,
+			&p.JoinedAt,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		c := &conversations[index[conversationID]]
+		c.Participants = append(c.Participants, p)
+	}
+
+	return rows.Err()
+}
+
+type lastMessageScanner struct {
+	id        sql.NullInt64
+	userID    sql.NullInt64
+	username  sql.NullString
+	content   sql.NullString
+	status    sql.NullString
+	createdAt sql.NullTime
+}
+
+func (sc *lastMessageScanner) message(conversationID int64) *models.Message {
+	if !sc.id.Valid {
+		return nil
+	}
+
+	return &models.Message{
+		ID:             sc.id.Int64,
+		ConversationID: conversationID,
+		UserID:         sc.userID.Int64,
+		Content:        sc.content.String,
+		Status:         sc.status.String,
+		User:           models.User{ID: sc.userID.Int64, Username: sc.username.String},
+		CreatedAt:      sc.createdAt.Time,
+	}
+}
+
+// directKey identifies the one-to-one conversation of two users, whoever
+// started it.
+func directKey(userID int64, otherID int64) string {
+	return fmt.Sprintf("%d:%d", min(userID, otherID), max(userID, otherID))
+}
@@ -1,8 +1,10 @@
 package store
 
 import (
+	"SocialMedia/internal/models"
 	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/lib/pq"
 )
@@ -11,15 +13,261 @@ type FollowerStore struct {
 	db *sql.DB
 }
 
-func (s *FollowerStore) Follow(ctx context.Context, userID int64, followedID int64) error {
+// Follow makes userID follow followedID. When the followed account is private
+// a follow request is created instead and pending is true.
+func (s *FollowerStore) Follow(ctx context.Context, userID int64, followedID int64) (bool, error) {
+	pending := false
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		target, err := s.getFollowTarget(ctx, tx, userID, followedID)
+		if err != nil {
+			return err
+		}
+
+		// A block in either direction prevents the follow
+		if target.blocked {
+			return ErrBlocked
+		}
+
+		if target.following {
+			return ErrConflict
+		}
+
+		if target.private {
+			pending = true
+			return s.createRequest(ctx, tx, userID, followedID)
+		}
+
+		return s.create(ctx, tx, userID, followedID)
+	})
+
+	return pending, err
+}
+
+// UnFollow removes the follow relationship, or cancels the pending follow
+// request if there is one.
+func (s *FollowerStore) UnFollow(ctx context.Context, userID int64, unfollowedID int64) error {
+	query := `
+		WITH unfollowed AS (
+			DELETE FROM followers
+			WHERE user_id = $1 AND follower_id = $2
+			RETURNING 1
+		), cancelled AS (
+			DELETE FROM follow_requests
+			WHERE user_id = $1 AND target_id = $2
+			RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM unfollowed) + (SELECT COUNT(*) FROM cancelled)
+	`
+	ctx, cancel := withQueryTimeout(ctx, "FollowerStore.UnFollow")
+	defer cancel()
+
+	var rows int64
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+		unfollowedID,
+	).Scan(&rows)
+
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// IsFollowing reports whether userID is an approved follower of followedID.
+func (s *FollowerStore) IsFollowing(ctx context.Context, userID int64, followedID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM followers
+			WHERE user_id = $1 AND follower_id = $2
+		)
+	`
+	ctx, cancel := withQueryTimeout(ctx, "FollowerStore.IsFollowing")
+	defer cancel()
+
+	var following bool
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+		followedID,
+	).Scan(&following)
+
+	if err != nil {
+		return false, err
+	}
+
+	return following, nil
+}
+
+// GetFollowingIDs returns the IDs of the users followed by userID.
+func (s *FollowerStore) GetFollowingIDs(ctx context.Context, userID int64) ([]int64, error) {
+	query := `
+		SELECT follower_id FROM followers
+		WHERE user_id = $1
+	`
+	ctx, cancel := withQueryTimeout(ctx, "FollowerStore.GetFollowingIDs")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetRequests returns the pending follow requests sent to the user.
+func (s *FollowerStore) GetRequests(ctx context.Context, targetID int64) ([]models.FollowRequest, error) {
+	query := `
+		SELECT fr.user_id, fr.target_id, fr.created_at, u.id, u.username
+		FROM follow_requests fr
+		JOIN users u ON u.id = fr.user_id
+		WHERE fr.target_id = $1
+		ORDER BY fr.created_at DESC
+	`
+	ctx, cancel := withQueryTimeout(ctx, "FollowerStore.GetRequests")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		targetID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.FollowRequest{}
+
+	for rows.Next() {
+		var fr models.FollowRequest
+
+		err := rows.Scan(
+			&fr.UserID,
+			&fr.TargetID,
+			&fr.CreatedAt,
+			&fr.User.ID,
+			&fr.User.Username,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, fr)
+	}
+
+	return requests, rows.Err()
+}
+
+// ApproveRequest turns the pending request of userID into a follow of targetID.
+func (s *FollowerStore) ApproveRequest(ctx context.Context, targetID int64, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.deleteRequest(ctx, tx, targetID, userID); err != nil {
+			return err
+		}
+
+		if err := s.create(ctx, tx, userID, targetID); err != nil && !errors.Is(err, ErrConflict) {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (s *FollowerStore) RejectRequest(ctx context.Context, targetID int64, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return s.deleteRequest(ctx, tx, targetID, userID)
+	})
+}
+
+type followTarget struct {
+	private   bool
+	blocked   bool
+	following bool
+}
+
+func (s *FollowerStore) getFollowTarget(ctx context.Context, tx *sql.Tx, userID int64, followedID int64) (*followTarget, error) {
+	query := `
+		SELECT u.is_private,
+			EXISTS (
+				SELECT 1 FROM user_blocks
+				WHERE (user_id = $1 AND blocked_id = $2) OR (user_id = $2 AND blocked_id = $1)
+			),
+			EXISTS (
+				SELECT 1 FROM followers
+				WHERE user_id = $1 AND follower_id = $2
+			)
+		FROM users u
+		WHERE u.id = $2 AND u.is_active = true
+	`
+	ctx, cancel := withQueryTimeout(ctx, "FollowerStore.getFollowTarget")
+	defer cancel()
+
+	target := &followTarget{}
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		userID,
+		followedID,
+	).Scan(
+		&target.private,
+		&target.blocked,
+		&target.following,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return target, nil
+}
+
+func (s *FollowerStore) create(ctx context.Context, tx *sql.Tx, userID int64, followedID int64) error {
 	query := `
 		INSERT INTO followers(user_id, follower_id)
 		VALUES ($1, $2)
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "FollowerStore.create")
 	defer cancel()
 
-	_, err := s.db.ExecContext(
+	_, err := tx.ExecContext(
 		ctx,
 		query,
 		userID,
@@ -38,31 +286,58 @@ func (s *FollowerStore) Follow(ctx context.Context, userID int64, followedID int
 	return nil
 }
 
-func (s *FollowerStore) UnFollow(ctx context.Context, userID int64, unfollowedID int64) error {
+func (s *FollowerStore) createRequest(ctx context.Context, tx *sql.Tx, userID int64, targetID int64) error {
 	query := `
-		DELETE FROM followers
-		WHERE user_id = $1 AND follower_id = $2
+		INSERT INTO follow_requests(user_id, target_id)
+		VALUES ($1, $2)
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "FollowerStore.createRequest")
 	defer cancel()
 
-	rows, err := s.db.ExecContext(
+	_, err := tx.ExecContext(
 		ctx,
 		query,
 		userID,
-		unfollowedID,
+		targetID,
+	)
+
+	if err != nil {
+		switch {
+		case IsDuplicateKeyError(err):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *FollowerStore) deleteRequest(ctx context.Context, tx *sql.Tx, targetID int64, userID int64) error {
+	query := `
+		DELETE FROM follow_requests
+		WHERE user_id = $1 AND target_id = $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "FollowerStore.deleteRequest")
+	defer cancel()
+
+	res, err := tx.ExecContext(
+		ctx,
+		query,
+		userID,
+		targetID,
 	)
 
 	if err != nil {
 		return err
 	}
 
-	row, err := rows.RowsAffected()
+	rows, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
 
-	if row == 0 {
+	if rows == 0 {
 		return ErrNotFound
 	}
 
@@ -77,3 +352,13 @@ func IsDuplicateKeyError(err error) bool {
 
 	return false
 }
+
+// IsForeignKeyError tells whether the row references a row that doesn't exist,
+// such as a user that was never created or was deleted.
+func IsForeignKeyError(err error) bool {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// HashtagStore indexes the hashtags of posts and comments.
+type HashtagStore struct {
+	db *sql.DB
+}
+
+// SetPostHashtags replaces the hashtags of the post. Hashtags kept by an edit
+// keep their original date so editing doesn't make a tag trend.
+func (s *HashtagStore) SetPostHashtags(ctx context.Context, postID int64, tags []string) error {
+	query := `
+		WITH removed AS (
+			DELETE FROM post_hashtags
+			WHERE post_id = $1 AND NOT (tag = ANY($2))
+		)
+		INSERT INTO post_hashtags (post_id, tag)
+		SELECT $1, unnest($2::VARCHAR[])
+		ON CONFLICT DO NOTHING
+	`
+	ctx, cancel := withQueryTimeout(ctx, "HashtagStore.SetPostHashtags")
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		postID,
+		pq.Array(tags),
+	)
+
+	return err
+}
+
+func (s *HashtagStore) AddCommentHashtags(ctx context.Context, commentID int64, tags []string) error {
+	query := `
+		INSERT INTO comment_hashtags (comment_id, tag)
+		SELECT $1, unnest($2::VARCHAR[])
+		ON CONFLICT DO NOTHING
+	`
+	ctx, cancel := withQueryTimeout(ctx, "HashtagStore.AddCommentHashtags")
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		commentID,
+		pq.Array(tags),
+	)
+
+	return err
+}
+
+// GetPosts lists the posts using the hashtag that the viewer is allowed to
+// see. Unlisted posts are never listed, posts held for review only to their
+// author.
+func (s *HashtagStore) GetPosts(ctx context.Context, tag string, viewerID int64, fq PaginatedFeedQuery) (*[]models.PostWithMetadata, error) {
+	query := `
+		SELECT
+			p.id, p.user_id, u.username, u.email, p.title, p.content, p.tags, COUNT(c.id) AS comments_count, p.created_at, p.version, p.status, p.visibility
+		FROM post_hashtags h
+		JOIN posts p on p.id = h.post_id
+		JOIN users u on p.user_id = u.id
+		LEFT JOIN comments c on c.post_id = p.id AND c.status = 'published'
+		WHERE h.tag = $1
+		AND (
+			p.user_id = $2
+			OR (
+				p.status = 'published' AND p.visibility <> 'unlisted'
+				AND (
+					(p.visibility = 'public' AND NOT u.is_private)
+					OR EXISTS (SELECT 1 FROM followers f WHERE f.user_id = $2 AND f.follower_id = p.user_id)
+				)
+			)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.user_id = $2 AND b.blocked_id = p.user_id) OR (b.user_id = p.user_id AND b.blocked_id = $2)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_mutes m
+			WHERE m.user_id = $2 AND m.muted_id = p.user_id
+		)
+		AND (p.title ILIKE $5 OR p.content ILIKE $5)
+		GROUP BY p.id, u.username, u.email
+		ORDER BY p.created_at ` + fq.Sort + `
+		LIMIT $3 OFFSET $4
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "HashtagStore.GetPosts")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		tag,
+		viewerID,
+		fq.Limit,
+		fq.Offset,
+		fmt.Sprintf("%%%s%%", fq.Search),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []models.PostWithMetadata{}
+	for rows.Next() {
+		var p models.PostWithMetadata
+
+		err := rows.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.User.Username,
+			&p.User.Email,
+			&p.Title,
+			&p.Content,
+			pq.Array(&p.Tags),
+			&p.CommentCount,
+			&p.CreatedAt,
+			&p.Version,
+			&p.Status,
+			&p.Visibility,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachToPosts(ctx, s.db, posts); err != nil {
+		return nil, err
+	}
+
+	return &posts, nil
+}
+
+// GetTrending ranks the hashtags used in public posts and their comments over
+// the last window of time. Tags are ranked by the number of distinct users
+// first so a single user repeating a tag can't make it trend.
+func (s *HashtagStore) GetTrending(ctx context.Context, window time.Duration, limit int) ([]models.TrendingTag, error) {
+	query := `
+		SELECT t.tag, COUNT(DISTINCT t.user_id) AS users, COUNT(*) AS uses
+		FROM (
+			SELECT h.tag, p.user_id
+			FROM post_hashtags h
+			JOIN posts p ON p.id = h.post_id
+			JOIN users u ON u.id = p.user_id
+			WHERE h.created_at > NOW() - make_interval(secs => $1)
+			AND p.status = 'published' AND p.visibility = 'public' AND NOT u.is_private
+			UNION ALL
+			SELECT h.tag, c.user_id
+			FROM comment_hashtags h
+			JOIN comments c ON c.id = h.comment_id
+			JOIN posts p ON p.id = c.post_id
+			JOIN users u ON u.id = p.user_id
+			WHERE h.created_at > NOW() - make_interval(secs => $1)
+			AND c.status = 'published' AND p.status = 'published' AND p.visibility = 'public' AND NOT u.is_private
+		) t
+		GROUP BY t.tag
+		ORDER BY users DESC, uses DESC, t.tag
+		LIMIT $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "HashtagStore.GetTrending")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		window.Seconds(),
+		limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.TrendingTag{}
+
+	for rows.Next() {
+		var t models.TrendingTag
+
+		err := rows.Scan(
+			&t.Tag,
+			&t.Users,
+			&t.Uses,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LinkPreviewStore caches the previews of the links shared in posts. Previews
+// are shared by every post linking to the same URL.
+type LinkPreviewStore struct {
+	db *sql.DB
+}
+
+// SetPostLinks replaces the links of the post, in the order they appear in it.
+func (s *LinkPreviewStore) SetPostLinks(ctx context.Context, postID int64, urls []string) error {
+	query := `
+		WITH removed AS (
+			DELETE FROM post_links
+			WHERE post_id = $1 AND NOT (url = ANY($2))
+		)
+		INSERT INTO post_links (post_id, url, position)
+		SELECT $1, l.url, l.position
+		FROM unnest($2::TEXT[]) WITH ORDINALITY AS l(url, position)
+		ON CONFLICT (post_id, url) DO UPDATE SET position = EXCLUDED.position
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "LinkPreviewStore.SetPostLinks")
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		postID,
+		pq.Array(urls),
+	)
+
+	return err
+}
+
+// GetStale returns the URLs that have no preview or one fetched longer than
+// maxAge ago.
+func (s *LinkPreviewStore) GetStale(ctx context.Context, urls []string, maxAge time.Duration) ([]string, error) {
+	query := `
+		SELECT l.url
+		FROM unnest($1::TEXT[]) AS l(url)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM link_previews p
+			WHERE p.url = l.url AND p.fetched_at > NOW() - make_interval(secs => $2)
+		)
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "LinkPreviewStore.GetStale")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		pq.Array(urls),
+		maxAge.Seconds(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stale := []string{}
+
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+
+		stale = append(stale, url)
+	}
+
+	return stale, rows.Err()
+}
+
+// Save stores the preview of the URL, replacing the previous one.
+func (s *LinkPreviewStore) Save(ctx context.Context, p *models.LinkPreview) error {
+	query := `
+		INSERT INTO link_previews (url, title, description, image_url, site_name, failed, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (url) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description, image_url = EXCLUDED.image_url,
+			site_name = EXCLUDED.site_name, failed = EXCLUDED.failed, fetched_at = EXCLUDED.fetched_at
+		RETURNING fetched_at
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "LinkPreviewStore.Save")
+	defer cancel()
+
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		p.URL,
+		p.Title,
+		p.Description,
+		p.ImageURL,
+		p.SiteName,
+		p.Failed,
+	).Scan(
+		&p.FetchedAt,
+	)
+}
+
+// GetByPostIDs returns the previews of the links of several posts, grouped by
+// post in the order of the links. Links that couldn't be unfurled are left
+// out.
+func (s *LinkPreviewStore) GetByPostIDs(ctx context.Context, postIDs []int64) (map[int64][]models.LinkPreview, error) {
+	query := `
+		SELECT l.post_id, p.url, p.title, p.description, p.image_url, p.site_name, p.fetched_at
+		FROM post_links l
+		JOIN link_previews p ON p.url = l.url
+		WHERE l.post_id = ANY($1) AND NOT p.failed
+		ORDER BY l.post_id, l.position
+	`
+
+	previews := map[int64][]models.LinkPreview{}
+	if len(postIDs) == 0 {
+		return previews, nil
+	}
+
+	ctx, cancel := withQueryTimeout(ctx, "LinkPreviewStore.GetByPostIDs")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		pq.Array(postIDs),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int64
+		var p models.LinkPreview
+
+		err := rows.Scan(
+			&postID,
+			&p.URL,
+			&p.Title,
+			&p.Description,
+			&p.ImageURL,
+			&p.SiteName,
+			&p.FetchedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		previews[postID] = append(previews[postID], p)
+	}
+
+	return previews, rows.Err()
+}
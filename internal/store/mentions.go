@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// MentionStore records the users mentioned in posts and comments.
+type MentionStore struct {
+	db *sql.DB
+}
+
+// SetPostMentions replaces the users mentioned by the post and returns the ones
+// that were not mentioned yet, so editing a post only notifies the new mentions.
+func (s *MentionStore) SetPostMentions(ctx context.Context, postID int64, userIDs []int64) ([]int64, error) {
+	query := `
+		WITH removed AS (
+			DELETE FROM post_mentions
+			WHERE post_id = $1 AND NOT (user_id = ANY($2))
+		)
+		INSERT INTO post_mentions (post_id, user_id)
+		SELECT $1, unnest($2::BIGINT[])
+		ON CONFLICT DO NOTHING
+		RETURNING user_id
+	`
+	ctx, cancel := withQueryTimeout(ctx, "MentionStore.SetPostMentions")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		postID,
+		pq.Array(userIDs),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	added := []int64{}
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		added = append(added, id)
+	}
+
+	return added, rows.Err()
+}
+
+func (s *MentionStore) AddCommentMentions(ctx context.Context, commentID int64, userIDs []int64) error {
+	query := `
+		INSERT INTO comment_mentions (comment_id, user_id)
+		SELECT $1, unnest($2::BIGINT[])
+		ON CONFLICT DO NOTHING
+	`
+	ctx, cancel := withQueryTimeout(ctx, "MentionStore.AddCommentMentions")
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		commentID,
+		pq.Array(userIDs),
+	)
+
+	return err
+}
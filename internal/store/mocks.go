@@ -2,24 +2,65 @@ package store
 
 import (
 	"SocialMedia/internal/models"
+	"cmp"
 	"context"
 	"database/sql"
+	"slices"
+	"sync"
 	"time"
 )
 
 func NewMockStore() Storage {
+	attachments := &MockAttachmentStore{}
+	followers := &MockFollowerStore{}
+	users := &MockUserStore{followers: followers}
+	followers.users = users
+
 	return Storage{
-		Users: &MockUserStore{},
+		Posts:                   &MockPostStore{attachments: attachments},
+		Attachments:             attachments,
+		Comments:                &MockCommentStore{},
+		Users:                   users,
+		Roles:                   &MockRoleStore{},
+		Suspensions:             &MockSuspensionStore{users: users},
+		Blocks:                  &MockBlockStore{users: users, followers: followers},
+		Mutes:                   &MockMuteStore{users: users},
+		Followers:               followers,
+		Outbox:                  &MockOutboxStore{},
+		NotificationPreferences: &MockNotificationPreferenceStore{},
+		Notifications:           &MockNotificationStore{},
+		Conversations:           &MockConversationStore{},
+		Hashtags:                &MockHashtagStore{},
+		LinkPreviews:            &MockLinkPreviewStore{},
 	}
 }
 
-type MockUserStore struct{}
+// MockUserStore hands out the Users set by ID, the Missing IDs aren't found and
+// any other ID is a plain user. Updated settings and suspensions are kept in
+// Users.
+type MockUserStore struct {
+	sync.Mutex
+	Users     map[int64]models.User
+	Missing   map[int64]bool
+	followers *MockFollowerStore
+}
 
 func (m *MockUserStore) Create(ctx context.Context, tx *sql.Tx, u *models.User) error {
 	return nil
 }
 
 func (m *MockUserStore) GetByID(ctx context.Context, userID int64) (*models.User, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if user, ok := m.Users[userID]; ok {
+		return &user, nil
+	}
+
+	if m.Missing[userID] {
+		return nil, ErrNotFound
+	}
+
 	return &models.User{ID: userID}, nil
 }
 
@@ -27,10 +68,34 @@ func (m *MockUserStore) GetByEmail(context.Context, string) (*models.User, error
 	return &models.User{}, nil
 }
 
-func (m *MockUserStore) CreateAndInvite(ctx context.Context, user *models.User, token string, exp time.Duration) error {
+func (m *MockUserStore) GetByUsernames(ctx context.Context, usernames []string) ([]models.User, error) {
+	return []models.User{}, nil
+}
+
+func (m *MockUserStore) CreateAndInvite(ctx context.Context, user *models.User, token string, exp time.Duration, mail *models.OutboxMail) error {
 	return nil
 }
 
+func (m *MockUserStore) UpdateSettings(ctx context.Context, u *models.User) ([]int64, error) {
+	m.set(*u)
+
+	if u.IsPrivate {
+		return []int64{}, nil
+	}
+
+	return m.followers.approveAll(u.ID), nil
+}
+
+func (m *MockUserStore) set(user models.User) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.Users == nil {
+		m.Users = map[int64]models.User{}
+	}
+	m.Users[user.ID] = user
+}
+
 func (m *MockUserStore) Activate(ctx context.Context, t string) error {
 	return nil
 }
@@ -38,3 +103,993 @@ func (m *MockUserStore) Activate(ctx context.Context, t string) error {
 func (m *MockUserStore) Delete(ctx context.Context, id int64) error {
 	return nil
 }
+
+// MockSuspensionStore sets the suspensions on the users of the mock user
+// store, the way they are joined to the users by the database.
+type MockSuspensionStore struct {
+	sync.Mutex
+	lastID int64
+	users  *MockUserStore
+}
+
+func (m *MockSuspensionStore) Create(ctx context.Context, suspension *models.Suspension) error {
+	m.Lock()
+	m.lastID++
+	suspension.ID = m.lastID
+	suspension.CreatedAt = time.Now().Format(time.RFC3339)
+	m.Unlock()
+
+	user, err := m.users.GetByID(ctx, suspension.UserID)
+	if err != nil {
+		return err
+	}
+
+	user.Suspension = suspension
+	m.users.set(*user)
+
+	return nil
+}
+
+func (m *MockSuspensionStore) Lift(ctx context.Context, userID int64) error {
+	user, err := m.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.Suspension.IsActive() {
+		return ErrNotFound
+	}
+
+	user.Suspension = nil
+	m.users.set(*user)
+
+	return nil
+}
+
+// MockRoleStore has the roles seeded by the migrations.
+type MockRoleStore struct{}
+
+func (m *MockRoleStore) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	for level, role := range []string{"user", "moderator", "admin"} {
+		if role == name {
+			return &models.Role{ID: int64(level + 1), Name: role, Level: level + 1}, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// MockBlockStore keeps the blocks in memory, keyed by the user then the
+// blocked user. Blocking removes the follows of the mock follower store.
+type MockBlockStore struct {
+	sync.Mutex
+	Blocked   map[[2]int64]bool
+	users     *MockUserStore
+	followers *MockFollowerStore
+}
+
+func (m *MockBlockStore) Block(ctx context.Context, userID int64, blockedID int64) error {
+	if _, err := m.users.GetByID(ctx, blockedID); err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.Blocked[[2]int64{userID, blockedID}] {
+		return ErrConflict
+	}
+
+	if m.Blocked == nil {
+		m.Blocked = map[[2]int64]bool{}
+	}
+	m.Blocked[[2]int64{userID, blockedID}] = true
+
+	m.followers.unfollowBoth(userID, blockedID)
+
+	return nil
+}
+
+func (m *MockBlockStore) Unblock(ctx context.Context, userID int64, blockedID int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if !m.Blocked[[2]int64{userID, blockedID}] {
+		return ErrNotFound
+	}
+	delete(m.Blocked, [2]int64{userID, blockedID})
+
+	return nil
+}
+
+func (m *MockBlockStore) IsBlocked(ctx context.Context, userID int64, otherID int64) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.Blocked[[2]int64{userID, otherID}] || m.Blocked[[2]int64{otherID, userID}], nil
+}
+
+func (m *MockBlockStore) GetBlockedIDs(ctx context.Context, userID int64) ([]int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	ids := []int64{}
+	for pair := range m.Blocked {
+		switch userID {
+		case pair[0]:
+			ids = append(ids, pair[1])
+		case pair[1]:
+			ids = append(ids, pair[0])
+		}
+	}
+
+	return ids, nil
+}
+
+// MockMuteStore keeps the mutes in memory, keyed by the user then the muted
+// user.
+type MockMuteStore struct {
+	sync.Mutex
+	Muted map[[2]int64]bool
+	users *MockUserStore
+}
+
+func (m *MockMuteStore) Mute(ctx context.Context, userID int64, mutedID int64) error {
+	if _, err := m.users.GetByID(ctx, mutedID); err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.Muted[[2]int64{userID, mutedID}] {
+		return ErrConflict
+	}
+
+	if m.Muted == nil {
+		m.Muted = map[[2]int64]bool{}
+	}
+	m.Muted[[2]int64{userID, mutedID}] = true
+
+	return nil
+}
+
+func (m *MockMuteStore) Unmute(ctx context.Context, userID int64, mutedID int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if !m.Muted[[2]int64{userID, mutedID}] {
+		return ErrNotFound
+	}
+	delete(m.Muted, [2]int64{userID, mutedID})
+
+	return nil
+}
+
+func (m *MockMuteStore) IsMuted(ctx context.Context, userID int64, mutedID int64) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.Muted[[2]int64{userID, mutedID}], nil
+}
+
+func (m *MockMuteStore) GetMutedIDs(ctx context.Context, userID int64) ([]int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	ids := []int64{}
+	for pair := range m.Muted {
+		if pair[0] == userID {
+			ids = append(ids, pair[1])
+		}
+	}
+
+	return ids, nil
+}
+
+// MockFollowerStore keeps the follows and the follow requests in memory, keyed
+// by the follower then the followed user. Following can be set to the IDs
+// returned by GetFollowingIDs.
+type MockFollowerStore struct {
+	sync.Mutex
+	Follows   map[[2]int64]bool
+	Requests  map[[2]int64]bool
+	Following []int64
+	users     *MockUserStore
+}
+
+func (m *MockFollowerStore) Follow(ctx context.Context, userID int64, followedID int64) (bool, error) {
+	target, err := m.users.GetByID(ctx, followedID)
+	if err != nil {
+		return false, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	key := [2]int64{userID, followedID}
+	if m.Follows[key] || m.Requests[key] {
+		return false, ErrConflict
+	}
+
+	if target.IsPrivate {
+		if m.Requests == nil {
+			m.Requests = map[[2]int64]bool{}
+		}
+		m.Requests[key] = true
+
+		return true, nil
+	}
+
+	if m.Follows == nil {
+		m.Follows = map[[2]int64]bool{}
+	}
+	m.Follows[key] = true
+
+	return false, nil
+}
+
+func (m *MockFollowerStore) UnFollow(ctx context.Context, userID int64, unfollowedID int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	key := [2]int64{userID, unfollowedID}
+	if !m.Follows[key] && !m.Requests[key] {
+		return ErrNotFound
+	}
+	delete(m.Follows, key)
+	delete(m.Requests, key)
+
+	return nil
+}
+
+func (m *MockFollowerStore) IsFollowing(ctx context.Context, userID int64, followedID int64) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.Follows[[2]int64{userID, followedID}], nil
+}
+
+func (m *MockFollowerStore) unfollowBoth(userID int64, otherID int64) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, key := range [][2]int64{{userID, otherID}, {otherID, userID}} {
+		delete(m.Follows, key)
+		delete(m.Requests, key)
+	}
+}
+
+// approveAll turns the requests sent to the target into follows.
+func (m *MockFollowerStore) approveAll(targetID int64) []int64 {
+	m.Lock()
+	defer m.Unlock()
+
+	approved := []int64{}
+	for key := range m.Requests {
+		if key[1] != targetID {
+			continue
+		}
+
+		delete(m.Requests, key)
+		if !m.Follows[key] {
+			if m.Follows == nil {
+				m.Follows = map[[2]int64]bool{}
+			}
+			m.Follows[key] = true
+			approved = append(approved, key[0])
+		}
+	}
+
+	slices.Sort(approved)
+	return approved
+}
+
+func (m *MockFollowerStore) GetFollowingIDs(ctx context.Context, userID int64) ([]int64, error) {
+	return m.Following, nil
+}
+
+func (m *MockFollowerStore) GetRequests(ctx context.Context, targetID int64) ([]models.FollowRequest, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	requests := []models.FollowRequest{}
+	for key := range m.Requests {
+		if key[1] == targetID {
+			requests = append(requests, models.FollowRequest{UserID: key[0], TargetID: targetID})
+		}
+	}
+
+	slices.SortFunc(requests, func(a, b models.FollowRequest) int {
+		return int(a.UserID - b.UserID)
+	})
+
+	return requests, nil
+}
+
+func (m *MockFollowerStore) ApproveRequest(ctx context.Context, targetID int64, userID int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	key := [2]int64{userID, targetID}
+	if !m.Requests[key] {
+		return ErrNotFound
+	}
+	delete(m.Requests, key)
+
+	if m.Follows == nil {
+		m.Follows = map[[2]int64]bool{}
+	}
+	m.Follows[key] = true
+
+	return nil
+}
+
+func (m *MockFollowerStore) RejectRequest(ctx context.Context, targetID int64, userID int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	key := [2]int64{userID, targetID}
+	if !m.Requests[key] {
+		return ErrNotFound
+	}
+	delete(m.Requests, key)
+
+	return nil
+}
+
+// MockOutboxStore hands out the Pending emails on Claim and records what
+// happened to each of them.
+type MockOutboxStore struct {
+	sync.Mutex
+	Pending []models.OutboxMail
+	Sent    []int64
+	Failed  []int64
+	Dead    []int64
+}
+
+func (m *MockOutboxStore) Enqueue(ctx context.Context, mail *models.OutboxMail) error {
+	m.Lock()
+	defer m.Unlock()
+
+	mail.ID = int64(len(m.Pending) + 1)
+	m.Pending = append(m.Pending, *mail)
+	return nil
+}
+
+func (m *MockOutboxStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMail, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	n := min(limit, len(m.Pending))
+	claimed := m.Pending[:n]
+	m.Pending = m.Pending[n:]
+
+	for i := range claimed {
+		claimed[i].Attempts++
+	}
+
+	return claimed, nil
+}
+
+func (m *MockOutboxStore) MarkSent(ctx context.Context, mail *models.OutboxMail) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.Sent = append(m.Sent, mail.ID)
+	return nil
+}
+
+func (m *MockOutboxStore) MarkFailed(ctx context.Context, mail *models.OutboxMail, reason string, retryAt time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.Failed = append(m.Failed, mail.ID)
+	return nil
+}
+
+func (m *MockOutboxStore) MarkDead(ctx context.Context, mail *models.OutboxMail, reason string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.Dead = append(m.Dead, mail.ID)
+	return nil
+}
+
+type MockNotificationPreferenceStore struct {
+	sync.Mutex
+	Preferences map[int64]models.NotificationPreferences
+}
+
+func (m *MockNotificationPreferenceStore) Get(ctx context.Context, userID int64) (*models.NotificationPreferences, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if prefs, ok := m.Preferences[userID]; ok {
+		return &prefs, nil
+	}
+
+	return models.DefaultNotificationPreferences(userID), nil
+}
+
+func (m *MockNotificationPreferenceStore) Update(ctx context.Context, prefs *models.NotificationPreferences) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.Preferences == nil {
+		m.Preferences = map[int64]models.NotificationPreferences{}
+	}
+
+	m.Preferences[prefs.UserID] = *prefs
+	return nil
+}
+
+func (m *MockNotificationPreferenceStore) GetDueDigests(ctx context.Context, period time.Duration, limit int) ([]models.DigestRecipient, error) {
+	return []models.DigestRecipient{}, nil
+}
+
+func (m *MockNotificationPreferenceStore) CompleteDigest(ctx context.Context, recipient *models.DigestRecipient, mail *models.OutboxMail) error {
+	return nil
+}
+
+// MockNotificationStore keeps the notifications in memory, grouping them the
+// same way the database does.
+type MockNotificationStore struct {
+	sync.Mutex
+	Notifications []models.Notification
+
+	// distinct actors of each notification
+	actors map[int64]map[int64]bool
+}
+
+func (m *MockNotificationStore) Create(ctx context.Context, n *models.Notification) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.actors == nil {
+		m.actors = map[int64]map[int64]bool{}
+	}
+
+	for i, existing := range m.Notifications {
+		if existing.UserID == n.UserID && existing.GroupKey == n.GroupKey && existing.ReadAt == nil {
+			m.actors[existing.ID][n.Actor.ID] = true
+
+			existing.Actor = n.Actor
+			existing.ActorCount = len(m.actors[existing.ID])
+			existing.UpdatedAt = time.Now()
+			m.Notifications[i] = existing
+
+			*n = existing
+			return nil
+		}
+	}
+
+	n.ID = int64(len(m.Notifications) + 1)
+	n.ActorCount = 1
+	m.actors[n.ID] = map[int64]bool{n.Actor.ID: true}
+	n.CreatedAt = time.Now()
+	n.UpdatedAt = n.CreatedAt
+	m.Notifications = append(m.Notifications, *n)
+
+	return nil
+}
+
+func (m *MockNotificationStore) GetByUserID(ctx context.Context, userID int64, cq CursorQuery) ([]models.Notification, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	notifications := []models.Notification{}
+
+	for _, n := range m.Notifications {
+		if n.UserID == userID {
+			notifications = append(notifications, n)
+		}
+	}
+
+	return notifications, nil
+}
+
+func (m *MockNotificationStore) CountUnread(ctx context.Context, userID int64) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	count := 0
+	for _, n := range m.Notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *MockNotificationStore) MarkRead(ctx context.Context, userID int64, notificationID int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for i, n := range m.Notifications {
+		if n.ID == notificationID && n.UserID == userID {
+			now := time.Now()
+			m.Notifications[i].ReadAt = &now
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (m *MockNotificationStore) MarkAllRead(ctx context.Context, userID int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	for i, n := range m.Notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			m.Notifications[i].ReadAt = &now
+		}
+	}
+
+	return nil
+}
+
+// MockPostStore returns the Posts set by ID, or a published public post of
+// user 1 for any other ID, along with the attachments stored in the mock
+// attachment store.
+type MockPostStore struct {
+	sync.Mutex
+	Posts       map[int64]models.Post
+	attachments *MockAttachmentStore
+}
+
+func (m *MockPostStore) Create(ctx context.Context, post *models.Post) error {
+	return nil
+}
+
+func (m *MockPostStore) GetByID(ctx context.Context, postID int64) (*models.Post, error) {
+	m.Lock()
+	post, ok := m.Posts[postID]
+	m.Unlock()
+
+	if ok {
+		post.Attachments = m.attachments.byPost(postID)
+		return &post, nil
+	}
+
+	return &models.Post{
+		ID:          postID,
+		UserID:      1,
+		Status:      models.StatusPublished,
+		Visibility:  models.VisibilityPublic,
+		Attachments: m.attachments.byPost(postID),
+	}, nil
+}
+
+func (m *MockPostStore) DeleteByID(ctx context.Context, postID int64) error {
+	return nil
+}
+
+func (m *MockPostStore) PatchPost(ctx context.Context, post *models.Post) error {
+	return nil
+}
+
+func (m *MockPostStore) GetUserFeed(ctx context.Context, userID int64, fq PaginatedFeedQuery) (*[]models.PostWithMetadata, error) {
+	return &[]models.PostWithMetadata{}, nil
+}
+
+func (m *MockPostStore) GetByUserID(ctx context.Context, authorID int64, viewerID int64, fq PaginatedFeedQuery) (*[]models.PostWithMetadata, error) {
+	return &[]models.PostWithMetadata{}, nil
+}
+
+func (m *MockPostStore) GetPending(ctx context.Context, fq PaginatedFeedQuery) ([]models.Post, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	posts := []models.Post{}
+	for _, p := range m.Posts {
+		if p.Status == models.StatusPendingReview {
+			posts = append(posts, p)
+		}
+	}
+
+	slices.SortFunc(posts, func(a, b models.Post) int { return cmp.Compare(a.ID, b.ID) })
+
+	return posts[:min(fq.Limit, len(posts))], nil
+}
+
+func (m *MockPostStore) SetStatus(ctx context.Context, post *models.Post) error {
+	m.Lock()
+	defer m.Unlock()
+
+	stored, ok := m.Posts[post.ID]
+	if !ok || stored.Version != post.Version {
+		return ErrConflict
+	}
+
+	post.Version++
+	stored.Status = post.Status
+	stored.Version = post.Version
+	m.Posts[post.ID] = stored
+
+	return nil
+}
+
+// MockCommentStore keeps the created comments in memory, they are only used
+// by the moderation queue.
+type MockCommentStore struct {
+	sync.Mutex
+	comments []models.Comment
+}
+
+func (m *MockCommentStore) Create(ctx context.Context, comment *models.Comment) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if comment.Status == "" {
+		comment.Status = models.StatusPublished
+	}
+
+	comment.ID = int64(len(m.comments) + 1)
+	comment.CreatedAt = time.Now().Format(time.RFC3339)
+	m.comments = append(m.comments, *comment)
+
+	return nil
+}
+
+func (m *MockCommentStore) GetByPostID(ctx context.Context, postID int64, viewerID int64) (*[]models.Comment, error) {
+	return &[]models.Comment{}, nil
+}
+
+func (m *MockCommentStore) GetPending(ctx context.Context, fq PaginatedFeedQuery) ([]models.Comment, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	comments := []models.Comment{}
+	for _, c := range m.comments {
+		if c.Status == models.StatusPendingReview {
+			comments = append(comments, c)
+		}
+	}
+
+	return comments[:min(fq.Limit, len(comments))], nil
+}
+
+func (m *MockCommentStore) SetStatus(ctx context.Context, commentID int64, status string) (*models.Comment, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	for i, c := range m.comments {
+		if c.ID == commentID && c.Status == models.StatusPendingReview {
+			m.comments[i].Status = status
+			c.Status = status
+			return &c, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// MockAttachmentStore keeps the attachments in memory.
+type MockAttachmentStore struct {
+	sync.Mutex
+	attachments []models.Attachment
+	lastID      int64
+}
+
+func (m *MockAttachmentStore) Create(ctx context.Context, a *models.Attachment, max int) error {
+	m.Lock()
+	defer m.Unlock()
+
+	count := 0
+	for _, existing := range m.attachments {
+		if existing.PostID == a.PostID {
+			count++
+		}
+	}
+
+	if count >= max {
+		return ErrConflict
+	}
+
+	m.lastID++
+	a.ID = m.lastID
+	a.CreatedAt = time.Now().Format(time.RFC3339)
+
+	m.attachments = append(m.attachments, *a)
+	return nil
+}
+
+func (m *MockAttachmentStore) Delete(ctx context.Context, postID int64, attachmentID int64) (*models.Attachment, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	for i, a := range m.attachments {
+		if a.ID == attachmentID && a.PostID == postID {
+			m.attachments = slices.Delete(m.attachments, i, i+1)
+			return &a, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (m *MockAttachmentStore) GetPostIDByKey(ctx context.Context, key string) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, a := range m.attachments {
+		if a.Key == key || a.ThumbnailKey == key {
+			return a.PostID, nil
+		}
+	}
+
+	return 0, ErrNotFound
+}
+
+func (m *MockAttachmentStore) byPost(postID int64) []models.Attachment {
+	m.Lock()
+	defer m.Unlock()
+
+	attachments := []models.Attachment{}
+	for _, a := range m.attachments {
+		if a.PostID == postID {
+			attachments = append(attachments, a)
+		}
+	}
+
+	return attachments
+}
+
+// MockHashtagStore returns Trending as the trending hashtags and records the
+// window they were asked for.
+type MockHashtagStore struct {
+	Trending []models.TrendingTag
+	Window   time.Duration
+}
+
+func (m *MockHashtagStore) SetPostHashtags(ctx context.Context, postID int64, tags []string) error {
+	return nil
+}
+
+func (m *MockHashtagStore) AddCommentHashtags(ctx context.Context, commentID int64, tags []string) error {
+	return nil
+}
+
+func (m *MockHashtagStore) GetPosts(ctx context.Context, tag string, viewerID int64, fq PaginatedFeedQuery) (*[]models.PostWithMetadata, error) {
+	return &[]models.PostWithMetadata{}, nil
+}
+
+func (m *MockHashtagStore) GetTrending(ctx context.Context, window time.Duration, limit int) ([]models.TrendingTag, error) {
+	m.Window = window
+
+	return m.Trending[:min(limit, len(m.Trending))], nil
+}
+
+// MockConversationStore keeps the conversations and their messages in memory.
+// Users listed in Blocked can't take part in conversations.
+type MockConversationStore struct {
+	sync.Mutex
+	Blocked []int64
+
+	conversations []models.Conversation
+	messages      []models.Message
+}
+
+func (m *MockConversationStore) Create(ctx context.Context, conv *models.Conversation, participantIDs []int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, id := range participantIDs {
+		if slices.Contains(m.Blocked, id) {
+			return ErrBlocked
+		}
+	}
+
+	now := time.Now()
+	conv.ID = int64(len(m.conversations) + 1)
+	conv.CreatedAt = now
+	conv.UpdatedAt = now
+
+	for _, id := range append([]int64{conv.CreatorID}, participantIDs...) {
+		conv.Participants = append(conv.Participants, models.Participant{User: models.User{ID: id}, JoinedAt: now})
+	}
+
+	m.conversations = append(m.conversations, *conv)
+	return nil
+}
+
+func (m *MockConversationStore) GetDirect(ctx context.Context, userID int64, otherID int64) (*models.Conversation, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, c := range m.conversations {
+		if !c.IsGroup && m.isParticipant(c, userID) && m.isParticipant(c, otherID) {
+			return &c, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (m *MockConversationStore) GetByID(ctx context.Context, conversationID int64, userID int64) (*models.Conversation, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, c := range m.conversations {
+		if c.ID == conversationID && m.isParticipant(c, userID) {
+			return &c, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (m *MockConversationStore) GetByUserID(ctx context.Context, userID int64, cq CursorQuery) ([]models.Conversation, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	conversations := []models.Conversation{}
+
+	for _, c := range m.conversations {
+		if m.isParticipant(c, userID) {
+			conversations = append(conversations, c)
+		}
+	}
+
+	return conversations, nil
+}
+
+func (m *MockConversationStore) CreateMessage(ctx context.Context, msg *models.Message) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if msg.Status == "" {
+		msg.Status = models.StatusPublished
+	}
+
+	for i, c := range m.conversations {
+		if c.ID != msg.ConversationID || !m.isParticipant(c, msg.UserID) {
+			continue
+		}
+
+		msg.ID = int64(len(m.messages) + 1)
+		msg.CreatedAt = time.Now()
+		m.messages = append(m.messages, *msg)
+
+		m.conversations[i].LastMessage = msg
+		m.conversations[i].UpdatedAt = msg.CreatedAt
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+func (m *MockConversationStore) GetMessages(ctx context.Context, conversationID int64, viewerID int64, cq CursorQuery) ([]models.Message, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	messages := []models.Message{}
+
+	//? Most recent first, like the database
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].ConversationID == conversationID {
+			messages = append(messages, m.messages[i])
+		}
+	}
+
+	return messages[:min(cq.Limit, len(messages))], nil
+}
+
+func (m *MockConversationStore) MarkRead(ctx context.Context, conversationID int64, userID int64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, c := range m.conversations {
+		if c.ID == conversationID && m.isParticipant(c, userID) {
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (m *MockConversationStore) GetPendingMessages(ctx context.Context, fq PaginatedFeedQuery) ([]models.Message, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	messages := []models.Message{}
+	for _, msg := range m.messages {
+		if msg.Status == models.StatusPendingReview {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages[:min(fq.Limit, len(messages))], nil
+}
+
+func (m *MockConversationStore) SetMessageStatus(ctx context.Context, messageID int64, status string) (*models.Message, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	for i, msg := range m.messages {
+		if msg.ID == messageID && msg.Status == models.StatusPendingReview {
+			m.messages[i].Status = status
+			msg.Status = status
+			return &msg, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (m *MockConversationStore) isParticipant(c models.Conversation, userID int64) bool {
+	for _, p := range c.Participants {
+		if p.User.ID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MockLinkPreviewStore keeps the links of the posts and the previews in memory,
+// previews never expire.
+type MockLinkPreviewStore struct {
+	sync.Mutex
+	links    map[int64][]string
+	previews map[string]models.LinkPreview
+}
+
+func (m *MockLinkPreviewStore) SetPostLinks(ctx context.Context, postID int64, urls []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.links == nil {
+		m.links = map[int64][]string{}
+	}
+
+	m.links[postID] = urls
+	return nil
+}
+
+func (m *MockLinkPreviewStore) GetStale(ctx context.Context, urls []string, maxAge time.Duration) ([]string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	stale := []string{}
+	for _, url := range urls {
+		if _, ok := m.previews[url]; !ok {
+			stale = append(stale, url)
+		}
+	}
+
+	return stale, nil
+}
+
+func (m *MockLinkPreviewStore) Save(ctx context.Context, p *models.LinkPreview) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.previews == nil {
+		m.previews = map[string]models.LinkPreview{}
+	}
+
+	p.FetchedAt = time.Now()
+	m.previews[p.URL] = *p
+	return nil
+}
+
+func (m *MockLinkPreviewStore) GetByPostIDs(ctx context.Context, postIDs []int64) (map[int64][]models.LinkPreview, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	previews := map[int64][]models.LinkPreview{}
+	for _, id := range postIDs {
+		for _, url := range m.links[id] {
+			if p, ok := m.previews[url]; ok && !p.Failed {
+				previews[id] = append(previews[id], p)
+			}
+		}
+	}
+
+	return previews, nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+type MuteStore struct {
+	db *sql.DB
+}
+
+func (s *MuteStore) Mute(ctx context.Context, userID int64, mutedID int64) error {
+	query := `
+		INSERT INTO user_mutes (user_id, muted_id)
+		VALUES ($1, $2)
+	`
+	ctx, cancel := withQueryTimeout(ctx, "MuteStore.Mute")
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		userID,
+		mutedID,
+	)
+
+	if err != nil {
+		switch {
+		case IsDuplicateKeyError(err):
+			return ErrConflict
+		case IsForeignKeyError(err):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *MuteStore) Unmute(ctx context.Context, userID int64, mutedID int64) error {
+	query := `
+		DELETE FROM user_mutes
+		WHERE user_id = $1 AND muted_id = $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "MuteStore.Unmute")
+	defer cancel()
+
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		userID,
+		mutedID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetMutedIDs returns the users muted by the user.
+func (s *MuteStore) GetMutedIDs(ctx context.Context, userID int64) ([]int64, error) {
+	query := `
+		SELECT muted_id FROM user_mutes
+		WHERE user_id = $1
+	`
+	ctx, cancel := withQueryTimeout(ctx, "MuteStore.GetMutedIDs")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// IsMuted reports whether the user has muted the other one.
+func (s *MuteStore) IsMuted(ctx context.Context, userID int64, mutedID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM user_mutes
+			WHERE user_id = $1 AND muted_id = $2
+		)
+	`
+	ctx, cancel := withQueryTimeout(ctx, "MuteStore.IsMuted")
+	defer cancel()
+
+	var muted bool
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+		mutedID,
+	).Scan(&muted)
+
+	if err != nil {
+		return false, err
+	}
+
+	return muted, nil
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type NotificationPreferenceStore struct {
+	db *sql.DB
+}
+
+// Get returns the notification preferences of the user, or the defaults when
+// they were never changed.
+func (s *NotificationPreferenceStore) Get(ctx context.Context, userID int64) (*models.NotificationPreferences, error) {
+	query := `
+		SELECT user_id, new_follower, comment, mention
+		FROM notification_preferences
+		WHERE user_id = $1
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationPreferenceStore.Get")
+	defer cancel()
+
+	var prefs models.NotificationPreferences
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+	).Scan(
+		&prefs.UserID,
+		&prefs.NewFollower,
+		&prefs.Comment,
+		&prefs.Mention,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return models.DefaultNotificationPreferences(userID), nil
+		default:
+			return nil, err
+		}
+	}
+
+	return &prefs, nil
+}
+
+func (s *NotificationPreferenceStore) Update(ctx context.Context, prefs *models.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, new_follower, comment, mention)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET new_follower = EXCLUDED.new_follower, comment = EXCLUDED.comment, mention = EXCLUDED.mention, updated_at = NOW()
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationPreferenceStore.Update")
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		prefs.UserID,
+		prefs.NewFollower,
+		prefs.Comment,
+		prefs.Mention,
+	)
+
+	return err
+}
+
+// GetDueDigests returns up to limit active users with at least one daily
+// preference whose last digest is older than period.
+func (s *NotificationPreferenceStore) GetDueDigests(ctx context.Context, period time.Duration, limit int) ([]models.DigestRecipient, error) {
+	query := `
+		SELECT
+			np.user_id, np.new_follower, np.comment, np.mention, np.last_digest_at,
+			COALESCE(np.last_digest_at, NOW() - make_interval(secs => $1)),
+			u.username, u.email, u.language
+		FROM notification_preferences np
+		JOIN users u ON u.id = np.user_id
+		WHERE u.is_active = true
+		AND 'daily' IN (np.new_follower, np.comment, np.mention)
+		AND (np.last_digest_at IS NULL OR np.last_digest_at <= NOW() - make_interval(secs => $1))
+		ORDER BY np.last_digest_at NULLS FIRST
+		LIMIT $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationPreferenceStore.GetDueDigests")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		period.Seconds(),
+		limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := []models.DigestRecipient{}
+
+	for rows.Next() {
+		var r models.DigestRecipient
+
+		err := rows.Scan(
+			&r.Preferences.UserID,
+			&r.Preferences.NewFollower,
+			&r.Preferences.Comment,
+			&r.Preferences.Mention,
+			&r.LastDigestAt,
+			&r.Since,
+			&r.User.Username,
+			&r.User.Email,
+			&r.User.Language,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		r.User.ID = r.Preferences.UserID
+		recipients = append(recipients, r)
+	}
+
+	return recipients, rows.Err()
+}
+
+// CompleteDigest moves the last digest of the recipient to now and queues its
+// email, if there is one, in the same transaction. It returns ErrConflict when
+// the digest was completed by another instance in the meantime.
+func (s *NotificationPreferenceStore) CompleteDigest(ctx context.Context, recipient *models.DigestRecipient, mail *models.OutboxMail) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.advanceDigest(ctx, tx, recipient); err != nil {
+			return err
+		}
+
+		if mail == nil {
+			return nil
+		}
+
+		return enqueueMail(ctx, tx, mail)
+	})
+}
+
+func (s *NotificationPreferenceStore) advanceDigest(ctx context.Context, tx *sql.Tx, recipient *models.DigestRecipient) error {
+	query := `
+		UPDATE notification_preferences
+		SET last_digest_at = NOW()
+		WHERE user_id = $1 AND last_digest_at IS NOT DISTINCT FROM $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationPreferenceStore.advanceDigest")
+	defer cancel()
+
+	res, err := tx.ExecContext(
+		ctx,
+		query,
+		recipient.User.ID,
+		recipient.LastDigestAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	//? Another instance sent the digest since it was read
+	if rows == 0 {
+		return ErrConflict
+	}
+
+	return nil
+}
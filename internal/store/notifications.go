@@ -0,0 +1,194 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+)
+
+type NotificationStore struct {
+	db *sql.DB
+}
+
+// Create records the notification, or merges it into the unread notification
+// with the same group key. The actor is counted once per group.
+func (s *NotificationStore) Create(ctx context.Context, n *models.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, kind, group_key, actor_ids, actor_id, post_id)
+		VALUES ($1, $2, $3, ARRAY[$4::BIGINT], $4, $5)
+		ON CONFLICT (user_id, group_key) WHERE read_at IS NULL DO UPDATE
+		SET actor_ids = array_append(array_remove(notifications.actor_ids, EXCLUDED.actor_id), EXCLUDED.actor_id),
+			actor_id = EXCLUDED.actor_id,
+			updated_at = NOW()
+		RETURNING id, cardinality(actor_ids), created_at, updated_at
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationStore.Create")
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		n.UserID,
+		n.Kind,
+		n.GroupKey,
+		n.Actor.ID,
+		n.PostID,
+	).Scan(
+		&n.ID,
+		&n.ActorCount,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetByUserID returns a page of the user's notifications, the most recently
+// updated first. The cursor is on updated_at, which moves when a group gets a
+// new actor: a notification updated while paging jumps above the cursor and is
+// only seen again from the first page.
+func (s *NotificationStore) GetByUserID(ctx context.Context, userID int64, cq CursorQuery) ([]models.Notification, error) {
+	query := `
+		SELECT n.id, n.user_id, n.kind, cardinality(n.actor_ids), n.post_id, n.read_at, n.created_at, n.updated_at,
+			COALESCE(u.id, 0), COALESCE(u.username, '')
+		FROM notifications n
+		LEFT JOIN users u ON u.id = n.actor_id
+		WHERE n.user_id = $1
+		AND ($2::TIMESTAMPTZ IS NULL OR (n.updated_at, n.id) < ($2, $3))
+		ORDER BY n.updated_at DESC, n.id DESC
+		LIMIT $4
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationStore.GetByUserID")
+	defer cancel()
+
+	var after sql.NullTime
+	var afterID int64
+
+	if cq.Cursor != "" {
+		cursor, err := DecodeCursor(cq.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		after = sql.NullTime{Time: cursor.Time, Valid: true}
+		afterID = cursor.ID
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userID,
+		after,
+		afterID,
+		cq.Limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+
+	for rows.Next() {
+		var n models.Notification
+
+		err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.Kind,
+			&n.ActorCount,
+			&n.PostID,
+			&n.ReadAt,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+			&n.Actor.ID,
+			&n.Actor.Username,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (s *NotificationStore) CountUnread(ctx context.Context, userID int64) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationStore.CountUnread")
+	defer cancel()
+
+	var count int
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+	).Scan(&count)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (s *NotificationStore) MarkRead(ctx context.Context, userID int64, notificationID int64) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationStore.MarkRead")
+	defer cancel()
+
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		notificationID,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *NotificationStore) MarkAllRead(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE user_id = $1 AND read_at IS NULL
+	`
+	ctx, cancel := withQueryTimeout(ctx, "NotificationStore.MarkAllRead")
+	defer cancel()
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		userID,
+	)
+
+	return err
+}
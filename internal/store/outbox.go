@@ -0,0 +1,182 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type OutboxStore struct {
+	db *sql.DB
+}
+
+func (s *OutboxStore) Enqueue(ctx context.Context, mail *models.OutboxMail) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return enqueueMail(ctx, tx, mail)
+	})
+}
+
+// Claim locks up to limit emails that are due for delivery. Emails stuck in
+// sending for longer than the lease, e.g. because a worker crashed, are
+// claimed again.
+func (s *OutboxStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMail, error) {
+	query := `
+		UPDATE mail_outbox
+		SET status = 'sending', attempts = attempts + 1, locked_until = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM mail_outbox
+			WHERE (status = 'pending' AND next_attempt_at <= NOW())
+			OR (status = 'sending' AND locked_until < NOW())
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, template, username, email, locale, data, status, attempts, created_at
+	`
+	ctx, cancel := withQueryTimeout(ctx, "OutboxStore.Claim")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		limit,
+		lease.Seconds(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mails := []models.OutboxMail{}
+
+	for rows.Next() {
+		var m models.OutboxMail
+
+		err := rows.Scan(
+			&m.ID,
+			&m.Template,
+			&m.Username,
+			&m.Email,
+			&m.Locale,
+			&m.Data,
+			&m.Status,
+			&m.Attempts,
+			&m.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mails = append(mails, m)
+	}
+
+	return mails, rows.Err()
+}
+
+// MarkSent records the delivery of a claimed email. The claims of an email
+// are told apart by its attempts, ErrConflict is returned when the lease ran
+// out and the email was claimed again.
+func (s *OutboxStore) MarkSent(ctx context.Context, mail *models.OutboxMail) error {
+	query := `
+		UPDATE mail_outbox
+		SET status = 'sent', sent_at = NOW(), locked_until = NULL, last_error = NULL
+		WHERE id = $1 AND status = 'sending' AND attempts = $2
+	`
+
+	return s.update(ctx, query, mail.ID, mail.Attempts)
+}
+
+// MarkFailed puts the claimed email back in the queue to be retried at
+// retryAt.
+func (s *OutboxStore) MarkFailed(ctx context.Context, mail *models.OutboxMail, reason string, retryAt time.Time) error {
+	query := `
+		UPDATE mail_outbox
+		SET status = 'pending', last_error = $3, next_attempt_at = $4, locked_until = NULL
+		WHERE id = $1 AND status = 'sending' AND attempts = $2
+	`
+
+	return s.update(ctx, query, mail.ID, mail.Attempts, reason, retryAt)
+}
+
+// MarkDead moves the claimed email to the dead-letter status, it won't be
+// retried.
+func (s *OutboxStore) MarkDead(ctx context.Context, mail *models.OutboxMail, reason string) error {
+	query := `
+		UPDATE mail_outbox
+		SET status = 'dead', last_error = $3, locked_until = NULL
+		WHERE id = $1 AND status = 'sending' AND attempts = $2
+	`
+
+	return s.update(ctx, query, mail.ID, mail.Attempts, reason)
+}
+
+func (s *OutboxStore) update(ctx context.Context, query string, args ...any) error {
+	ctx, cancel := withQueryTimeout(ctx, "OutboxStore.update")
+	defer cancel()
+
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		args...,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	//? Another worker claimed the email once the lease ran out
+	if rows == 0 {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// enqueueMail writes the email to the outbox as part of the given transaction.
+func enqueueMail(ctx context.Context, tx *sql.Tx, mail *models.OutboxMail) error {
+	query := `
+		INSERT INTO mail_outbox (template, username, email, locale, data)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at
+	`
+	ctx, cancel := withQueryTimeout(ctx, "enqueueMail")
+	defer cancel()
+
+	data := mail.Data
+	if len(data) == 0 {
+		data = []byte("{}")
+	}
+
+	locale := mail.Locale
+	if locale == "" {
+		locale = "en"
+	}
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		mail.Template,
+		mail.Username,
+		mail.Email,
+		locale,
+		data,
+	).Scan(
+		&mail.ID,
+		&mail.Status,
+		&mail.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
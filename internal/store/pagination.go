@@ -1,12 +1,16 @@
 package store
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 type PaginatedFeedQuery struct {
 	Limit  int      `json:"limit" validate:"gte=1,lte=20"`
 	Offset int      `json:"offset" validate:"gte=0"`
@@ -75,3 +79,73 @@ func parseTime(s string) string {
 	}
 	return t.Format(time.DateTime)
 }
+
+// CursorQuery paginates lists that change while being read, such as the
+// notifications. Cursor is empty for the first page. Items that can only be
+// added are never skipped nor repeated, items sorted on a time that changes
+// can move above the cursor and are then missed by the next pages.
+type CursorQuery struct {
+	Limit  int    `json:"limit" validate:"gte=1,lte=50"`
+	Cursor string `json:"cursor"`
+}
+
+func (cq CursorQuery) Parse(r *http.Request) (CursorQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return cq, err
+		}
+
+		cq.Limit = l
+	}
+
+	cursor := qs.Get("cursor")
+	if cursor != "" {
+		if _, err := DecodeCursor(cursor); err != nil {
+			return cq, err
+		}
+
+		cq.Cursor = cursor
+	}
+
+	return cq, nil
+}
+
+// Cursor is the position of the last item of a page, sorted by time then ID.
+type Cursor struct {
+	Time time.Time
+	ID   int64
+}
+
+func EncodeCursor(t time.Time, id int64) string {
+	raw := t.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatInt(id, 10)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{Time: t, ID: n}, nil
+}
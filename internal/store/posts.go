@@ -16,13 +16,21 @@ type PostStore struct {
 
 func (s *PostStore) Create(ctx context.Context, post *models.Post) error {
 	query := `
-		INSERT INTO posts (content, title, user_id, tags)
-		VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at
+		INSERT INTO posts (content, title, user_id, tags, status, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "PostStore.Create")
 	defer cancel()
 
+	if post.Status == "" {
+		post.Status = models.StatusPublished
+	}
+
+	if post.Visibility == "" {
+		post.Visibility = models.VisibilityPublic
+	}
+
 	err := s.db.QueryRowContext(
 		ctx,
 		query,
@@ -30,6 +38,8 @@ func (s *PostStore) Create(ctx context.Context, post *models.Post) error {
 		post.Title,
 		post.UserID,
 		pq.Array(post.Tags),
+		post.Status,
+		post.Visibility,
 	).Scan(
 		&post.ID,
 		&post.CreatedAt,
@@ -45,11 +55,11 @@ func (s *PostStore) Create(ctx context.Context, post *models.Post) error {
 
 func (s *PostStore) GetByID(ctx context.Context, postID int64) (*models.Post, error) {
 	query := `
-		SELECT id, title, user_id, content, tags, created_at, updated_at, version
+		SELECT id, title, user_id, content, tags, created_at, updated_at, version, status, visibility
 		FROM posts
 		WHERE id = $1
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "PostStore.GetByID")
 	defer cancel()
 
 	var post models.Post
@@ -67,6 +77,8 @@ func (s *PostStore) GetByID(ctx context.Context, postID int64) (*models.Post, er
 		&post.CreatedAt,
 		&post.UpdatedAt,
 		&post.Version,
+		&post.Status,
+		&post.Visibility,
 	)
 
 	if err != nil {
@@ -78,6 +90,16 @@ func (s *PostStore) GetByID(ctx context.Context, postID int64) (*models.Post, er
 		}
 	}
 
+	attachments, err := getAttachments(ctx, s.db, []int64{post.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	post.Attachments = attachments[post.ID]
+	if post.Attachments == nil {
+		post.Attachments = []models.Attachment{}
+	}
+
 	return &post, nil
 }
 
@@ -87,7 +109,7 @@ func (s *PostStore) DeleteByID(ctx context.Context, postID int64) error {
 		WHERE id = $1 
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "PostStore.DeleteByID")
 	defer cancel()
 
 	res, err := s.db.ExecContext(
@@ -115,12 +137,12 @@ func (s *PostStore) DeleteByID(ctx context.Context, postID int64) error {
 func (s *PostStore) PatchPost(ctx context.Context, post *models.Post) error {
 	query := `
 		UPDATE posts
-		SET title = $2, content = $3, tags = $4, updated_at = NOW(), version = version + 1
+		SET title = $2, content = $3, tags = $4, status = $6, visibility = $7, updated_at = NOW(), version = version + 1
 		WHERE id = $1 AND version = $5
 		RETURNING created_at, updated_at, version
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "PostStore.PatchPost")
 	defer cancel()
 
 	err := s.db.QueryRowContext(
@@ -131,6 +153,8 @@ func (s *PostStore) PatchPost(ctx context.Context, post *models.Post) error {
 		post.Content,
 		pq.Array(post.Tags),
 		post.Version,
+		post.Status,
+		post.Visibility,
 	).Scan(
 		&post.CreatedAt,
 		&post.UpdatedAt,
@@ -154,13 +178,23 @@ func (s *PostStore) GetUserFeed(ctx context.Context, userID int64, fq PaginatedF
 
 	query := `
 		SELECT 
-			p.id, p.user_id, u.username, u.email, p.title, p.content, p.tags, COUNT(c.id) AS comments_count, p.created_at, p.version
+			p.id, p.user_id, u.username, u.email, p.title, p.content, p.tags, COUNT(c.id) AS comments_count, p.created_at, p.version, p.status, p.visibility
 		FROM posts p
-		LEFT JOIN comments c on c.post_id = p.id
+		LEFT JOIN comments c on c.post_id = p.id AND c.status = 'published'
 		LEFT JOIN users u on p.user_id = u.id
 		JOIN followers f on f.follower_id = p.user_id OR p.user_id = $1
 		WHERE (f.user_id = $1 OR p.user_id = $1) 
 	  	AND (p.title ILIKE $4 OR p.content ILIKE $4)
+		AND (p.status = 'published' OR p.user_id = $1)
+		AND (p.visibility IN ('public', 'followers') OR p.user_id = $1)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.user_id = $1 AND b.blocked_id = p.user_id) OR (b.user_id = p.user_id AND b.blocked_id = $1)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_mutes m
+			WHERE m.user_id = $1 AND m.muted_id = p.user_id
+		)
 	`
 
 	// Conditionally add the tags filtering logic
@@ -189,7 +223,7 @@ func (s *PostStore) GetUserFeed(ctx context.Context, userID int64, fq PaginatedF
 		args = append(args, pq.Array(fq.Tags))
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "PostStore.GetUserFeed")
 	defer cancel()
 
 	rows, err := s.db.QueryContext(
@@ -218,6 +252,8 @@ func (s *PostStore) GetUserFeed(ctx context.Context, userID int64, fq PaginatedF
 			&p.CommentCount,
 			&p.CreatedAt,
 			&p.Version,
+			&p.Status,
+			&p.Visibility,
 		)
 
 		if err != nil {
@@ -227,5 +263,197 @@ func (s *PostStore) GetUserFeed(ctx context.Context, userID int64, fq PaginatedF
 		feed = append(feed, p)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachToPosts(ctx, s.db, feed); err != nil {
+		return nil, err
+	}
+
 	return &feed, nil
 }
+
+// GetByUserID lists the posts of a single author. Posts held for review and
+// unlisted posts are only included when the viewer is the author, followers
+// only posts when the viewer follows the author.
+func (s *PostStore) GetByUserID(ctx context.Context, authorID int64, viewerID int64, fq PaginatedFeedQuery) (*[]models.PostWithMetadata, error) {
+	query := `
+		SELECT 
+			p.id, p.user_id, u.username, u.email, p.title, p.content, p.tags, COUNT(c.id) AS comments_count, p.created_at, p.version, p.status, p.visibility
+		FROM posts p
+		LEFT JOIN comments c on c.post_id = p.id AND c.status = 'published'
+		JOIN users u on p.user_id = u.id
+		WHERE p.user_id = $1
+		AND (p.status = 'published' OR p.user_id = $2)
+		AND (
+			p.user_id = $2
+			OR p.visibility = 'public'
+			OR (p.visibility = 'followers' AND EXISTS (
+				SELECT 1 FROM followers f WHERE f.user_id = $2 AND f.follower_id = p.user_id
+			))
+		)
+		AND (p.title ILIKE $5 OR p.content ILIKE $5)
+		GROUP BY p.id, u.username, u.email
+		ORDER BY p.created_at ` + fq.Sort + `
+		LIMIT $3 OFFSET $4
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "PostStore.GetByUserID")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		authorID,
+		viewerID,
+		fq.Limit,
+		fq.Offset,
+		fmt.Sprintf("%%%s%%", fq.Search),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []models.PostWithMetadata{}
+	for rows.Next() {
+		var p models.PostWithMetadata
+
+		err := rows.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.User.Username,
+			&p.User.Email,
+			&p.Title,
+			&p.Content,
+			pq.Array(&p.Tags),
+			&p.CommentCount,
+			&p.CreatedAt,
+			&p.Version,
+			&p.Status,
+			&p.Visibility,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachToPosts(ctx, s.db, posts); err != nil {
+		return nil, err
+	}
+
+	return &posts, nil
+}
+
+// GetPending returns a page of the posts held for review, the oldest first
+// unless sorted otherwise.
+func (s *PostStore) GetPending(ctx context.Context, fq PaginatedFeedQuery) ([]models.Post, error) {
+	query := `
+		SELECT p.id, p.user_id, u.username, p.title, p.content, p.tags, p.created_at, p.updated_at, p.version, p.status, p.visibility
+		FROM posts p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.status = 'pending_review'
+		ORDER BY p.created_at ` + fq.Sort + `, p.id ` + fq.Sort + `
+		LIMIT $1 OFFSET $2
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "PostStore.GetPending")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	ids := []int64{}
+
+	for rows.Next() {
+		var p models.Post
+
+		err := rows.Scan(
+			&p.ID,
+			&p.UserID,
+			&p.User.Username,
+			&p.Title,
+			&p.Content,
+			pq.Array(&p.Tags),
+			&p.CreatedAt,
+			&p.UpdatedAt,
+			&p.Version,
+			&p.Status,
+			&p.Visibility,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		p.User.ID = p.UserID
+		posts = append(posts, p)
+		ids = append(ids, p.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attachments, err := getAttachments(ctx, s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range posts {
+		posts[i].Attachments = attachments[posts[i].ID]
+		if posts[i].Attachments == nil {
+			posts[i].Attachments = []models.Attachment{}
+		}
+	}
+
+	return posts, nil
+}
+
+// SetStatus stores the status of the post once reviewed. ErrConflict is
+// returned when the post was edited since it was read.
+func (s *PostStore) SetStatus(ctx context.Context, post *models.Post) error {
+	query := `
+		UPDATE posts
+		SET status = $2, version = version + 1
+		WHERE id = $1 AND version = $3
+		RETURNING version
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "PostStore.SetStatus")
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		post.ID,
+		post.Status,
+		post.Version,
+	).Scan(
+		&post.Version,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TestPostVisibility runs against a migrated database, the users it creates
+// are deleted along with their posts, follows and blocks.
+func TestPostVisibility(t *testing.T) {
+	addr := os.Getenv("DB_ADDR")
+	if addr == "" {
+		t.Skip("DB_ADDR is not set")
+	}
+
+	ctx := context.Background()
+
+	db, err := sql.Open("postgres", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.PingContext(ctx); err != nil {
+		t.Skipf("postgres is unavailable: %v", err)
+	}
+
+	s := NewStorage(db)
+
+	suffix := time.Now().UnixNano()
+	ids := []int64{}
+
+	createUser := func(t *testing.T, name string, private bool) int64 {
+		t.Helper()
+
+		var id int64
+		err := db.QueryRowContext(ctx, `
+			INSERT INTO users (username, email, password, is_active, is_private, role_id)
+			VALUES ($1, $2, $3, TRUE, $4, (SELECT id FROM roles WHERE name = 'user'))
+			RETURNING id
+		`, fmt.Sprintf("%s%d", name, suffix), fmt.Sprintf("%s%d@test.com", name, suffix), []byte("password"), private).Scan(&id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, id)
+		return id
+	}
+
+	defer func() {
+		if _, err := db.ExecContext(ctx, `DELETE FROM users WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	publicAuthor := createUser(t, "public", false)
+	privateAuthor := createUser(t, "private", true)
+	stranger := createUser(t, "stranger", false)
+	follower := createUser(t, "follower", false)
+	blocked := createUser(t, "blocked", false)
+
+	// Each author writes a post of every visibility and one held for review,
+	// titled after the author and the visibility
+	for _, author := range []int64{publicAuthor, privateAuthor} {
+		for _, visibility := range []string{models.VisibilityPublic, models.VisibilityFollowers, models.VisibilityUnlisted} {
+			post := &models.Post{UserID: author, Title: fmt.Sprintf("%d-%s", author, visibility), Content: "content", Visibility: visibility}
+			if err := s.Posts.Create(ctx, post); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		held := &models.Post{UserID: author, Title: fmt.Sprintf("%d-held", author), Content: "content", Status: models.StatusPendingReview}
+		if err := s.Posts.Create(ctx, held); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, author := range []int64{publicAuthor, privateAuthor} {
+		for _, viewer := range []int64{follower, blocked} {
+			pending, err := s.Followers.Follow(ctx, viewer, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if pending {
+				if err := s.Followers.ApproveRequest(ctx, author, viewer); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		if err := s.Blocks.Block(ctx, author, blocked); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fq := PaginatedFeedQuery{Limit: 20, Sort: "desc"}
+
+	titles := func(t *testing.T, posts *[]models.PostWithMetadata) []string {
+		t.Helper()
+
+		got := []string{}
+		for _, p := range *posts {
+			got = append(got, p.Title)
+		}
+		sort.Strings(got)
+
+		return got
+	}
+
+	expect := func(t *testing.T, got []string, want ...string) {
+		t.Helper()
+
+		sort.Strings(want)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+
+	title := func(author int64, visibility string) string {
+		return fmt.Sprintf("%d-%s", author, visibility)
+	}
+
+	t.Run("feed", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			viewer int64
+			want   []string
+		}{
+			{
+				name:   "follower",
+				viewer: follower,
+				want: []string{
+					title(publicAuthor, models.VisibilityPublic),
+					title(publicAuthor, models.VisibilityFollowers),
+					title(privateAuthor, models.VisibilityPublic),
+					title(privateAuthor, models.VisibilityFollowers),
+				},
+			},
+			{
+				name:   "stranger",
+				viewer: stranger,
+				want:   []string{},
+			},
+			{
+				name:   "blocked",
+				viewer: blocked,
+				want:   []string{},
+			},
+			{
+				name:   "author",
+				viewer: publicAuthor,
+				want: []string{
+					title(publicAuthor, models.VisibilityPublic),
+					title(publicAuthor, models.VisibilityFollowers),
+					title(publicAuthor, models.VisibilityUnlisted),
+					title(publicAuthor, "held"),
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				posts, err := s.Posts.GetUserFeed(ctx, tt.viewer, fq)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				expect(t, titles(t, posts), tt.want...)
+			})
+		}
+	})
+
+	// The privacy of the author and the blocks are checked by the handler
+	// before listing the posts
+	t.Run("user posts", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			author int64
+			viewer int64
+			want   []string
+		}{
+			{
+				name:   "follower",
+				author: publicAuthor,
+				viewer: follower,
+				want: []string{
+					title(publicAuthor, models.VisibilityPublic),
+					title(publicAuthor, models.VisibilityFollowers),
+				},
+			},
+			{
+				name:   "stranger",
+				author: publicAuthor,
+				viewer: stranger,
+				want:   []string{title(publicAuthor, models.VisibilityPublic)},
+			},
+			{
+				name:   "follower of a private author",
+				author: privateAuthor,
+				viewer: follower,
+				want: []string{
+					title(privateAuthor, models.VisibilityPublic),
+					title(privateAuthor, models.VisibilityFollowers),
+				},
+			},
+			{
+				name:   "author",
+				author: publicAuthor,
+				viewer: publicAuthor,
+				want: []string{
+					title(publicAuthor, models.VisibilityPublic),
+					title(publicAuthor, models.VisibilityFollowers),
+					title(publicAuthor, models.VisibilityUnlisted),
+					title(publicAuthor, "held"),
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				posts, err := s.Posts.GetByUserID(ctx, tt.author, tt.viewer, fq)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				expect(t, titles(t, posts), tt.want...)
+			})
+		}
+	})
+}
@@ -17,7 +17,7 @@ func (r *RoleStore) GetByName(ctx context.Context, roleName string) (*models.Rol
 	WHERE name = $1
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "RoleStore.GetByName")
 	defer cancel()
 
 	role := &models.Role{}
@@ -11,6 +11,7 @@ import (
 var (
 	ErrNotFound = errors.New("resource not found")
 	ErrConflict = errors.New("resource conflict")
+	ErrBlocked  = errors.New("user is blocked")
 
 	// Duplicate check
 	ErrDuplicateUsername = errors.New("a user with that username already exists")
@@ -26,37 +27,134 @@ type Storage struct {
 		DeleteByID(context.Context, int64) error
 		PatchPost(context.Context, *models.Post) error
 		GetUserFeed(context.Context, int64, PaginatedFeedQuery) (*[]models.PostWithMetadata, error)
+		GetByUserID(context.Context, int64, int64, PaginatedFeedQuery) (*[]models.PostWithMetadata, error)
+		GetPending(context.Context, PaginatedFeedQuery) ([]models.Post, error)
+		SetStatus(context.Context, *models.Post) error
+	}
+	Attachments interface {
+		Create(context.Context, *models.Attachment, int) error
+		Delete(context.Context, int64, int64) (*models.Attachment, error)
+		GetPostIDByKey(context.Context, string) (int64, error)
 	}
 	Comments interface {
 		Create(context.Context, *models.Comment) error
-		GetByPostID(context.Context, int64) (*[]models.Comment, error)
+		GetByPostID(context.Context, int64, int64) (*[]models.Comment, error)
+		GetPending(context.Context, PaginatedFeedQuery) ([]models.Comment, error)
+		SetStatus(context.Context, int64, string) (*models.Comment, error)
 	}
 	Users interface {
 		Create(context.Context, *sql.Tx, *models.User) error
 
 		GetByID(context.Context, int64) (*models.User, error)
 		GetByEmail(context.Context, string) (*models.User, error)
-		CreateAndInvite(context.Context, *models.User, string, time.Duration) error
+		GetByUsernames(context.Context, []string) ([]models.User, error)
+		CreateAndInvite(context.Context, *models.User, string, time.Duration, *models.OutboxMail) error
+		UpdateSettings(context.Context, *models.User) ([]int64, error)
 		Delete(context.Context, int64) error
 
 		Activate(context.Context, string) error
 	}
 	Followers interface {
-		Follow(context.Context, int64, int64) error
+		Follow(context.Context, int64, int64) (bool, error)
 		UnFollow(context.Context, int64, int64) error
+		IsFollowing(context.Context, int64, int64) (bool, error)
+		GetFollowingIDs(context.Context, int64) ([]int64, error)
+		GetRequests(context.Context, int64) ([]models.FollowRequest, error)
+		ApproveRequest(context.Context, int64, int64) error
+		RejectRequest(context.Context, int64, int64) error
 	}
 	Roles interface {
 		GetByName(context.Context, string) (*models.Role, error)
 	}
+	Blocks interface {
+		Block(context.Context, int64, int64) error
+		Unblock(context.Context, int64, int64) error
+		IsBlocked(context.Context, int64, int64) (bool, error)
+		GetBlockedIDs(context.Context, int64) ([]int64, error)
+	}
+	Mutes interface {
+		Mute(context.Context, int64, int64) error
+		Unmute(context.Context, int64, int64) error
+		IsMuted(context.Context, int64, int64) (bool, error)
+		GetMutedIDs(context.Context, int64) ([]int64, error)
+	}
+	Suspensions interface {
+		Create(context.Context, *models.Suspension) error
+		Lift(context.Context, int64) error
+	}
+	Outbox interface {
+		Enqueue(context.Context, *models.OutboxMail) error
+		Claim(context.Context, int, time.Duration) ([]models.OutboxMail, error)
+		MarkSent(context.Context, *models.OutboxMail) error
+		MarkFailed(context.Context, *models.OutboxMail, string, time.Time) error
+		MarkDead(context.Context, *models.OutboxMail, string) error
+	}
+	NotificationPreferences interface {
+		Get(context.Context, int64) (*models.NotificationPreferences, error)
+		Update(context.Context, *models.NotificationPreferences) error
+		GetDueDigests(context.Context, time.Duration, int) ([]models.DigestRecipient, error)
+		CompleteDigest(context.Context, *models.DigestRecipient, *models.OutboxMail) error
+	}
+	Notifications interface {
+		Create(context.Context, *models.Notification) error
+		GetByUserID(context.Context, int64, CursorQuery) ([]models.Notification, error)
+		CountUnread(context.Context, int64) (int, error)
+		MarkRead(context.Context, int64, int64) error
+		MarkAllRead(context.Context, int64) error
+	}
+	Activity interface {
+		GetNewFollowers(context.Context, int64, time.Time) ([]models.Activity, error)
+		GetComments(context.Context, int64, time.Time) ([]models.Activity, error)
+		GetMentions(context.Context, int64, time.Time) ([]models.Activity, error)
+	}
+	Conversations interface {
+		Create(context.Context, *models.Conversation, []int64) error
+		GetDirect(context.Context, int64, int64) (*models.Conversation, error)
+		GetByID(context.Context, int64, int64) (*models.Conversation, error)
+		GetByUserID(context.Context, int64, CursorQuery) ([]models.Conversation, error)
+		CreateMessage(context.Context, *models.Message) error
+		GetMessages(context.Context, int64, int64, CursorQuery) ([]models.Message, error)
+		MarkRead(context.Context, int64, int64) error
+		GetPendingMessages(context.Context, PaginatedFeedQuery) ([]models.Message, error)
+		SetMessageStatus(context.Context, int64, string) (*models.Message, error)
+	}
+	Mentions interface {
+		SetPostMentions(context.Context, int64, []int64) ([]int64, error)
+		AddCommentMentions(context.Context, int64, []int64) error
+	}
+	LinkPreviews interface {
+		SetPostLinks(context.Context, int64, []string) error
+		GetStale(context.Context, []string, time.Duration) ([]string, error)
+		Save(context.Context, *models.LinkPreview) error
+		GetByPostIDs(context.Context, []int64) (map[int64][]models.LinkPreview, error)
+	}
+	Hashtags interface {
+		SetPostHashtags(context.Context, int64, []string) error
+		AddCommentHashtags(context.Context, int64, []string) error
+		GetPosts(context.Context, string, int64, PaginatedFeedQuery) (*[]models.PostWithMetadata, error)
+		GetTrending(context.Context, time.Duration, int) ([]models.TrendingTag, error)
+	}
 }
 
 func NewStorage(db *sql.DB) Storage {
 	return Storage{
-		Posts:     &PostStore{db: db},
-		Comments:  &CommentStore{db: db},
-		Users:     &UserStore{db: db},
-		Followers: &FollowerStore{db: db},
-		Roles:     &RoleStore{db: db},
+		Posts:                   &PostStore{db: db},
+		Attachments:             &AttachmentStore{db: db},
+		Comments:                &CommentStore{db: db},
+		Users:                   &UserStore{db: db},
+		Followers:               &FollowerStore{db: db},
+		Roles:                   &RoleStore{db: db},
+		Blocks:                  &BlockStore{db: db},
+		Mutes:                   &MuteStore{db: db},
+		Suspensions:             &SuspensionStore{db: db},
+		Outbox:                  &OutboxStore{db: db},
+		NotificationPreferences: &NotificationPreferenceStore{db: db},
+		Notifications:           &NotificationStore{db: db},
+		Activity:                &ActivityStore{db: db},
+		Conversations:           &ConversationStore{db: db},
+		Mentions:                &MentionStore{db: db},
+		Hashtags:                &HashtagStore{db: db},
+		LinkPreviews:            &LinkPreviewStore{db: db},
 	}
 }
 
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"SocialMedia/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// activeSuspensionJoin attaches the latest active suspension of a user as "s".
+// It is used by the user queries so the suspension travels with the user.
+const activeSuspensionJoin = `
+	LEFT JOIN LATERAL (
+		SELECT id, moderator_id, reason, expires_at, created_at FROM user_suspensions
+		WHERE user_id = users.id AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at DESC NULLS FIRST
+		LIMIT 1
+	) s ON true
+`
+
+type SuspensionStore struct {
+	db *sql.DB
+}
+
+func (s *SuspensionStore) Create(ctx context.Context, suspension *models.Suspension) error {
+	query := `
+		INSERT INTO user_suspensions (user_id, moderator_id, reason, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	ctx, cancel := withQueryTimeout(ctx, "SuspensionStore.Create")
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		suspension.UserID,
+		suspension.ModeratorID,
+		suspension.Reason,
+		suspension.ExpiresAt,
+	).Scan(
+		&suspension.ID,
+		&suspension.CreatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Lift ends every active suspension of the user.
+func (s *SuspensionStore) Lift(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE user_suspensions
+		SET lifted_at = NOW()
+		WHERE user_id = $1 AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`
+	ctx, cancel := withQueryTimeout(ctx, "SuspensionStore.Lift")
+	defer cancel()
+
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		userID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// suspensionScanner holds the nullable columns of activeSuspensionJoin.
+type suspensionScanner struct {
+	id          sql.NullInt64
+	moderatorID sql.NullInt64
+	reason      sql.NullString
+	expiresAt   sql.NullTime
+	createdAt   sql.NullString
+}
+
+func (sc *suspensionScanner) suspension(userID int64) *models.Suspension {
+	if !sc.id.Valid {
+		return nil
+	}
+
+	var expiresAt *time.Time
+	if sc.expiresAt.Valid {
+		expiresAt = &sc.expiresAt.Time
+	}
+
+	return &models.Suspension{
+		ID:          sc.id.Int64,
+		UserID:      userID,
+		ModeratorID: sc.moderatorID.Int64,
+		Reason:      sc.reason.String,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   sc.createdAt.String,
+	}
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"SocialMedia/internal/logging"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("SocialMedia/internal/store")
+
+// slowQueryThreshold is the time after which the queries of a store method are
+// logged, with the logger of the request.
+const slowQueryThreshold = 500 * time.Millisecond
+
+// withQueryTimeout bounds the queries of a store method by QueryTimeoutDuration
+// and traces them in a span named after the method. The span ends with cancel.
+func withQueryTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	start := time.Now()
+
+	ctx, span := tracer.Start(
+		ctx,
+		method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+
+	return ctx, func() {
+		cancel()
+		span.End()
+
+		if elapsed := time.Since(start); elapsed > slowQueryThreshold {
+			if logger, ok := logging.FromContext(ctx); ok {
+				logger.Warnw("slow query", "method", method, "duration", elapsed)
+			}
+		}
+	}
+}
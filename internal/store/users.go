@@ -18,11 +18,11 @@ type UserStore struct {
 
 func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, user *models.User) error {
 	query := `
-		INSERT INTO users (username, password, email, role_id)
-		VALUES ($1, $2, $3, ( SELECT id FROM roles where name = $4 )) 
+		INSERT INTO users (username, password, email, language, role_id)
+		VALUES ($1, $2, $3, $4, ( SELECT id FROM roles where name = $5 )) 
 		RETURNING id, created_at, is_active
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.Create")
 	defer cancel()
 
 	// Default any new user as regular user if not specified
@@ -31,12 +31,18 @@ func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, user *models.User) e
 		role = "user"
 	}
 
+	language := user.Language
+	if language == "" {
+		language = "en"
+	}
+
 	err := tx.QueryRowContext(
 		ctx,
 		query,
 		user.Username,
 		user.Password.Hash,
 		user.Email,
+		language,
 		role,
 	).Scan(
 		&user.ID,
@@ -44,6 +50,8 @@ func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, user *models.User) e
 		&user.IsActive,
 	)
 
+	user.Language = language
+
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Constraint {
@@ -60,7 +68,9 @@ func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, user *models.User) e
 	return nil
 }
 
-func (s *UserStore) CreateAndInvite(ctx context.Context, user *models.User, token string, invitationExp time.Duration) error {
+// CreateAndInvite creates the user along with the invitation and queues the
+// invitation email in the outbox, all in the same transaction.
+func (s *UserStore) CreateAndInvite(ctx context.Context, user *models.User, token string, invitationExp time.Duration, mail *models.OutboxMail) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
 		if err := s.Create(ctx, tx, user); err != nil {
 			return err
@@ -70,20 +80,28 @@ func (s *UserStore) CreateAndInvite(ctx context.Context, user *models.User, toke
 			return err
 		}
 
+		if err := enqueueMail(ctx, tx, mail); err != nil {
+			return err
+		}
+
 		return nil
 	})
 }
 
 func (s *UserStore) GetByID(ctx context.Context, userID int64) (*models.User, error) {
 	query := `
-		SELECT users.id, username, email, password, is_active, created_at, roles.id, roles.name, roles.level, roles.description FROM users
+		SELECT users.id, username, email, password, is_active, is_private, language, users.created_at, roles.id, roles.name, roles.level, roles.description,
+			s.id, s.moderator_id, s.reason, s.expires_at, s.created_at
+		FROM users
 		JOIN roles ON (users.role_id = roles.id)
+	` + activeSuspensionJoin + `
 		WHERE users.id = $1 AND is_active = true
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.GetByID")
 	defer cancel()
 
 	var user models.User
+	var suspension suspensionScanner
 
 	err := s.db.QueryRowContext(
 		ctx,
@@ -95,11 +113,18 @@ func (s *UserStore) GetByID(ctx context.Context, userID int64) (*models.User, er
 		&user.Email,
 		&user.Password.Hash,
 		&user.IsActive,
+		&user.IsPrivate,
+		&user.Language,
 		&user.CreatedAt,
 		&user.Role.ID,
 		&user.Role.Name,
 		&user.Role.Level,
 		&user.Role.Description,
+		&suspension.id,
+		&suspension.moderatorID,
+		&suspension.reason,
+		&suspension.expiresAt,
+		&suspension.createdAt,
 	)
 
 	if err != nil {
@@ -111,18 +136,24 @@ func (s *UserStore) GetByID(ctx context.Context, userID int64) (*models.User, er
 		}
 	}
 
+	user.Suspension = suspension.suspension(user.ID)
+
 	return &user, nil
 }
 
 func (s *UserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at FROM users
+		SELECT users.id, username, email, password, users.created_at,
+			s.id, s.moderator_id, s.reason, s.expires_at, s.created_at
+		FROM users
+	` + activeSuspensionJoin + `
 		WHERE email = $1 AND is_active = true
 	`
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.GetByEmail")
 	defer cancel()
 
 	var user models.User
+	var suspension suspensionScanner
 
 	err := s.db.QueryRowContext(
 		ctx,
@@ -134,6 +165,11 @@ func (s *UserStore) GetByEmail(ctx context.Context, email string) (*models.User,
 		&user.Email,
 		&user.Password.Hash,
 		&user.CreatedAt,
+		&suspension.id,
+		&suspension.moderatorID,
+		&suspension.reason,
+		&suspension.expiresAt,
+		&suspension.createdAt,
 	)
 
 	if err != nil {
@@ -145,9 +181,57 @@ func (s *UserStore) GetByEmail(ctx context.Context, email string) (*models.User,
 		}
 	}
 
+	user.Suspension = suspension.suspension(user.ID)
+
 	return &user, nil
 }
 
+// GetByUsernames returns the active users among the given usernames, unknown
+// usernames are ignored.
+func (s *UserStore) GetByUsernames(ctx context.Context, usernames []string) ([]models.User, error) {
+	query := `
+		SELECT id, username, email, is_private, language, created_at
+		FROM users
+		WHERE username = ANY($1) AND is_active = true
+	`
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.GetByUsernames")
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		pq.Array(usernames),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+
+	for rows.Next() {
+		var user models.User
+
+		err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.IsPrivate,
+			&user.Language,
+			&user.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
 func (s *UserStore) Activate(ctx context.Context, token string) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
 		// 1. find the user that this token belongs to.
@@ -178,7 +262,7 @@ func (s *UserStore) Delete(ctx context.Context, userID int64) error {
 		WHERE id = $1
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.Delete")
 	defer cancel()
 
 	_, err := s.db.ExecContext(
@@ -200,6 +284,106 @@ func (s *UserStore) Delete(ctx context.Context, userID int64) error {
 	return nil
 }
 
+// UpdateSettings persists the account settings a user can change themselves.
+// UpdateSettings saves the settings of the user. Making the account public
+// approves its pending follow requests in the same transaction, the IDs of the
+// users who now follow it are returned.
+func (s *UserStore) UpdateSettings(ctx context.Context, user *models.User) ([]int64, error) {
+	approved := []int64{}
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.updateSettings(ctx, tx, user); err != nil {
+			return err
+		}
+
+		if user.IsPrivate {
+			return nil
+		}
+
+		var err error
+		approved, err = s.approveFollowRequests(ctx, tx, user.ID)
+		return err
+	})
+
+	return approved, err
+}
+
+func (s *UserStore) updateSettings(ctx context.Context, tx *sql.Tx, user *models.User) error {
+	query := `
+		UPDATE users
+		SET is_private = $1, language = $2
+		WHERE id = $3
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.updateSettings")
+	defer cancel()
+
+	res, err := tx.ExecContext(
+		ctx,
+		query,
+		user.IsPrivate,
+		user.Language,
+		user.ID,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *UserStore) approveFollowRequests(ctx context.Context, tx *sql.Tx, userID int64) ([]int64, error) {
+	query := `
+		WITH approved AS (
+			DELETE FROM follow_requests
+			WHERE target_id = $1
+			RETURNING user_id
+		)
+		INSERT INTO followers (user_id, follower_id)
+		SELECT user_id, $1 FROM approved
+		ON CONFLICT DO NOTHING
+		RETURNING user_id
+	`
+
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.approveFollowRequests")
+	defer cancel()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		query,
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 func (s *UserStore) getUserFromInvitations(ctx context.Context, tx *sql.Tx, token string) (*models.User, error) {
 	query := `
 		SELECT u.id, u.username, u.email, u.is_active, u.created_at 
@@ -208,7 +392,7 @@ func (s *UserStore) getUserFromInvitations(ctx context.Context, tx *sql.Tx, toke
 		WHERE ui.token = $1 AND ui.expiry > $2
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.getUserFromInvitations")
 	defer cancel()
 
 	user := &models.User{}
@@ -248,7 +432,7 @@ func (s *UserStore) createUserInvitation(ctx context.Context, tx *sql.Tx, token
 		VALUES ($1, $2, $3)
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.createUserInvitation")
 	defer cancel()
 
 	//? Need to use tx since this will be running in a transaction
@@ -274,7 +458,7 @@ func (s *UserStore) update(ctx context.Context, tx *sql.Tx, user *models.User) e
 		WHERE id = $4
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.update")
 	defer cancel()
 
 	_, err := tx.ExecContext(
@@ -304,7 +488,7 @@ func (s *UserStore) deleteUserInvitations(ctx context.Context, tx *sql.Tx, userI
 		WHERE user_id = $1
 	`
 
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	ctx, cancel := withQueryTimeout(ctx, "UserStore.deleteUserInvitations")
 	defer cancel()
 
 	_, err := tx.ExecContext(
@@ -0,0 +1,147 @@
+// Package textparse extracts the @mentions, #hashtags and links of the content
+// written by the users.
+package textparse
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxHashtagLength is the longest hashtag kept, longer ones are ignored
+	MaxHashtagLength = 100
+	// MaxURLLength is the longest link kept, longer ones are ignored
+	MaxURLLength = 2048
+)
+
+var (
+	// The marker must start the text or follow a separator, so emails, URL
+	// paths and fragments or HTML entities are not picked up
+	mentionRegex = regexp.MustCompile(`(?:^|[^\w/@#&])@(\w+)`)
+	hashtagRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_/@#&])#([\p{L}\p{N}_]+)`)
+	urlRegex     = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"'\x60]+`)
+)
+
+// Entities are what was found in a piece of content, without duplicates and in
+// order of appearance.
+type Entities struct {
+	// Usernames, as written
+	Mentions []string
+	// Normalized hashtags, without the leading #
+	Hashtags []string
+	// Absolute http and https links
+	URLs []string
+}
+
+func Parse(text string) Entities {
+	return Entities{
+		Mentions: Mentions(text),
+		Hashtags: Hashtags(text),
+		URLs:     URLs(text),
+	}
+}
+
+// Mentions returns the distinct usernames mentioned in the text.
+func Mentions(text string) []string {
+	seen := map[string]bool{}
+	mentions := []string{}
+
+	for _, m := range mentionRegex.FindAllStringSubmatch(text, -1) {
+		if seen[m[1]] {
+			continue
+		}
+
+		seen[m[1]] = true
+		mentions = append(mentions, m[1])
+	}
+
+	return mentions
+}
+
+// Hashtags returns the distinct hashtags of the text, normalized.
+func Hashtags(text string) []string {
+	seen := map[string]bool{}
+	hashtags := []string{}
+
+	for _, m := range hashtagRegex.FindAllStringSubmatch(text, -1) {
+		tag, ok := NormalizeHashtag(m[1])
+		if !ok || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		hashtags = append(hashtags, tag)
+	}
+
+	return hashtags
+}
+
+// URLs returns the distinct links of the text. The punctuation ending a
+// sentence is not part of the link, nor is a closing parenthesis that wasn't
+// opened in it.
+func URLs(text string) []string {
+	seen := map[string]bool{}
+	urls := []string{}
+
+	for _, raw := range urlRegex.FindAllString(text, -1) {
+		raw = trimURL(raw)
+		if len(raw) > MaxURLLength || seen[raw] {
+			continue
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+
+		seen[raw] = true
+		urls = append(urls, raw)
+	}
+
+	return urls
+}
+
+func trimURL(raw string) string {
+	for raw != "" {
+		last := raw[len(raw)-1]
+
+		switch {
+		case strings.IndexByte(".,;:!?*", last) >= 0:
+		case last == ')' && strings.Count(raw, "(") < strings.Count(raw, ")"):
+		case last == ']' && strings.Count(raw, "[") < strings.Count(raw, "]"):
+		default:
+			return raw
+		}
+
+		raw = raw[:len(raw)-1]
+	}
+
+	return raw
+}
+
+// NormalizeHashtag lower cases the tag and strips its leading #. It reports
+// whether the result is a valid hashtag: letters, digits and underscores with
+// at least one letter, so "#1" is not a hashtag.
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxHashtagLength {
+		return "", false
+	}
+
+	hasLetter := false
+	for _, r := range tag {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r) || r == '_':
+		default:
+			return "", false
+		}
+	}
+
+	return tag, hasLetter
+}
@@ -0,0 +1,76 @@
+package textparse
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		mentions []string
+		hashtags []string
+	}{
+		{"plain text has no entities", "hello gophers", []string{}, []string{}},
+		{"mentions and hashtags are found", "@alice meet @bob #Go #golang", []string{"alice", "bob"}, []string{"go", "golang"}},
+		{"duplicates are dropped", "@alice @alice #go #GO", []string{"alice"}, []string{"go"}},
+		{"punctuation ends an entity", "(@alice), #go!", []string{"alice"}, []string{"go"}},
+		{"emails are not mentions", "mail me at gopher@example.com", []string{}, []string{}},
+		{"links are not hashtags", "see https://example.com/page#section and https://example.com/@alice", []string{}, []string{}},
+		{"html entities are not hashtags", "it&#39;s", []string{}, []string{}},
+		{"numbers are not hashtags", "issue #42 in #go2", []string{}, []string{"go2"}},
+		{"hashtags can be in any language", "#café #日本", []string{}, []string{"café", "日本"}},
+		{"glued markers are ignored", "#a#b @a@b", []string{"a"}, []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Parse(tt.text)
+
+			if !slices.Equal(e.Mentions, tt.mentions) {
+				t.Errorf("expected mentions %v, got %v", tt.mentions, e.Mentions)
+			}
+
+			if !slices.Equal(e.Hashtags, tt.hashtags) {
+				t.Errorf("expected hashtags %v, got %v", tt.hashtags, e.Hashtags)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	if tag, ok := NormalizeHashtag(" #GoLang "); !ok || tag != "golang" {
+		t.Errorf("expected golang, got %q (%v)", tag, ok)
+	}
+
+	for _, tag := range []string{"", "#", "42", "go-lang", "go lang"} {
+		if _, ok := NormalizeHashtag(tag); ok {
+			t.Errorf("expected %q to be invalid", tag)
+		}
+	}
+}
+
+func TestURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"plain text has no links", "hello gophers", []string{}},
+		{"links are found", "read https://go.dev/doc and http://example.com", []string{"https://go.dev/doc", "http://example.com"}},
+		{"duplicates are dropped", "https://go.dev https://go.dev", []string{"https://go.dev"}},
+		{"punctuation ends a link", "see https://go.dev/blog, or https://go.dev/doc.", []string{"https://go.dev/blog", "https://go.dev/doc"}},
+		{"parentheses are kept when balanced", "(https://en.wikipedia.org/wiki/Go_(language))", []string{"https://en.wikipedia.org/wiki/Go_(language)"}},
+		{"queries and fragments are kept", "https://example.com/a?b=1&c=2#d", []string{"https://example.com/a?b=1&c=2#d"}},
+		{"other schemes are ignored", "ftp://example.com javascript:alert(1) https://", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := URLs(tt.text); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package unfurl
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// parseHead reads the meta tags and the title of the page, keyed by their
+// property or name in lower case. It stops at the body, the metadata is never
+// after it.
+func parseHead(r io.Reader) (map[string]string, string) {
+	z := html.NewTokenizer(r)
+
+	meta := map[string]string{}
+	title := ""
+	inTitle := false
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			// End of the page or of what is read of it
+			return meta, title
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+
+			switch string(name) {
+			case "body":
+				return meta, title
+			case "title":
+				inTitle = true
+			case "meta":
+				var key, content string
+
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+
+					switch string(k) {
+					case "property", "name":
+						if key == "" {
+							key = strings.ToLower(strings.TrimSpace(string(v)))
+						}
+					case "content":
+						content = string(v)
+					}
+				}
+
+				// The first value wins, like the crawlers of the main platforms
+				if _, ok := meta[key]; key != "" && !ok {
+					meta[key] = content
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+
+			switch string(name) {
+			case "head":
+				return meta, title
+			case "title":
+				inTitle = false
+			}
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(z.Text())
+			}
+		}
+	}
+}
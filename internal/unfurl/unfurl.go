@@ -0,0 +1,195 @@
+// Package unfurl fetches the OpenGraph and Twitter card metadata of the links
+// shared by the users so they can be shown as previews.
+//
+// The pages are fetched on behalf of the server, so only public addresses can
+// be reached: the check is done on every address dialed, after the DNS
+// resolution, which covers redirects and DNS rebinding.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Longest values kept, longer ones are truncated
+const (
+	MaxTitleLength       = 300
+	MaxDescriptionLength = 1000
+	MaxSiteNameLength    = 100
+	MaxImageURLLength    = 2048
+)
+
+var (
+	ErrInvalidURL       = errors.New("unfurl: only absolute http and https links can be unfurled")
+	ErrForbiddenAddress = errors.New("unfurl: destination address is not allowed")
+	ErrNotHTML          = errors.New("unfurl: not an html page")
+	ErrNoMetadata       = errors.New("unfurl: page has no preview metadata")
+)
+
+type Preview struct {
+	// URL is the link as it was shared, not where it redirected to
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+type Options struct {
+	// Timeout of the whole fetch, redirects included
+	Timeout time.Duration
+	// MaxBodySize is how much of the page is read, the metadata is in the head
+	MaxBodySize  int64
+	MaxRedirects int
+	UserAgent    string
+	// AllowPrivateNetworks disables the protection against requests to the
+	// internal network. It is only meant for tests.
+	AllowPrivateNetworks bool
+}
+
+type Unfurler struct {
+	opts   Options
+	client *http.Client
+}
+
+func New(opts Options) *Unfurler {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 512 << 10
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = 3
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "GoSocialBot/1.0 (+link preview)"
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = guard
+	}
+
+	transport := &http.Transport{
+		//? A proxy would be dialed instead of the destination, bypassing the guard
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return fmt.Errorf("unfurl: stopped after %d redirects", opts.MaxRedirects)
+			}
+
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrInvalidURL
+			}
+
+			return nil
+		},
+	}
+
+	return &Unfurler{opts: opts, client: client}
+}
+
+// Unfurl fetches the page and returns its preview. Pages without a title nor
+// a description have no preview.
+func (u *Unfurler) Unfurl(ctx context.Context, rawURL string) (*Preview, error) {
+	link, err := url.Parse(rawURL)
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Hostname() == "" {
+		return nil, ErrInvalidURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", u.opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) {
+			return nil, ErrForbiddenAddress
+		}
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("unfurl: %s responded %s", link.Host, res.Status)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return nil, ErrNotHTML
+	}
+
+	body, err := charset.NewReader(io.LimitReader(res.Body, u.opts.MaxBodySize), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, title := parseHead(body)
+
+	preview := &Preview{
+		URL:         rawURL,
+		Title:       clean(first(meta["og:title"], meta["twitter:title"], title), MaxTitleLength),
+		Description: clean(first(meta["og:description"], meta["twitter:description"], meta["description"]), MaxDescriptionLength),
+		SiteName:    clean(meta["og:site_name"], MaxSiteNameLength),
+	}
+
+	if preview.Title == "" && preview.Description == "" {
+		return nil, ErrNoMetadata
+	}
+
+	// Images are relative to the page they were found on, after the redirects
+	image := first(meta["og:image:secure_url"], meta["og:image"], meta["og:image:url"], meta["twitter:image"], meta["twitter:image:src"])
+	if ref, err := res.Request.URL.Parse(strings.TrimSpace(image)); err == nil && image != "" {
+		if (ref.Scheme == "http" || ref.Scheme == "https") && len(ref.String()) <= MaxImageURLLength {
+			preview.ImageURL = ref.String()
+		}
+	}
+
+	return preview, nil
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// clean collapses the whitespace and truncates the value to limit runes.
+func clean(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	return strings.TrimSpace(string([]rune(s)[:limit-1])) + "…"
+}
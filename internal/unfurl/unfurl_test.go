@@ -0,0 +1,208 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Fallback title</title>
+	<meta property="og:title" content="The Go Programming Language">
+	<meta property="og:description" content="Build simple, secure, scalable systems with Go &amp; friends.">
+	<meta property="og:image" content="/images/gopher.png">
+	<meta property="og:site_name" content="Go">
+	<meta property="og:title" content="A second title is ignored">
+</head>
+<body><title>Not the page title</title></body>
+</html>`
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>  Just a
+			title </title><meta name="description" content="And a description"></head></html>`))
+	})
+	mux.HandleFunc("/latin1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("<html><head><title>Caf\xe9</title></head></html>"))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"title":"not a page"}`))
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><!--" + strings.Repeat("x", 64<<10) + `--><title>Too far</title></head></html>`))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/og", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestUnfurl(t *testing.T) {
+	srv := newServer(t)
+	ctx := context.Background()
+
+	u := New(Options{
+		Timeout:              500 * time.Millisecond,
+		MaxBodySize:          16 << 10,
+		AllowPrivateNetworks: true,
+	})
+
+	t.Run("should read the opengraph metadata", func(t *testing.T) {
+		p, err := u.Unfurl(ctx, srv.URL+"/og")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := Preview{
+			URL:         srv.URL + "/og",
+			Title:       "The Go Programming Language",
+			Description: "Build simple, secure, scalable systems with Go & friends.",
+			ImageURL:    srv.URL + "/images/gopher.png",
+			SiteName:    "Go",
+		}
+		if *p != expected {
+			t.Errorf("expected %+v, got %+v", expected, *p)
+		}
+	})
+
+	t.Run("should fall back to the title and description", func(t *testing.T) {
+		p, err := u.Unfurl(ctx, srv.URL+"/plain")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Title != "Just a title" || p.Description != "And a description" {
+			t.Errorf("unexpected preview %+v", *p)
+		}
+	})
+
+	t.Run("should decode the page charset", func(t *testing.T) {
+		p, err := u.Unfurl(ctx, srv.URL+"/latin1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Title != "Café" {
+			t.Errorf("expected Café, got %q", p.Title)
+		}
+	})
+
+	t.Run("should keep the shared link after a redirect", func(t *testing.T) {
+		p, err := u.Unfurl(ctx, srv.URL+"/redirect")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.URL != srv.URL+"/redirect" || p.Title != "The Go Programming Language" {
+			t.Errorf("unexpected preview %+v", *p)
+		}
+	})
+
+	t.Run("should only unfurl html pages", func(t *testing.T) {
+		if _, err := u.Unfurl(ctx, srv.URL+"/json"); !errors.Is(err, ErrNotHTML) {
+			t.Errorf("expected ErrNotHTML, got %v", err)
+		}
+	})
+
+	t.Run("should not read past the size limit", func(t *testing.T) {
+		if _, err := u.Unfurl(ctx, srv.URL+"/huge"); !errors.Is(err, ErrNoMetadata) {
+			t.Errorf("expected ErrNoMetadata, got %v", err)
+		}
+	})
+
+	t.Run("should give up on slow servers", func(t *testing.T) {
+		start := time.Now()
+		if _, err := u.Unfurl(ctx, srv.URL+"/slow"); err == nil {
+			t.Error("expected the request to time out")
+		}
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the request to stop after the timeout, took %s", elapsed)
+		}
+	})
+
+	t.Run("should stop redirect loops", func(t *testing.T) {
+		if _, err := u.Unfurl(ctx, srv.URL+"/loop"); err == nil {
+			t.Error("expected the redirects to be limited")
+		}
+	})
+
+	t.Run("should reject other schemes", func(t *testing.T) {
+		for _, link := range []string{"file:///etc/passwd", "gopher://example.com", "/relative", "https://"} {
+			if _, err := u.Unfurl(ctx, link); !errors.Is(err, ErrInvalidURL) {
+				t.Errorf("expected %q to be rejected, got %v", link, err)
+			}
+		}
+	})
+}
+
+func TestUnfurlPrivateNetworks(t *testing.T) {
+	srv := newServer(t)
+	u := New(Options{Timeout: 500 * time.Millisecond})
+
+	// The test server listens on the loopback
+	for _, link := range []string{srv.URL + "/og", strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/og"} {
+		if _, err := u.Unfurl(context.Background(), link); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("expected %s to be forbidden, got %v", link, err)
+		}
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublic(netip.MustParseAddr(tt.ip)); got != tt.public {
+			t.Errorf("expected %s public to be %v", tt.ip, tt.public)
+		}
+	}
+}